@@ -60,6 +60,45 @@ type PlanClient interface {
 	GetResellerAuthorizations(ctx context.Context, query wireformat.ResellerAuthorizationQuery) ([]wireformat.ResellerAuthorization, error)
 }
 
+// PlanMetadataSaver is implemented by the plan clients able to save the
+// metadata of a plan revision along with its definition.
+type PlanMetadataSaver interface {
+	// SaveWithMetadata uploads a new plan to the plans service, with the
+	// description, price and release notes of the new revision.
+	SaveWithMetadata(ctx context.Context, planURL, definition string, metadata wireformat.PlanMetadata) (*wireformat.Plan, error)
+}
+
+// SaveWithMetadata uploads a new plan with the metadata of the new
+// revision, using the SaveWithMetadata method of the client when it
+// implements PlanMetadataSaver. Other clients can only save plans without
+// metadata.
+func SaveWithMetadata(ctx context.Context, client PlanClient, planURL, definition string, metadata wireformat.PlanMetadata) (*wireformat.Plan, error) {
+	if saver, ok := client.(PlanMetadataSaver); ok {
+		return saver.SaveWithMetadata(ctx, planURL, definition, metadata)
+	}
+	if metadata != (wireformat.PlanMetadata{}) {
+		return nil, errors.NotSupportedf("saving plan metadata with %T", client)
+	}
+	return client.Save(ctx, planURL, definition)
+}
+
+// Authenticator is implemented by the plan clients able to authenticate
+// with the plans service without performing another operation.
+type Authenticator interface {
+	// Login obtains the discharged macaroons required by the
+	// operations of the plans service that need authentication.
+	Login(ctx context.Context) error
+}
+
+// Login authenticates with the plans service, using the Login method of
+// the client when it implements Authenticator.
+func Login(ctx context.Context, client PlanClient) error {
+	if a, ok := client.(Authenticator); ok {
+		return a.Login(ctx)
+	}
+	return errors.NotSupportedf("logging in with %T", client)
+}
+
 // headerName is the name of the header the handler will look for in incoming requests.
 const headerName = "X-Request-ID"
 
@@ -85,6 +124,12 @@ var _ PlanClient = (*client)(nil)
 type client struct {
 	plansService string
 	client       httpClient
+	dialect      wireformat.Dialect
+
+	// strict and maxResponseSize are set by StrictResponses and
+	// MaxResponseSize.
+	strict          bool
+	maxResponseSize int64
 }
 
 // ClientOption defines a function which configures a Client.
@@ -99,6 +144,19 @@ func HTTPClient(c httpClient) func(h *client) error {
 	}
 }
 
+// WireDialect returns a function that sets the dialect in which the client
+// sends the fields renamed in Juju 2.0. The default is
+// wireformat.DialectLegacy.
+func WireDialect(d wireformat.Dialect) ClientOption {
+	return func(h *client) error {
+		if err := d.Validate(); err != nil {
+			return errors.Trace(err)
+		}
+		h.dialect = d
+		return nil
+	}
+}
+
 // NewPlanClient returns a new client for plan management.
 func NewPlanClient(url string, options ...ClientOption) (*client, error) {
 	c := &client{
@@ -150,11 +208,13 @@ func (c *client) Release(ctx context.Context, planID string) (*wireformat.Plan,
 	}
 
 	var plan wireformat.Plan
-	decoder := json.NewDecoder(response.Body)
-	err = decoder.Decode(&plan)
+	err = c.decode("release plan", response, &plan)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	if err := c.checkPlans("release plan", pID.String(), plan); err != nil {
+		return nil, errors.Trace(err)
+	}
 
 	return &plan, nil
 }
@@ -199,7 +259,7 @@ func (c *client) suspendResume(ctx context.Context, operation, planURL string, a
 	response, err := c.client.Do(req)
 	if err != nil {
 		if strings.Contains(err.Error(), "refused discharge") {
-			return errors.Errorf(`unauthorized to %s plan: please run "charm whoami" to verify you are member of the %q group`, operation, pURL.Owner)
+			return errors.Errorf(`unauthorized to %s plan: please run "charm-plans whoami" to verify you are member of the %q group`, operation, pURL.Owner)
 		}
 
 		return errors.Annotatef(err, "failed to %v the plan", operation)
@@ -217,6 +277,13 @@ func (c *client) suspendResume(ctx context.Context, operation, planURL string, a
 // Save stores the rating plan definition (definition - plan definition yaml) under a
 // specified name (planURL).
 func (c *client) Save(ctx context.Context, planURL string, definition string) (*wireformat.Plan, error) {
+	return c.SaveWithMetadata(ctx, planURL, definition, wireformat.PlanMetadata{})
+}
+
+// SaveWithMetadata stores the rating plan definition under a specified name
+// (planURL), along with the description, price and release notes of the new
+// revision.
+func (c *client) SaveWithMetadata(ctx context.Context, planURL string, definition string, metadata wireformat.PlanMetadata) (*wireformat.Plan, error) {
 	pURL, err := wireformat.ParsePlanURL(planURL)
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -226,7 +293,13 @@ func (c *client) Save(ctx context.Context, planURL string, definition string) (*
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	plan := wireformat.Plan{URL: planURL, Definition: definition}
+	plan := wireformat.Plan{
+		URL:             planURL,
+		Definition:      definition,
+		PlanDescription: metadata.Description,
+		PlanPrice:       metadata.Price,
+		ReleaseNotes:    metadata.ReleaseNotes,
+	}
 
 	payload := &bytes.Buffer{}
 	err = json.NewEncoder(payload).Encode(plan)
@@ -244,7 +317,7 @@ func (c *client) Save(ctx context.Context, planURL string, definition string) (*
 	response, err := c.client.Do(req)
 	if err != nil {
 		if strings.Contains(err.Error(), "refused discharge") {
-			return nil, errors.Errorf(`unauthorized to save the plan: please run "charm whoami" to verify you are member of the %q group`, pURL.Owner)
+			return nil, errors.Errorf(`unauthorized to save the plan: please run "charm-plans whoami" to verify you are member of the %q group`, pURL.Owner)
 		}
 		return nil, errors.Annotate(err, "failed to save the plan")
 	}
@@ -256,11 +329,13 @@ func (c *client) Save(ctx context.Context, planURL string, definition string) (*
 	}
 
 	var planResult wireformat.Plan
-	decoder := json.NewDecoder(response.Body)
-	err = decoder.Decode(&planResult)
+	err = c.decode("save plan", response, &planResult)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	if err := c.checkPlans("save plan", planURL, planResult); err != nil {
+		return nil, errors.Trace(err)
+	}
 
 	return &planResult, nil
 }
@@ -304,7 +379,7 @@ func (c *client) AddCharm(ctx context.Context, planURL string, charmURL string,
 	response, err := c.client.Do(req)
 	if err != nil {
 		if strings.Contains(err.Error(), "refused discharge") {
-			return errors.Errorf(`unauthorized to add charm: please run "charm whoami" to verify you are member of the %q group`, pURL.Owner)
+			return errors.Errorf(`unauthorized to add charm: please run "charm-plans whoami" to verify you are member of the %q group`, pURL.Owner)
 		}
 		return errors.Annotate(err, "failed to add charm")
 	}
@@ -346,11 +421,13 @@ func (c *client) Get(ctx context.Context, planURL string) ([]wireformat.Plan, er
 	}
 
 	var plans []wireformat.Plan
-	decoder := json.NewDecoder(response.Body)
-	err = decoder.Decode(&plans)
+	err = c.decode("retrieve plans", response, &plans)
 	if err != nil {
 		return nil, errors.Annotatef(err, "failed to unmarshal the response")
 	}
+	if err := c.checkPlans("retrieve plans", planURL, plans...); err != nil {
+		return nil, errors.Trace(err)
+	}
 	return plans, nil
 }
 
@@ -378,11 +455,13 @@ func (c *client) GetPlans(ctx context.Context, owner string) ([]wireformat.Plan,
 	}
 
 	var plans []wireformat.Plan
-	decoder := json.NewDecoder(response.Body)
-	err = decoder.Decode(&plans)
+	err = c.decode("retrieve plans", response, &plans)
 	if err != nil {
 		return nil, errors.Annotatef(err, "failed to unmarshal the response")
 	}
+	if err := c.checkPlans("retrieve plans", "", plans...); err != nil {
+		return nil, errors.Trace(err)
+	}
 	sort.Slice(plans, func(i, j int) bool {
 		return plans[i].Id > plans[j].Id
 	})
@@ -413,7 +492,7 @@ func (c *client) GetPlanRevisions(ctx context.Context, plan string) ([]wireforma
 	response, err := c.client.Do(req)
 	if err != nil {
 		if strings.Contains(err.Error(), "refused discharge") {
-			return nil, errors.Errorf(`unauthorized to retrieve plan revisions: please run "charm whoami" to verify you are member of the %q group`, planID.Owner)
+			return nil, errors.Errorf(`unauthorized to retrieve plan revisions: please run "charm-plans whoami" to verify you are member of the %q group`, planID.Owner)
 		}
 		return nil, errors.Annotate(err, "failed to retrieve plan revisions")
 	}
@@ -424,11 +503,13 @@ func (c *client) GetPlanRevisions(ctx context.Context, plan string) ([]wireforma
 	}
 
 	var plans []wireformat.Plan
-	decoder := json.NewDecoder(response.Body)
-	err = decoder.Decode(&plans)
+	err = c.decode("retrieve plan revisions", response, &plans)
 	if err != nil {
 		return nil, errors.Annotatef(err, "failed to unmarshal the response")
 	}
+	if err := c.checkPlans("retrieve plan revisions", planID.PlanURL.String(), plans...); err != nil {
+		return nil, errors.Trace(err)
+	}
 	return plans, nil
 }
 
@@ -460,11 +541,13 @@ func (c *client) GetDefaultPlan(ctx context.Context, charmURL string) (*wireform
 	}
 
 	var plan wireformat.Plan
-	dec := json.NewDecoder(response.Body)
-	err = dec.Decode(&plan)
+	err = c.decode("retrieve default plan", response, &plan)
 	if err != nil {
 		return nil, errors.Annotatef(err, "failed to unmarshal response")
 	}
+	if err := c.checkPlans("retrieve default plan", "", plan); err != nil {
+		return nil, errors.Trace(err)
+	}
 	return &plan, nil
 }
 
@@ -496,11 +579,13 @@ func (c *client) GetPlansForCharm(ctx context.Context, charmURL string) ([]wiref
 	}
 
 	var plans []wireformat.Plan
-	dec := json.NewDecoder(response.Body)
-	err = dec.Decode(&plans)
+	err = c.decode("retrieve associated plans", response, &plans)
 	if err != nil {
 		return nil, errors.Annotatef(err, "failed to unmarshal response")
 	}
+	if err := c.checkPlans("retrieve associated plans", "", plans...); err != nil {
+		return nil, errors.Trace(err)
+	}
 	return plans, nil
 }
 
@@ -530,7 +615,7 @@ func (c *client) GetPlanDetails(ctx context.Context, planURL string) (*wireforma
 	response, err := c.client.Do(req)
 	if err != nil {
 		if strings.Contains(err.Error(), "refused discharge") {
-			return nil, errors.Errorf(`unauthorized to retrieve plan details: please run "charm whoami" to verify you are member of the %q group`, purl.Owner)
+			return nil, errors.Errorf(`unauthorized to retrieve plan details: please run "charm-plans whoami" to verify you are member of the %q group`, purl.Owner)
 		}
 		return nil, errors.Annotate(err, "failed to retrieve plan details")
 	}
@@ -542,11 +627,13 @@ func (c *client) GetPlanDetails(ctx context.Context, planURL string) (*wireforma
 	}
 
 	var plan wireformat.PlanDetails
-	decoder := json.NewDecoder(response.Body)
-	err = decoder.Decode(&plan)
+	err = c.decode("retrieve plan details", response, &plan)
 	if err != nil {
 		return nil, errors.Annotatef(err, "failed to unmarshal the response")
 	}
+	if err := c.checkPlans("retrieve plan details", purl.PlanURL.String(), plan.Plan); err != nil {
+		return nil, errors.Trace(err)
+	}
 	return &plan, nil
 }
 
@@ -564,14 +651,12 @@ func (c *client) Authorize(ctx context.Context, environmentUUID, charmURL, servi
 		PlanURL:         planURL,
 	}
 
-	buff := &bytes.Buffer{}
-	encoder := json.NewEncoder(buff)
-	err = encoder.Encode(auth)
+	data, err := auth.MarshalJSONDialect(c.dialect)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
 
-	req, err := http.NewRequest("POST", u.String(), bytes.NewReader(buff.Bytes()))
+	req, err := http.NewRequest("POST", u.String(), bytes.NewReader(data))
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -590,8 +675,7 @@ func (c *client) Authorize(ctx context.Context, environmentUUID, charmURL, servi
 	}
 
 	var m *macaroon.Macaroon
-	decoder := json.NewDecoder(response.Body)
-	err = decoder.Decode(&m)
+	err = c.decode("authorize plan", response, &m)
 	if err != nil {
 		return nil, errors.Annotatef(err, "failed to unmarshal the response")
 	}
@@ -609,9 +693,15 @@ func (c *client) GetAuthorizations(ctx context.Context, query wireformat.Authori
 	q.Set("authorization-id", query.AuthorizationID)
 	q.Set("user", query.User)
 	q.Set("plan-url", query.PlanURL)
-	q.Set("env-uuid", query.EnvironmentUUID)
+	if c.dialect.EmitsLegacy() {
+		q.Set("env-uuid", query.EnvironmentUUID)
+		q.Set("service-name", query.ServiceName)
+	}
+	if c.dialect.EmitsJuju2() {
+		q.Set("model-uuid", query.EnvironmentUUID)
+		q.Set("application", query.ServiceName)
+	}
 	q.Set("charm-url", query.CharmURL)
-	q.Set("service-name", query.ServiceName)
 	q.Set("include-plan", strconv.FormatBool(query.IncludePlan))
 	q.Set("statement-period", query.StatementPeriod)
 	u.RawQuery = q.Encode()
@@ -636,15 +726,53 @@ func (c *client) GetAuthorizations(ctx context.Context, query wireformat.Authori
 		return nil, errors.Trace(err)
 	}
 
-	var auths []wireformat.Authorization
-	dec := json.NewDecoder(response.Body)
-	err = dec.Decode(&auths)
+	auths, err := c.decodeAuthorizations("retrieve authorizations", response)
 	if err != nil {
 		return nil, errors.Annotatef(err, "failed to unmarshal response")
 	}
+	if query.IncludePlan {
+		plans := make([]wireformat.Plan, len(auths))
+		for i, a := range auths {
+			plans[i] = wireformat.Plan{Id: a.PlanID, URL: a.PlanURL, Definition: a.PlanDefinition}
+		}
+		if err := c.checkPlans("retrieve authorizations", query.PlanURL, plans...); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
 	return auths, nil
 }
 
+// loginAuthorizationID is the id of the authorization looked up by Login,
+// which is never issued by the plans service.
+const loginAuthorizationID = "00000000-0000-0000-0000-000000000000"
+
+// Login implements Authenticator. Looking up an authorization by id
+// requires authentication, so the request obtains a discharge from the
+// identity manager, while the lookup itself is cheap and matches nothing.
+func (c *client) Login(ctx context.Context) error {
+	u, err := url.Parse(c.plansService + "/v3/plan/authorization")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	u.RawQuery = url.Values{"authorization-id": {loginAuthorizationID}}.Encode()
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return errors.Annotate(err, "failed to create GET request")
+	}
+	req = requestWithId(ctx, req)
+
+	response, err := c.client.Do(req)
+	if err != nil {
+		return errors.Annotate(err, "failed to log in")
+	}
+	defer discardClose(response)
+
+	if response.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return errors.Trace(unmarshalError("log in", response))
+}
+
 // AuthorizeReseller returns the reseller authorization macaroon for the specified application.
 func (c *client) AuthorizeReseller(ctx context.Context, plan, charm, application, applicationOwner, applicationUser string) (*macaroon.Macaroon, error) {
 	u, err := url.Parse(c.plansService + "/v3/plan/reseller/authorize")
@@ -686,8 +814,7 @@ func (c *client) AuthorizeReseller(ctx context.Context, plan, charm, application
 	}
 
 	var m *macaroon.Macaroon
-	decoder := json.NewDecoder(response.Body)
-	err = decoder.Decode(&m)
+	err = c.decode("authorize reseller plan", response, &m)
 	if err != nil {
 		return nil, errors.Annotatef(err, "failed to unmarshal the response")
 	}
@@ -742,11 +869,22 @@ func (c *client) GetResellerAuthorizations(ctx context.Context, query wireformat
 	}
 
 	var auths []wireformat.ResellerAuthorization
-	dec := json.NewDecoder(response.Body)
-	err = dec.Decode(&auths)
+	err = c.decode("retrieve reseller authorizations", response, &auths)
 	if err != nil {
 		return nil, errors.Annotatef(err, "failed to unmarshal response")
 	}
+	if query.IncludePlan {
+		plans := make([]wireformat.Plan, len(auths))
+		for i, a := range auths {
+			plans[i] = wireformat.Plan{Id: a.PlanID, URL: a.Plan, Definition: a.PlanDefinition}
+			if id, err := wireformat.ParsePlanIDWithOptionalRevision(a.Plan); err == nil {
+				plans[i].URL = id.PlanURL.String()
+			}
+		}
+		if err := c.checkPlans("retrieve reseller authorizations", "", plans...); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
 	return auths, nil
 }
 
@@ -754,14 +892,15 @@ func discardClose(response *http.Response) {
 	if response == nil || response.Body == nil {
 		return
 	}
-	io.Copy(ioutil.Discard, response.Body)
+	// Oversized bodies are not drained: the connection is not reused.
+	io.Copy(ioutil.Discard, io.LimitReader(response.Body, maxErrorSize))
 	response.Body.Close()
 }
 
 func unmarshalError(action string, response *http.Response) error {
 	if response.StatusCode != http.StatusOK {
 		id := idHeader(response)
-		data, err := ioutil.ReadAll(response.Body)
+		data, err := ioutil.ReadAll(io.LimitReader(response.Body, maxErrorSize))
 		if err != nil {
 			return errors.Errorf("failed to %s: received status code %d [ID:%v]", action, response.StatusCode, id)
 		}
@@ -71,6 +71,51 @@ func (s *clientIntegrationSuite) TestSave(c *gc.C) {
 	})
 }
 
+func (s *clientIntegrationSuite) TestSaveMetadata(c *gc.C) {
+	s.httpClient.status = http.StatusOK
+	s.httpClient.body = wireformat.Plan{
+		Id:  "testisv/default/2",
+		URL: "testisv/default",
+	}
+
+	_, err := api.SaveWithMetadata(context.Background(), s.planClient, "testisv/default", testPlan, wireformat.PlanMetadata{
+		Description:  "a test plan",
+		Price:        "1.00 USD per unit",
+		ReleaseNotes: "lower the price",
+	})
+	c.Assert(err, jc.ErrorIsNil)
+
+	s.httpClient.assertRequest(c, "POST", "/v3/p", wireformat.Plan{
+		URL:             "testisv/default",
+		Definition:      testPlan,
+		PlanDescription: "a test plan",
+		PlanPrice:       "1.00 USD per unit",
+		ReleaseNotes:    "lower the price",
+	})
+}
+
+func (s *clientIntegrationSuite) TestSaveMetadataNotSupported(c *gc.C) {
+	// A client only implementing PlanClient cannot save the metadata.
+	client := struct{ api.PlanClient }{s.planClient}
+	_, err := api.SaveWithMetadata(context.Background(), client, "testisv/default", testPlan, wireformat.PlanMetadata{
+		Description: "a test plan",
+	})
+	c.Assert(err, jc.Satisfies, errors.IsNotSupported)
+	c.Assert(s.httpClient.requestMethod, gc.Equals, "")
+
+	s.httpClient.status = http.StatusOK
+	s.httpClient.body = wireformat.Plan{
+		Id:  "testisv/default/2",
+		URL: "testisv/default",
+	}
+	_, err = api.SaveWithMetadata(context.Background(), client, "testisv/default", testPlan, wireformat.PlanMetadata{})
+	c.Assert(err, jc.ErrorIsNil)
+	s.httpClient.assertRequest(c, "POST", "/v3/p", wireformat.Plan{
+		URL:        "testisv/default",
+		Definition: testPlan,
+	})
+}
+
 func (s *clientIntegrationSuite) TestSaveFail(c *gc.C) {
 	s.httpClient.status = http.StatusBadRequest
 	s.httpClient.body = struct {
@@ -89,7 +134,7 @@ func (s *clientIntegrationSuite) TestSaveUnauthorized(c *gc.C) {
 	s.httpClient.SetErrors(errors.New("refused discharge: unauthorized"))
 
 	_, err := s.planClient.Save(context.Background(), "testisv/default", testPlan)
-	c.Assert(err, gc.ErrorMatches, `unauthorized to save the plan: please run "charm whoami" to verify you are member of the "testisv" group`)
+	c.Assert(err, gc.ErrorMatches, `unauthorized to save the plan: please run "charm-plans whoami" to verify you are member of the "testisv" group`)
 }
 
 func (s *clientIntegrationSuite) TestRelease(c *gc.C) {
@@ -230,7 +275,7 @@ func (s *clientIntegrationSuite) TestResumeUnauthorized(c *gc.C) {
 	s.httpClient.SetErrors(errors.New("refused discharge: unauthorized"))
 
 	err := s.planClient.Resume(context.Background(), "testisv/default", true)
-	c.Assert(err, gc.ErrorMatches, `unauthorized to resume plan: please run "charm whoami" to verify you are member of the "testisv" group`)
+	c.Assert(err, gc.ErrorMatches, `unauthorized to resume plan: please run "charm-plans whoami" to verify you are member of the "testisv" group`)
 }
 
 func (s *clientIntegrationSuite) TestAddCharm(c *gc.C) {
@@ -253,7 +298,7 @@ func (s *clientIntegrationSuite) TestAddCharmUnauthorized(c *gc.C) {
 	s.httpClient.SetErrors(errors.New("refused discharge: unauthorized"))
 
 	err := s.planClient.AddCharm(context.Background(), "testisv/default", "cs:~testers/charm1-0", true)
-	c.Assert(err, gc.ErrorMatches, `unauthorized to add charm: please run "charm whoami" to verify you are member of the "testisv" group`)
+	c.Assert(err, gc.ErrorMatches, `unauthorized to add charm: please run "charm-plans whoami" to verify you are member of the "testisv" group`)
 }
 
 func (s *clientIntegrationSuite) TestAddCharmFail(c *gc.C) {
@@ -486,7 +531,7 @@ func (s *clientIntegrationSuite) TestGetPlanDetailsUnauthorized(c *gc.C) {
 	s.httpClient.SetErrors(errors.New("refused discharge: unauthorized"))
 
 	_, err := s.planClient.GetPlanDetails(context.Background(), "testisv/default")
-	c.Assert(err, gc.ErrorMatches, `unauthorized to retrieve plan details: please run "charm whoami" to verify you are member of the "testisv" group`)
+	c.Assert(err, gc.ErrorMatches, `unauthorized to retrieve plan details: please run "charm-plans whoami" to verify you are member of the "testisv" group`)
 }
 
 func (s *clientIntegrationSuite) TestGetPlanDetailsNotFound(c *gc.C) {
@@ -514,7 +559,7 @@ func (s *clientIntegrationSuite) TestSuspendUnauthorized(c *gc.C) {
 	s.httpClient.SetErrors(errors.New("refused discharge: unauthorized"))
 
 	err := s.planClient.Suspend(context.Background(), "testisv/default", true)
-	c.Assert(err, gc.ErrorMatches, `unauthorized to suspend plan: please run "charm whoami" to verify you are member of the "testisv" group`)
+	c.Assert(err, gc.ErrorMatches, `unauthorized to suspend plan: please run "charm-plans whoami" to verify you are member of the "testisv" group`)
 }
 
 func (s *clientIntegrationSuite) TestGetPlanRevisions(c *gc.C) {
@@ -554,7 +599,7 @@ func (s *clientIntegrationSuite) TestGetPlanRevisionsUnauthorized(c *gc.C) {
 	s.httpClient.SetErrors(errors.New("refused discharge: unauthorized"))
 
 	_, err := s.planClient.GetPlanRevisions(context.Background(), "testisv/default")
-	c.Assert(err, gc.ErrorMatches, `unauthorized to retrieve plan revisions: please run "charm whoami" to verify you are member of the "testisv" group`)
+	c.Assert(err, gc.ErrorMatches, `unauthorized to retrieve plan revisions: please run "charm-plans whoami" to verify you are member of the "testisv" group`)
 }
 
 func (s *clientIntegrationSuite) TestAuthorize(c *gc.C) {
@@ -576,6 +621,81 @@ func (s *clientIntegrationSuite) TestAuthorize(c *gc.C) {
 	})
 }
 
+func (s *clientIntegrationSuite) TestAuthorizeWireDialect(c *gc.C) {
+	m, err := macaroon.New([]byte{}, "abc", "")
+	c.Assert(err, jc.ErrorIsNil)
+	s.httpClient.status = http.StatusOK
+	s.httpClient.body = m
+
+	client, err := api.NewPlanClient("", api.HTTPClient(s.httpClient), api.WireDialect(wireformat.DialectJuju2))
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = client.Authorize(context.Background(), "envUUID", "cs:~testers/charm1-0", "test-service", "testisv/default")
+	c.Assert(err, jc.ErrorIsNil)
+	s.httpClient.assertRequest(c, "POST", "/v3/plan/authorize", map[string]string{
+		"model-uuid":  "envUUID",
+		"charm-url":   "cs:~testers/charm1-0",
+		"application": "test-service",
+		"plan-url":    "testisv/default",
+	})
+}
+
+func (s *clientIntegrationSuite) TestLogin(c *gc.C) {
+	for _, status := range []int{http.StatusOK, http.StatusNotFound} {
+		s.httpClient.status = status
+		err := api.Login(context.Background(), s.planClient)
+		c.Assert(err, jc.ErrorIsNil)
+		s.httpClient.assertRequest(c, "GET", "/v3/plan/authorization?authorization-id=00000000-0000-0000-0000-000000000000", nil)
+	}
+
+	s.httpClient.status = http.StatusBadRequest
+	s.httpClient.body = struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}{
+		Code:    "bad request",
+		Message: "silly error",
+	}
+	err := api.Login(context.Background(), s.planClient)
+	c.Assert(err, gc.ErrorMatches, `failed to log in.*: silly error`)
+
+	err = api.Login(context.Background(), struct{ api.PlanClient }{s.planClient})
+	c.Assert(err, jc.Satisfies, errors.IsNotSupported)
+}
+
+func (s *clientIntegrationSuite) TestGetAuthorizationsWireDialect(c *gc.C) {
+	tests := []struct {
+		dialect wireformat.Dialect
+		url     string
+	}{{
+		dialect: wireformat.DialectLegacy,
+		url:     "/v3/plan/authorization?authorization-id=&charm-url=&env-uuid=model&include-plan=false&plan-url=&service-name=app&statement-period=&user=",
+	}, {
+		dialect: wireformat.DialectCompat,
+		url:     "/v3/plan/authorization?application=app&authorization-id=&charm-url=&env-uuid=model&include-plan=false&model-uuid=model&plan-url=&service-name=app&statement-period=&user=",
+	}, {
+		dialect: wireformat.DialectJuju2,
+		url:     "/v3/plan/authorization?application=app&authorization-id=&charm-url=&include-plan=false&model-uuid=model&plan-url=&statement-period=&user=",
+	}}
+	for i, t := range tests {
+		c.Logf("test %d: %v", i, t.dialect)
+		s.httpClient.status = http.StatusOK
+		s.httpClient.body = []wireformat.Authorization{}
+		client, err := api.NewPlanClient("", api.HTTPClient(s.httpClient), api.WireDialect(t.dialect))
+		c.Assert(err, jc.ErrorIsNil)
+		_, err = client.GetAuthorizations(context.Background(), wireformat.AuthorizationQuery{
+			EnvironmentUUID: "model",
+			ServiceName:     "app",
+		})
+		c.Assert(err, jc.ErrorIsNil)
+		s.httpClient.assertRequest(c, "GET", t.url, nil)
+	}
+}
+
+func (s *clientIntegrationSuite) TestInvalidWireDialect(c *gc.C) {
+	_, err := api.NewPlanClient("", api.WireDialect(wireformat.Dialect(42)))
+	c.Assert(err, gc.ErrorMatches, `wire dialect 42 not valid`)
+}
+
 func (s *clientIntegrationSuite) TestAuthorizeFail(c *gc.C) {
 	s.httpClient.status = http.StatusBadRequest
 	s.httpClient.body = struct {
@@ -0,0 +1,336 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/utils"
+	"gopkg.in/macaroon-bakery.v2/bakery/checkers"
+	"gopkg.in/macaroon.v1"
+
+	"github.com/juju/plans-client/api/wireformat"
+)
+
+// DefaultExpiryMargin is the default time before the expiry of a cached
+// authorization macaroon after which it is no longer reused.
+const DefaultExpiryMargin = 5 * time.Minute
+
+// AuthorizationKey identifies an authorization request.
+type AuthorizationKey struct {
+	EnvironmentUUID string `json:"env-uuid"`
+	CharmURL        string `json:"charm-url"`
+	ServiceName     string `json:"service-name"`
+	PlanURL         string `json:"plan-url"`
+}
+
+// AuthorizationStore stores authorization macaroons.
+type AuthorizationStore interface {
+	// Get returns the macaroon stored for the key, or an error
+	// satisfying errors.IsNotFound if there is none.
+	Get(key AuthorizationKey) (*macaroon.Macaroon, error)
+	// Put stores the macaroon for the key.
+	Put(key AuthorizationKey, m *macaroon.Macaroon) error
+	// Remove removes the macaroon stored for the key, if any.
+	Remove(key AuthorizationKey) error
+	// RemoveAll removes all the stored macaroons.
+	RemoveAll() error
+}
+
+// NewMemoryAuthorizationStore returns an AuthorizationStore holding the
+// macaroons in memory.
+func NewMemoryAuthorizationStore() AuthorizationStore {
+	return &memoryStore{
+		macaroons: make(map[AuthorizationKey]*macaroon.Macaroon),
+	}
+}
+
+type memoryStore struct {
+	mu        sync.Mutex
+	macaroons map[AuthorizationKey]*macaroon.Macaroon
+}
+
+// Get implements AuthorizationStore.Get.
+func (s *memoryStore) Get(key AuthorizationKey) (*macaroon.Macaroon, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.macaroons[key]
+	if !ok {
+		return nil, errors.NotFoundf("authorization")
+	}
+	return m.Clone(), nil
+}
+
+// Put implements AuthorizationStore.Put.
+func (s *memoryStore) Put(key AuthorizationKey, m *macaroon.Macaroon) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.macaroons[key] = m.Clone()
+	return nil
+}
+
+// Remove implements AuthorizationStore.Remove.
+func (s *memoryStore) Remove(key AuthorizationKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.macaroons, key)
+	return nil
+}
+
+// RemoveAll implements AuthorizationStore.RemoveAll.
+func (s *memoryStore) RemoveAll() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.macaroons = make(map[AuthorizationKey]*macaroon.Macaroon)
+	return nil
+}
+
+// NewFileAuthorizationStore returns an AuthorizationStore holding the
+// macaroons in files in the specified directory, which is created if
+// needed. The store may be shared by processes.
+func NewFileAuthorizationStore(dir string) AuthorizationStore {
+	return &fileStore{dir: dir}
+}
+
+type fileStore struct {
+	dir string
+}
+
+// fileEntry is the content of a file of the file store.
+type fileEntry struct {
+	Key      AuthorizationKey   `json:"key"`
+	Macaroon *macaroon.Macaroon `json:"macaroon"`
+}
+
+const fileStoreSuffix = ".authorization"
+
+func (s *fileStore) path(key AuthorizationKey) string {
+	data, _ := json.Marshal(key)
+	sum := sha256.Sum256(data)
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+fileStoreSuffix)
+}
+
+// Get implements AuthorizationStore.Get.
+func (s *fileStore) Get(key AuthorizationKey) (*macaroon.Macaroon, error) {
+	data, err := ioutil.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, errors.NotFoundf("authorization")
+	}
+	if err != nil {
+		return nil, errors.Annotate(err, "failed to read authorization")
+	}
+	var entry fileEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key || entry.Macaroon == nil {
+		// A partial or corrupted entry is not fatal: the
+		// authorization is requested again and the entry replaced.
+		return nil, errors.NotFoundf("authorization")
+	}
+	return entry.Macaroon, nil
+}
+
+// Put implements AuthorizationStore.Put.
+func (s *fileStore) Put(key AuthorizationKey, m *macaroon.Macaroon) error {
+	data, err := json.Marshal(fileEntry{Key: key, Macaroon: m})
+	if err != nil {
+		return errors.Annotate(err, "failed to marshal authorization")
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return errors.Annotate(err, "failed to create authorization cache directory")
+	}
+	return errors.Annotate(utils.AtomicWriteFile(s.path(key), data, 0600), "failed to write authorization")
+}
+
+// Remove implements AuthorizationStore.Remove.
+func (s *fileStore) Remove(key AuthorizationKey) error {
+	if err := os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+		return errors.Annotate(err, "failed to remove authorization")
+	}
+	return nil
+}
+
+// RemoveAll implements AuthorizationStore.RemoveAll.
+func (s *fileStore) RemoveAll() error {
+	infos, err := ioutil.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Annotate(err, "failed to read authorization cache directory")
+	}
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), fileStoreSuffix) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, info.Name())); err != nil && !os.IsNotExist(err) {
+			return errors.Annotate(err, "failed to remove authorization")
+		}
+	}
+	return nil
+}
+
+var (
+	_ PlanClient        = (*CachingPlanClient)(nil)
+	_ PlanMetadataSaver = (*CachingPlanClient)(nil)
+	_ Authenticator     = (*CachingPlanClient)(nil)
+)
+
+// CachingPlanClient is a PlanClient that reuses the authorization
+// macaroons previously obtained for the same environment, charm,
+// service and plan. All other calls are passed on to the wrapped
+// client.
+type CachingPlanClient struct {
+	PlanClient
+
+	store  AuthorizationStore
+	margin time.Duration
+	now    func() time.Time
+}
+
+// CacheOption defines a function which configures a CachingPlanClient.
+type CacheOption func(c *CachingPlanClient) error
+
+// CacheStore returns a function that sets the store of the cached
+// macaroons. By default, macaroons are stored in memory.
+func CacheStore(store AuthorizationStore) CacheOption {
+	return func(c *CachingPlanClient) error {
+		if store == nil {
+			return errors.NotValidf("nil authorization store")
+		}
+		c.store = store
+		return nil
+	}
+}
+
+// CacheExpiryMargin returns a function that sets how long before their
+// expiry cached macaroons stop being reused.
+func CacheExpiryMargin(margin time.Duration) CacheOption {
+	return func(c *CachingPlanClient) error {
+		if margin < 0 {
+			return errors.NotValidf("negative expiry margin %v", margin)
+		}
+		c.margin = margin
+		return nil
+	}
+}
+
+// CacheClock returns a function that sets the function used to get the
+// current time when checking the expiry of cached macaroons.
+func CacheClock(now func() time.Time) CacheOption {
+	return func(c *CachingPlanClient) error {
+		c.now = now
+		return nil
+	}
+}
+
+// NewCachingPlanClient returns a PlanClient caching the authorization
+// macaroons obtained with the specified client.
+func NewCachingPlanClient(client PlanClient, options ...CacheOption) (*CachingPlanClient, error) {
+	c := &CachingPlanClient{
+		PlanClient: client,
+		store:      NewMemoryAuthorizationStore(),
+		margin:     DefaultExpiryMargin,
+		now:        time.Now,
+	}
+	for _, option := range options {
+		err := option(c)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return c, nil
+}
+
+// Authorize implements PlanClient.Authorize. A cached macaroon is
+// returned unless it expires within the expiry margin. Macaroons with
+// no time-before caveat are reused until invalidated. The cache is
+// best-effort: failing to read or store a macaroon does not fail the
+// authorization.
+func (c *CachingPlanClient) Authorize(ctx context.Context, environmentUUID, charmURL, serviceName, planURL string) (*macaroon.Macaroon, error) {
+	key := AuthorizationKey{
+		EnvironmentUUID: environmentUUID,
+		CharmURL:        charmURL,
+		ServiceName:     serviceName,
+		PlanURL:         planURL,
+	}
+	if m, err := c.store.Get(key); err == nil && c.usable(m) {
+		return m, nil
+	}
+	m, err := c.PlanClient.Authorize(ctx, environmentUUID, charmURL, serviceName, planURL)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if c.usable(m) {
+		_ = c.store.Put(key, m)
+	}
+	return m, nil
+}
+
+// Invalidate removes the cached macaroon for the specified environment,
+// charm, service and plan.
+func (c *CachingPlanClient) Invalidate(environmentUUID, charmURL, serviceName, planURL string) error {
+	return errors.Trace(c.store.Remove(AuthorizationKey{
+		EnvironmentUUID: environmentUUID,
+		CharmURL:        charmURL,
+		ServiceName:     serviceName,
+		PlanURL:         planURL,
+	}))
+}
+
+// InvalidateAll removes all cached macaroons.
+func (c *CachingPlanClient) InvalidateAll() error {
+	return errors.Trace(c.store.RemoveAll())
+}
+
+// usable returns true if the macaroon does not expire within the
+// expiry margin.
+func (c *CachingPlanClient) usable(m *macaroon.Macaroon) bool {
+	expires, ok := ExpiryTime(m)
+	return !ok || expires.After(c.now().Add(c.margin))
+}
+
+// ExpiryTime returns the earliest time of the time-before caveats of
+// the macaroon. It returns false if the macaroon has no such caveat.
+func ExpiryTime(m *macaroon.Macaroon) (time.Time, bool) {
+	var expires time.Time
+	found := false
+	for _, cav := range m.Caveats() {
+		if cav.Location != "" {
+			continue
+		}
+		cond, arg, err := checkers.ParseCaveat(cav.Id)
+		if err != nil || cond != checkers.CondTimeBefore {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, arg)
+		if err != nil {
+			continue
+		}
+		if !found || t.Before(expires) {
+			expires, found = t, true
+		}
+	}
+	return expires, found
+}
+
+// SaveWithMetadata implements PlanMetadataSaver, passing the call on to the
+// wrapped client.
+func (c *CachingPlanClient) SaveWithMetadata(ctx context.Context, planURL, definition string, metadata wireformat.PlanMetadata) (*wireformat.Plan, error) {
+	return SaveWithMetadata(ctx, c.PlanClient, planURL, definition, metadata)
+}
+
+// Login implements Authenticator, passing the call on to the wrapped
+// client.
+func (c *CachingPlanClient) Login(ctx context.Context) error {
+	return Login(ctx, c.PlanClient)
+}
@@ -0,0 +1,193 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package api_test
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/macaroon.v1"
+
+	"github.com/juju/plans-client/api"
+	plantesting "github.com/juju/plans-client/testing"
+)
+
+const cacheModelUUID = "9d4ba8a9-1b2c-4f5e-8e3a-2f0c63a1c0de"
+
+type cacheSuite struct {
+	mockAPI *plantesting.MockPlanClient
+	now     time.Time
+}
+
+var _ = gc.Suite(&cacheSuite{})
+
+func (s *cacheSuite) SetUpTest(c *gc.C) {
+	s.mockAPI = plantesting.NewMockPlanClient()
+	s.now = time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)
+	s.mockAPI.AuthorizationMacaroon = newExpiringMacaroon(c, "auth-1", s.now.Add(time.Hour))
+}
+
+func newExpiringMacaroon(c *gc.C, id string, expires time.Time) *macaroon.Macaroon {
+	m, err := macaroon.New([]byte("root-key"), id, "plans")
+	c.Assert(err, jc.ErrorIsNil)
+	if !expires.IsZero() {
+		err = m.AddFirstPartyCaveat("time-before " + expires.Format(time.RFC3339))
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	return m
+}
+
+func (s *cacheSuite) newClient(c *gc.C, options ...api.CacheOption) *api.CachingPlanClient {
+	options = append([]api.CacheOption{api.CacheClock(func() time.Time { return s.now })}, options...)
+	client, err := api.NewCachingPlanClient(s.mockAPI, options...)
+	c.Assert(err, jc.ErrorIsNil)
+	return client
+}
+
+func (s *cacheSuite) authorize(c *gc.C, client api.PlanClient, application string) string {
+	m, err := client.Authorize(context.Background(), cacheModelUUID, "cs:~testisv/charm1-0", application, "testisv/default")
+	c.Assert(err, jc.ErrorIsNil)
+	return m.Id()
+}
+
+func (s *cacheSuite) TestInvalidOptions(c *gc.C) {
+	_, err := api.NewCachingPlanClient(s.mockAPI, api.CacheStore(nil))
+	c.Assert(err, gc.ErrorMatches, "nil authorization store not valid")
+	_, err = api.NewCachingPlanClient(s.mockAPI, api.CacheExpiryMargin(-time.Minute))
+	c.Assert(err, gc.ErrorMatches, "negative expiry margin -1m0s not valid")
+}
+
+func (s *cacheSuite) TestReuse(c *gc.C) {
+	client := s.newClient(c)
+	c.Assert(s.authorize(c, client, "app1"), gc.Equals, "auth-1")
+	s.mockAPI.AuthorizationMacaroon = newExpiringMacaroon(c, "auth-2", s.now.Add(time.Hour))
+	c.Assert(s.authorize(c, client, "app1"), gc.Equals, "auth-1")
+	s.mockAPI.CheckCallNames(c, "Authorize")
+
+	// A different request tuple is not served from the cache.
+	c.Assert(s.authorize(c, client, "app2"), gc.Equals, "auth-2")
+	s.mockAPI.CheckCallNames(c, "Authorize", "Authorize")
+}
+
+func (s *cacheSuite) TestExpiry(c *gc.C) {
+	client := s.newClient(c, api.CacheExpiryMargin(10*time.Minute))
+	c.Assert(s.authorize(c, client, "app1"), gc.Equals, "auth-1")
+	s.mockAPI.AuthorizationMacaroon = newExpiringMacaroon(c, "auth-2", s.now.Add(2*time.Hour))
+
+	s.now = s.now.Add(49 * time.Minute)
+	c.Assert(s.authorize(c, client, "app1"), gc.Equals, "auth-1")
+
+	// Within the expiry margin, a new authorization is obtained.
+	s.now = s.now.Add(2 * time.Minute)
+	c.Assert(s.authorize(c, client, "app1"), gc.Equals, "auth-2")
+	c.Assert(s.authorize(c, client, "app1"), gc.Equals, "auth-2")
+	s.mockAPI.CheckCallNames(c, "Authorize", "Authorize")
+}
+
+func (s *cacheSuite) TestEarliestExpiry(c *gc.C) {
+	m := newExpiringMacaroon(c, "auth-1", s.now.Add(time.Hour))
+	err := m.AddFirstPartyCaveat("time-before " + s.now.Add(time.Minute).Format(time.RFC3339))
+	c.Assert(err, jc.ErrorIsNil)
+	expires, ok := api.ExpiryTime(m)
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(expires.Equal(s.now.Add(time.Minute)), jc.IsTrue)
+
+	// Macaroons expiring within the margin are not cached.
+	s.mockAPI.AuthorizationMacaroon = m
+	client := s.newClient(c)
+	s.authorize(c, client, "app1")
+	s.authorize(c, client, "app1")
+	s.mockAPI.CheckCallNames(c, "Authorize", "Authorize")
+}
+
+func (s *cacheSuite) TestNoExpiry(c *gc.C) {
+	s.mockAPI.AuthorizationMacaroon = newExpiringMacaroon(c, "auth-1", time.Time{})
+	_, ok := api.ExpiryTime(s.mockAPI.AuthorizationMacaroon)
+	c.Assert(ok, jc.IsFalse)
+	client := s.newClient(c)
+	s.authorize(c, client, "app1")
+	s.now = s.now.Add(365 * 24 * time.Hour)
+	s.authorize(c, client, "app1")
+	s.mockAPI.CheckCallNames(c, "Authorize")
+}
+
+func (s *cacheSuite) TestInvalidate(c *gc.C) {
+	client := s.newClient(c)
+	s.authorize(c, client, "app1")
+	s.authorize(c, client, "app2")
+	s.mockAPI.AuthorizationMacaroon = newExpiringMacaroon(c, "auth-2", s.now.Add(time.Hour))
+
+	err := client.Invalidate(cacheModelUUID, "cs:~testisv/charm1-0", "app1", "testisv/default")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.authorize(c, client, "app1"), gc.Equals, "auth-2")
+	c.Assert(s.authorize(c, client, "app2"), gc.Equals, "auth-1")
+
+	err = client.InvalidateAll()
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.AuthorizationMacaroon = newExpiringMacaroon(c, "auth-3", s.now.Add(time.Hour))
+	c.Assert(s.authorize(c, client, "app2"), gc.Equals, "auth-3")
+}
+
+func (s *cacheSuite) TestAuthorizeError(c *gc.C) {
+	client := s.newClient(c)
+	s.mockAPI.SetErrors(errors.New("boom"))
+	_, err := client.Authorize(context.Background(), cacheModelUUID, "cs:~testisv/charm1-0", "app1", "testisv/default")
+	c.Assert(err, gc.ErrorMatches, "boom")
+	c.Assert(s.authorize(c, client, "app1"), gc.Equals, "auth-1")
+	s.mockAPI.CheckCallNames(c, "Authorize", "Authorize")
+}
+
+func (s *cacheSuite) TestPassThrough(c *gc.C) {
+	client := s.newClient(c)
+	_, err := client.GetPlans(context.Background(), "testisv")
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.CheckCallNames(c, "GetPlans")
+}
+
+func (s *cacheSuite) TestFileStore(c *gc.C) {
+	dir := filepath.Join(c.MkDir(), "authorizations")
+	client := s.newClient(c, api.CacheStore(api.NewFileAuthorizationStore(dir)))
+	s.authorize(c, client, "app1")
+	s.mockAPI.AuthorizationMacaroon = newExpiringMacaroon(c, "auth-2", s.now.Add(time.Hour))
+
+	// The cached macaroon is shared by clients using the same directory.
+	other := s.newClient(c, api.CacheStore(api.NewFileAuthorizationStore(dir)))
+	c.Assert(s.authorize(c, other, "app1"), gc.Equals, "auth-1")
+	s.mockAPI.CheckCallNames(c, "Authorize")
+
+	// Corrupted entries are replaced.
+	infos, err := ioutil.ReadDir(dir)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(infos, gc.HasLen, 1)
+	err = ioutil.WriteFile(filepath.Join(dir, infos[0].Name()), []byte("{"), 0600)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.authorize(c, other, "app1"), gc.Equals, "auth-2")
+	c.Assert(s.authorize(c, client, "app1"), gc.Equals, "auth-2")
+
+	err = client.InvalidateAll()
+	c.Assert(err, jc.ErrorIsNil)
+	infos, err = ioutil.ReadDir(dir)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(infos, gc.HasLen, 0)
+}
+
+func (s *cacheSuite) TestMemoryStoreCopies(c *gc.C) {
+	store := api.NewMemoryAuthorizationStore()
+	key := api.AuthorizationKey{PlanURL: "testisv/default"}
+	m := newExpiringMacaroon(c, "auth-1", time.Time{})
+	err := store.Put(key, m)
+	c.Assert(err, jc.ErrorIsNil)
+	err = m.AddFirstPartyCaveat("extra")
+	c.Assert(err, jc.ErrorIsNil)
+	stored, err := store.Get(key)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(stored.Caveats(), gc.HasLen, 0)
+	_, err = store.Get(api.AuthorizationKey{})
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
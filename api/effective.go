@@ -0,0 +1,47 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/plans-client/api/wireformat"
+)
+
+// PlanAt returns the state of the plan for the charm at time t: which
+// revision of the plan was effective and whether the plan was suspended
+// for the charm. It retrieves the details of every released revision of
+// the plan and replays their events with wireformat.PlanStateAt.
+func PlanAt(ctx context.Context, client PlanClient, planURL, charmURL string, t time.Time) (*wireformat.PlanState, error) {
+	revisions, err := client.GetPlanRevisions(ctx, planURL)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to retrieve revisions of plan %v", planURL)
+	}
+	var details []wireformat.PlanDetails
+	for _, revision := range revisions {
+		if !revision.Released {
+			continue
+		}
+		d, err := client.GetPlanDetails(ctx, revision.Id)
+		if err != nil {
+			return nil, errors.Annotatef(err, "failed to retrieve plan %v details", revision.Id)
+		}
+		details = append(details, *d)
+	}
+	if len(details) == 0 {
+		// No revision is effective, but the details of the plan
+		// still hold the events of the charm.
+		d, err := client.GetPlanDetails(ctx, planURL)
+		if err != nil {
+			return nil, errors.Annotatef(err, "failed to retrieve plan %v details", planURL)
+		}
+		details = append(details, *d)
+	}
+	state := wireformat.PlanStateAt(details, charmURL, t)
+	state.PlanURL = planURL
+	return &state, nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package api_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/api/wireformat"
+	plantesting "github.com/juju/plans-client/testing"
+)
+
+type planAtSuite struct {
+	mockAPI *plantesting.MockPlanClient
+}
+
+var _ = gc.Suite(&planAtSuite{})
+
+func (s *planAtSuite) SetUpTest(c *gc.C) {
+	s.mockAPI = plantesting.NewMockPlanClient()
+	s.mockAPI.PlanRevisions = []wireformat.Plan{
+		{Id: "testisv/default/1", URL: "testisv/default", Released: true},
+		{Id: "testisv/default/2", URL: "testisv/default", Released: true},
+		{Id: "testisv/default/3", URL: "testisv/default"},
+	}
+	effective := time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)
+	s.mockAPI.RevisionDetails = map[string]*wireformat.PlanDetails{
+		"testisv/default/1": {
+			Plan:     wireformat.Plan{Id: "testisv/default/1", URL: "testisv/default"},
+			Released: &wireformat.Event{Type: "release", Time: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)},
+			Charms: []wireformat.CharmPlanDetail{{
+				CharmURL: "cs:~testisv/charm1-0",
+				Attached: wireformat.Event{Type: "create", Time: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)},
+			}},
+		},
+		"testisv/default/2": {
+			Plan:     wireformat.Plan{Id: "testisv/default/2", URL: "testisv/default", EffectiveTime: &effective},
+			Released: &wireformat.Event{Type: "release", Time: time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC)},
+			Charms: []wireformat.CharmPlanDetail{{
+				CharmURL: "cs:~testisv/charm1-0",
+				Attached: wireformat.Event{Type: "create", Time: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)},
+				Events:   []wireformat.Event{{Type: "suspend", Time: time.Date(2017, 3, 5, 0, 0, 0, 0, time.UTC)}},
+			}},
+		},
+	}
+}
+
+func (s *planAtSuite) TestPlanAt(c *gc.C) {
+	t := time.Date(2017, 2, 15, 0, 0, 0, 0, time.UTC)
+	state, err := api.PlanAt(context.Background(), s.mockAPI, "testisv/default", "cs:~testisv/charm1-0", t)
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.CheckCallNames(c, "GetPlanRevisions", "GetPlanDetails", "GetPlanDetails")
+	s.mockAPI.CheckCall(c, 1, "GetPlanDetails", "testisv/default/1")
+	s.mockAPI.CheckCall(c, 2, "GetPlanDetails", "testisv/default/2")
+	c.Assert(state.PlanID, gc.Equals, "testisv/default/1")
+	c.Assert(state.Suspended, jc.IsFalse)
+
+	state, err = api.PlanAt(context.Background(), s.mockAPI, "testisv/default", "cs:~testisv/charm1-0", t.AddDate(0, 1, 0))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(state.PlanID, gc.Equals, "testisv/default/2")
+	c.Assert(state.Suspended, jc.IsTrue)
+}
+
+func (s *planAtSuite) TestNoReleasedRevision(c *gc.C) {
+	s.mockAPI.PlanRevisions = s.mockAPI.PlanRevisions[2:]
+	s.mockAPI.PlanDetails = &wireformat.PlanDetails{
+		Plan: wireformat.Plan{Id: "testisv/default/3", URL: "testisv/default"},
+		Charms: []wireformat.CharmPlanDetail{{
+			CharmURL: "cs:~testisv/charm1-0",
+			Attached: wireformat.Event{Type: "create", Time: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)},
+		}},
+	}
+	state, err := api.PlanAt(context.Background(), s.mockAPI, "testisv/default", "cs:~testisv/charm1-0", time.Date(2017, 2, 15, 0, 0, 0, 0, time.UTC))
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.CheckCallNames(c, "GetPlanRevisions", "GetPlanDetails")
+	s.mockAPI.CheckCall(c, 1, "GetPlanDetails", "testisv/default")
+	c.Assert(state.PlanURL, gc.Equals, "testisv/default")
+	c.Assert(state.PlanID, gc.Equals, "")
+	c.Assert(state.Attached, jc.IsTrue)
+}
+
+func (s *planAtSuite) TestError(c *gc.C) {
+	s.mockAPI.SetErrors(errors.New("boom"))
+	_, err := api.PlanAt(context.Background(), s.mockAPI, "testisv/default", "cs:~testisv/charm1-0", time.Now())
+	c.Assert(err, gc.ErrorMatches, "failed to retrieve plan testisv/default/1 details: boom")
+}
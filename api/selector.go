@@ -0,0 +1,69 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/plans-client/api/wireformat"
+)
+
+// SelectPlanRevisions returns the revisions of the plan matched by the
+// selector, in ascending revision order. The revision effective at a time
+// is determined from the details of the released revisions of the plan.
+func SelectPlanRevisions(ctx context.Context, client PlanClient, sel wireformat.PlanSelector) ([]wireformat.Plan, error) {
+	planURL := sel.PlanURL.String()
+	revisions, err := client.GetPlanRevisions(ctx, planURL)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to retrieve revisions of plan %v", planURL)
+	}
+	if sel.Kind != wireformat.SelectEffectiveAt {
+		selected, err := sel.Select(revisions)
+		return selected, errors.Trace(err)
+	}
+	var details []wireformat.PlanDetails
+	for _, revision := range revisions {
+		if !revision.Released {
+			continue
+		}
+		d, err := client.GetPlanDetails(ctx, revision.Id)
+		if err != nil {
+			return nil, errors.Annotatef(err, "failed to retrieve plan %v details", revision.Id)
+		}
+		details = append(details, *d)
+	}
+	if id, _ := wireformat.EffectiveRevision(details, sel.Time); id != "" {
+		for _, revision := range revisions {
+			if revision.Id == id {
+				return []wireformat.Plan{revision}, nil
+			}
+		}
+	}
+	return nil, errors.NotFoundf("revision of plan %v effective at %v", planURL, sel.Time.Format(time.RFC3339))
+}
+
+// ResolvePlanSelector returns the ids of the revisions of the plan matched
+// by the selector. Selectors naming a revision are returned without
+// querying the plans service.
+func ResolvePlanSelector(ctx context.Context, client PlanClient, sel wireformat.PlanSelector) ([]wireformat.PlanID, error) {
+	if sel.Kind == wireformat.SelectRevision {
+		return []wireformat.PlanID{sel.PlanURL.Revision(sel.Revision)}, nil
+	}
+	revisions, err := SelectPlanRevisions(ctx, client, sel)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	ids := make([]wireformat.PlanID, len(revisions))
+	for i, revision := range revisions {
+		id, err := wireformat.ParsePlanID(revision.Id)
+		if err != nil {
+			return nil, errors.Annotatef(err, "failed to parse plan id %q", revision.Id)
+		}
+		ids[i] = *id
+	}
+	return ids, nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package api_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/api/wireformat"
+	plantesting "github.com/juju/plans-client/testing"
+)
+
+type selectorSuite struct {
+	mockAPI *plantesting.MockPlanClient
+}
+
+var _ = gc.Suite(&selectorSuite{})
+
+func (s *selectorSuite) SetUpTest(c *gc.C) {
+	s.mockAPI = plantesting.NewMockPlanClient()
+	s.mockAPI.PlanRevisions = []wireformat.Plan{
+		{Id: "testisv/default/1", URL: "testisv/default", Released: true},
+		{Id: "testisv/default/2", URL: "testisv/default", Released: true},
+		{Id: "testisv/default/3", URL: "testisv/default"},
+	}
+	effective := time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)
+	s.mockAPI.RevisionDetails = map[string]*wireformat.PlanDetails{
+		"testisv/default/1": {
+			Plan:     wireformat.Plan{Id: "testisv/default/1", URL: "testisv/default"},
+			Released: &wireformat.Event{Type: "release", Time: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		"testisv/default/2": {
+			Plan:     wireformat.Plan{Id: "testisv/default/2", URL: "testisv/default", EffectiveTime: &effective},
+			Released: &wireformat.Event{Type: "release", Time: time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+}
+
+func (s *selectorSuite) resolve(c *gc.C, selector string) ([]wireformat.PlanID, error) {
+	sel, err := wireformat.ParsePlanSelector(selector)
+	c.Assert(err, jc.ErrorIsNil)
+	return api.ResolvePlanSelector(context.Background(), s.mockAPI, *sel)
+}
+
+func (s *selectorSuite) TestResolveRevision(c *gc.C) {
+	ids, err := s.resolve(c, "testisv/default/7")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ids, jc.DeepEquals, []wireformat.PlanID{{PlanURL: wireformat.PlanURL{Owner: "testisv", Name: "default"}, Revision: 7}})
+	s.mockAPI.CheckNoCalls(c)
+}
+
+func (s *selectorSuite) TestResolveRange(c *gc.C) {
+	ids, err := s.resolve(c, "testisv/default/2..5")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ids, gc.HasLen, 2)
+	c.Assert(ids[0].String(), gc.Equals, "testisv/default/2")
+	c.Assert(ids[1].String(), gc.Equals, "testisv/default/3")
+	s.mockAPI.CheckCallNames(c, "GetPlanRevisions")
+	s.mockAPI.CheckCall(c, 0, "GetPlanRevisions", "testisv/default")
+}
+
+func (s *selectorSuite) TestResolveReleased(c *gc.C) {
+	ids, err := s.resolve(c, "testisv/default/released")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ids, gc.HasLen, 1)
+	c.Assert(ids[0].String(), gc.Equals, "testisv/default/2")
+}
+
+func (s *selectorSuite) TestResolveEffectiveAt(c *gc.C) {
+	ids, err := s.resolve(c, "testisv/default@2017-02-15")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ids, gc.HasLen, 1)
+	c.Assert(ids[0].String(), gc.Equals, "testisv/default/1")
+	s.mockAPI.CheckCallNames(c, "GetPlanRevisions", "GetPlanDetails", "GetPlanDetails")
+	s.mockAPI.CheckCall(c, 1, "GetPlanDetails", "testisv/default/1")
+	s.mockAPI.CheckCall(c, 2, "GetPlanDetails", "testisv/default/2")
+
+	ids, err = s.resolve(c, "testisv/default@2017-03-01")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ids[0].String(), gc.Equals, "testisv/default/2")
+
+	_, err = s.resolve(c, "testisv/default@2016-12-31")
+	c.Assert(err, gc.ErrorMatches, "revision of plan testisv/default effective at 2016-12-31T00:00:00Z not found")
+	c.Assert(errors.IsNotFound(err), jc.IsTrue)
+}
+
+func (s *selectorSuite) TestResolveDetailsError(c *gc.C) {
+	s.mockAPI.SetErrors(errors.New("boom"))
+	_, err := s.resolve(c, "testisv/default@2017-02-15")
+	c.Assert(err, gc.ErrorMatches, "failed to retrieve plan testisv/default/1 details: boom")
+}
@@ -0,0 +1,230 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/plans-client/api/wireformat"
+)
+
+// DefaultMaxResponseSize is the maximum size of the body of a response
+// accepted by a strict client, unless set with MaxResponseSize.
+const DefaultMaxResponseSize = 4 << 20
+
+// maxErrorSize is the maximum size of the body of an error response read
+// by any client.
+const maxErrorSize = 64 << 10
+
+// snippetSize is the maximum size of the payload snippet included in a
+// ResponseError.
+const snippetSize = 256
+
+// StrictResponses returns a function that makes the client validate the
+// responses of the plans service, to detect drift of the service from
+// the API contract. A strict client:
+//   - rejects responses larger than the maximum response size,
+//   - rejects unknown fields and trailing data,
+//   - validates the plans it receives, including the plans included in
+//     authorizations, and checks their ids,
+//   - checks that the plans retrieved by url match the requested url.
+//
+// Violations are reported as a *ResponseError. Unknown fields are not
+// detected in macaroons, which have their own JSON decoding.
+func StrictResponses() ClientOption {
+	return func(h *client) error {
+		h.strict = true
+		if h.maxResponseSize == 0 {
+			h.maxResponseSize = DefaultMaxResponseSize
+		}
+		return nil
+	}
+}
+
+// MaxResponseSize returns a function that sets the maximum size of the
+// body of a response accepted by the client.
+func MaxResponseSize(n int64) ClientOption {
+	return func(h *client) error {
+		if n <= 0 {
+			return errors.NotValidf("maximum response size %d", n)
+		}
+		h.maxResponseSize = n
+		return nil
+	}
+}
+
+// ResponseError is returned when a response of the plans service violates
+// the API contract.
+type ResponseError struct {
+	// Action is the action the response was received for.
+	Action string
+	// Reason describes the violation.
+	Reason string
+	// Snippet holds the offending part of the payload.
+	Snippet string
+}
+
+// Error implements error.
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("invalid response to %s: %s in %q", e.Action, e.Reason, e.Snippet)
+}
+
+// IsResponseError returns true if the cause of the error is a
+// *ResponseError.
+func IsResponseError(err error) bool {
+	_, ok := errors.Cause(err).(*ResponseError)
+	return ok
+}
+
+// decode decodes the body of the response into v, enforcing the checks
+// of a strict client.
+func (c *client) decode(action string, response *http.Response, v interface{}) error {
+	if !c.strict && c.maxResponseSize == 0 {
+		return errors.Trace(json.NewDecoder(response.Body).Decode(v))
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(response.Body, c.maxResponseSize+1))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if int64(len(data)) > c.maxResponseSize {
+		start := data[:c.maxResponseSize]
+		if len(start) > snippetSize {
+			start = start[:snippetSize]
+		}
+		return &ResponseError{
+			Action:  action,
+			Reason:  fmt.Sprintf("body larger than %d bytes", c.maxResponseSize),
+			Snippet: string(start) + "...",
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if c.strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		if !c.strict {
+			return errors.Trace(err)
+		}
+		return &ResponseError{
+			Action:  action,
+			Reason:  err.Error(),
+			Snippet: snippet(data, errorOffset(data, err)),
+		}
+	}
+	if c.strict && dec.More() {
+		return &ResponseError{
+			Action:  action,
+			Reason:  "unexpected data after the response",
+			Snippet: snippet(data, int(dec.InputOffset())),
+		}
+	}
+	return nil
+}
+
+// decodeAuthorizations decodes the authorizations in the body of the
+// response. A strict client rejects their unknown fields, which the
+// transitional decoding of wireformat.Authorization otherwise ignores.
+func (c *client) decodeAuthorizations(action string, response *http.Response) ([]wireformat.Authorization, error) {
+	if !c.strict {
+		var auths []wireformat.Authorization
+		err := c.decode(action, response, &auths)
+		return auths, errors.Trace(err)
+	}
+	var strictAuths []wireformat.StrictAuthorization
+	if err := c.decode(action, response, &strictAuths); err != nil {
+		return nil, errors.Trace(err)
+	}
+	auths := make([]wireformat.Authorization, len(strictAuths))
+	for i, a := range strictAuths {
+		auths[i] = wireformat.Authorization(a)
+	}
+	return auths, nil
+}
+
+// checkPlans validates the plans received by a strict client. When
+// requested is a plan url, the plans must be revisions of that plan; when
+// it is a plan id, the plans must be that revision.
+func (c *client) checkPlans(action, requested string, plans ...wireformat.Plan) error {
+	if !c.strict {
+		return nil
+	}
+	var expected *wireformat.PlanID
+	if requested != "" {
+		var err error
+		if expected, err = wireformat.ParsePlanIDWithOptionalRevision(requested); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	for _, p := range plans {
+		reason := ""
+		if err := p.Validate(); err != nil {
+			reason = err.Error()
+		} else if id, err := wireformat.ParsePlanID(p.Id); err != nil {
+			reason = err.Error()
+		} else if id.PlanURL.String() != p.URL {
+			reason = fmt.Sprintf("plan id %q does not match plan url %q", p.Id, p.URL)
+		} else if expected != nil && p.URL != expected.PlanURL.String() {
+			reason = fmt.Sprintf("plan %v does not match requested plan %v", p.URL, requested)
+		} else if expected != nil && expected.Revision != 0 && id.Revision != expected.Revision {
+			reason = fmt.Sprintf("plan %v does not match requested plan %v", p.Id, requested)
+		}
+		if reason != "" {
+			data, _ := json.Marshal(p)
+			return &ResponseError{
+				Action:  action,
+				Reason:  reason,
+				Snippet: snippet(data, 0),
+			}
+		}
+	}
+	return nil
+}
+
+// errorOffset returns the offset in data of the cause of the decoding
+// error.
+func errorOffset(data []byte, err error) int {
+	switch err := err.(type) {
+	case *json.SyntaxError:
+		return int(err.Offset)
+	case *json.UnmarshalTypeError:
+		return int(err.Offset)
+	}
+	// Unknown fields are reported as: json: unknown field "name".
+	const unknownField = `json: unknown field `
+	if msg := err.Error(); strings.HasPrefix(msg, unknownField) {
+		if i := bytes.Index(data, []byte(strings.TrimPrefix(msg, unknownField))); i >= 0 {
+			return i
+		}
+	}
+	return 0
+}
+
+// snippet returns the part of data around the offset, at most
+// snippetSize bytes long.
+func snippet(data []byte, offset int) string {
+	start := offset - snippetSize/2
+	if start < 0 {
+		start = 0
+	}
+	end := start + snippetSize
+	if end > len(data) {
+		end = len(data)
+	}
+	s := string(data[start:end])
+	if start > 0 {
+		s = "..." + s
+	}
+	if end < len(data) {
+		s += "..."
+	}
+	return s
+}
@@ -0,0 +1,260 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/api/wireformat"
+)
+
+type strictSuite struct {
+	httpClient *mockHttpClient
+}
+
+var _ = gc.Suite(&strictSuite{})
+
+func (s *strictSuite) SetUpTest(c *gc.C) {
+	s.httpClient = &mockHttpClient{status: http.StatusOK}
+}
+
+func (s *strictSuite) client(c *gc.C, options ...api.ClientOption) api.PlanClient {
+	client, err := api.NewPlanClient("", append([]api.ClientOption{api.HTTPClient(s.httpClient)}, options...)...)
+	c.Assert(err, jc.ErrorIsNil)
+	return client
+}
+
+var validPlan = wireformat.Plan{
+	Id:         "testisv/default/1",
+	URL:        "testisv/default",
+	Definition: testPlan,
+}
+
+func (s *strictSuite) TestValidResponse(c *gc.C) {
+	s.httpClient.body = []wireformat.Plan{validPlan}
+	plans, err := s.client(c, api.StrictResponses()).Get(context.Background(), "testisv/default")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(plans, jc.DeepEquals, []wireformat.Plan{validPlan})
+}
+
+func (s *strictSuite) TestValidAuthorizations(c *gc.C) {
+	// The Juju 2.0 names of the renamed fields are known fields.
+	s.httpClient.body = json.RawMessage(`[{"authorization-id": "auth-1", "plan": "testisv/default", "model-uuid": "model", "application": "app", "plan-id": "testisv/default/1", "plan-definition": "p"}]`)
+	auths, err := s.client(c, api.StrictResponses()).GetAuthorizations(context.Background(), wireformat.AuthorizationQuery{PlanURL: "testisv/default", IncludePlan: true})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(auths, jc.DeepEquals, []wireformat.Authorization{{
+		AuthorizationID: "auth-1",
+		PlanURL:         "testisv/default",
+		EnvironmentUUID: "model",
+		ServiceName:     "app",
+		PlanID:          "testisv/default/1",
+		PlanDefinition:  "p",
+	}})
+}
+
+func (s *strictSuite) TestLenientByDefault(c *gc.C) {
+	s.httpClient.body = json.RawMessage(`[{"id": "", "url": "testisv/other", "new-field": 1}]`)
+	plans, err := s.client(c).Get(context.Background(), "testisv/default")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(plans, jc.DeepEquals, []wireformat.Plan{{URL: "testisv/other"}})
+}
+
+func (s *strictSuite) TestViolations(c *gc.C) {
+	tests := []struct {
+		about string
+		body  interface{}
+		call  func(api.PlanClient) error
+		err   string
+	}{{
+		about: "unknown field",
+		body:  json.RawMessage(`[{"id": "testisv/default/1", "url": "testisv/default", "plan": "p", "new-field": 1}]`),
+		call: func(client api.PlanClient) error {
+			_, err := client.Get(context.Background(), "testisv/default")
+			return err
+		},
+		err: `failed to unmarshal the response: invalid response to retrieve plans: json: unknown field "new-field" in "\[{\\"id\\":\\"testisv/default/1\\",\\"url\\":\\"testisv/default\\",\\"plan\\":\\"p\\",\\"new-field\\":1}\]"`,
+	}, {
+		about: "unknown authorization field",
+		body:  json.RawMessage(`[{"authorization-id": "auth-1", "plan": "testisv/default", "model-uuid": "model", "new-field": 1}]`),
+		call: func(client api.PlanClient) error {
+			_, err := client.GetAuthorizations(context.Background(), wireformat.AuthorizationQuery{})
+			return err
+		},
+		err: `failed to unmarshal response: invalid response to retrieve authorizations: json: unknown field "new-field" in .*`,
+	}, {
+		about: "unknown reseller authorization field",
+		body:  json.RawMessage(`[{"auth-uuid": "auth-1", "plan": "testisv/default", "new-field": 1}]`),
+		call: func(client api.PlanClient) error {
+			_, err := client.GetResellerAuthorizations(context.Background(), wireformat.ResellerAuthorizationQuery{Reseller: "acme"})
+			return err
+		},
+		err: `failed to unmarshal response: invalid response to retrieve reseller authorizations: json: unknown field "new-field" in .*`,
+	}, {
+		about: "included plan not matching the authorization",
+		body: []wireformat.Authorization{{
+			AuthorizationID: "auth-1",
+			PlanURL:         "testisv/default",
+			PlanID:          "testisv/other/1",
+			PlanDefinition:  "p",
+		}},
+		call: func(client api.PlanClient) error {
+			_, err := client.GetAuthorizations(context.Background(), wireformat.AuthorizationQuery{IncludePlan: true})
+			return err
+		},
+		err: `invalid response to retrieve authorizations: plan id "testisv/other/1" does not match plan url "testisv/default" in .*`,
+	}, {
+		about: "included plan not matching the requested plan",
+		body: []wireformat.Authorization{{
+			AuthorizationID: "auth-1",
+			PlanURL:         "testisv/other",
+			PlanID:          "testisv/other/1",
+			PlanDefinition:  "p",
+		}},
+		call: func(client api.PlanClient) error {
+			_, err := client.GetAuthorizations(context.Background(), wireformat.AuthorizationQuery{PlanURL: "testisv/default", IncludePlan: true})
+			return err
+		},
+		err: `invalid response to retrieve authorizations: plan testisv/other does not match requested plan testisv/default in .*`,
+	}, {
+		about: "reseller authorization missing the included plan",
+		body: []wireformat.ResellerAuthorization{{
+			AuthUUID: "auth-1",
+			Plan:     "testisv/default",
+			PlanID:   "testisv/default/1",
+		}},
+		call: func(client api.PlanClient) error {
+			_, err := client.GetResellerAuthorizations(context.Background(), wireformat.ResellerAuthorizationQuery{Reseller: "acme", IncludePlan: true})
+			return err
+		},
+		err: `invalid response to retrieve reseller authorizations: missing plan definition in .*`,
+	}, {
+		about: "type mismatch",
+		body:  json.RawMessage(`{"id": 1}`),
+		call: func(client api.PlanClient) error {
+			_, err := client.GetDefaultPlan(context.Background(), "cs:~testisv/charm-1")
+			return err
+		},
+		err: `failed to unmarshal response: invalid response to retrieve default plan: json: cannot unmarshal number into Go struct field Plan.id of type string in .*`,
+	}, {
+		about: "empty id",
+		body:  []wireformat.Plan{{URL: "testisv/default", Definition: "p"}},
+		call: func(client api.PlanClient) error {
+			_, err := client.GetPlanRevisions(context.Background(), "testisv/default")
+			return err
+		},
+		err: `invalid response to retrieve plan revisions: plan id "" not valid in "{\\"id\\":\\"\\",.*}"`,
+	}, {
+		about: "invalid url",
+		body:  []wireformat.Plan{{Id: "testisv/default/1", URL: "testisv", Definition: "p"}},
+		call: func(client api.PlanClient) error {
+			_, err := client.GetPlans(context.Background(), "testisv")
+			return err
+		},
+		err: `invalid response to retrieve plans: plan url "testisv" not valid in .*`,
+	}, {
+		about: "missing definition",
+		body:  wireformat.Plan{Id: "testisv/default/1", URL: "testisv/default"},
+		call: func(client api.PlanClient) error {
+			_, err := client.Release(context.Background(), "testisv/default/1")
+			return err
+		},
+		err: `invalid response to release plan: missing plan definition in .*`,
+	}, {
+		about: "id not matching url",
+		body:  []wireformat.Plan{{Id: "testisv/other/1", URL: "testisv/default", Definition: "p"}},
+		call: func(client api.PlanClient) error {
+			_, err := client.GetPlansForCharm(context.Background(), "cs:~testisv/charm-1")
+			return err
+		},
+		err: `invalid response to retrieve associated plans: plan id "testisv/other/1" does not match plan url "testisv/default" in .*`,
+	}, {
+		about: "plan not matching the requested url",
+		body:  []wireformat.Plan{validPlan, {Id: "testisv/other/1", URL: "testisv/other", Definition: "p"}},
+		call: func(client api.PlanClient) error {
+			_, err := client.Get(context.Background(), "testisv/default")
+			return err
+		},
+		err: `invalid response to retrieve plans: plan testisv/other does not match requested plan testisv/default in .*`,
+	}, {
+		about: "plan details not matching the requested plan",
+		body:  wireformat.PlanDetails{Plan: wireformat.Plan{Id: "testisv/other/1", URL: "testisv/other", Definition: "p"}},
+		call: func(client api.PlanClient) error {
+			_, err := client.GetPlanDetails(context.Background(), "testisv/default/1")
+			return err
+		},
+		err: `invalid response to retrieve plan details: plan testisv/other does not match requested plan testisv/default in .*`,
+	}, {
+		about: "released plan not matching the requested plan",
+		body:  wireformat.Plan{Id: "testisv/other/1", URL: "testisv/other", Definition: "p"},
+		call: func(client api.PlanClient) error {
+			_, err := client.Release(context.Background(), "testisv/default/1")
+			return err
+		},
+		err: `invalid response to release plan: plan testisv/other does not match requested plan testisv/default/1 in .*`,
+	}, {
+		about: "released revision not matching the requested revision",
+		body:  wireformat.Plan{Id: "testisv/default/2", URL: "testisv/default", Definition: "p"},
+		call: func(client api.PlanClient) error {
+			_, err := client.Release(context.Background(), "testisv/default/1")
+			return err
+		},
+		err: `invalid response to release plan: plan testisv/default/2 does not match requested plan testisv/default/1 in .*`,
+	}}
+	for i, t := range tests {
+		c.Logf("test %d: %s", i, t.about)
+		s.httpClient.body = t.body
+		err := t.call(s.client(c, api.StrictResponses()))
+		c.Check(err, gc.ErrorMatches, t.err)
+		c.Check(api.IsResponseError(err), jc.IsTrue)
+	}
+}
+
+func (s *strictSuite) TestMaxResponseSize(c *gc.C) {
+	s.httpClient.body = []wireformat.Plan{validPlan}
+	_, err := s.client(c, api.MaxResponseSize(16)).Get(context.Background(), "testisv/default")
+	c.Assert(err, gc.ErrorMatches, `failed to unmarshal the response: invalid response to retrieve plans: body larger than 16 bytes in "\[{\\"id\\":\\"testisv/\.\.\."`)
+	c.Assert(api.IsResponseError(err), jc.IsTrue)
+
+	// The maximum size applies to strict clients too.
+	_, err = s.client(c, api.MaxResponseSize(16), api.StrictResponses()).Get(context.Background(), "testisv/default")
+	c.Assert(err, gc.ErrorMatches, `.*body larger than 16 bytes.*`)
+	_, err = s.client(c, api.StrictResponses(), api.MaxResponseSize(16)).Get(context.Background(), "testisv/default")
+	c.Assert(err, gc.ErrorMatches, `.*body larger than 16 bytes.*`)
+
+	_, err = api.NewPlanClient("", api.MaxResponseSize(0))
+	c.Assert(err, gc.ErrorMatches, `maximum response size 0 not valid`)
+	c.Assert(errors.IsNotValid(err), jc.IsTrue)
+}
+
+func (s *strictSuite) TestErrorBodySize(c *gc.C) {
+	s.httpClient.status = http.StatusInternalServerError
+	s.httpClient.body = strings.Repeat("x", 1<<20)
+	_, err := s.client(c).Get(context.Background(), "testisv/default")
+	c.Assert(err, gc.ErrorMatches, `failed to retrieve plans: received status code 500 and response .*`)
+	c.Assert(len(err.Error()) < 1<<17, jc.IsTrue)
+}
+
+func (s *strictSuite) TestSnippet(c *gc.C) {
+	// The snippet of a large payload is taken around the offending
+	// field.
+	padding := strings.Repeat("x", 1000)
+	s.httpClient.body = json.RawMessage(`{"id": "testisv/default/1", "url": "testisv/default", "plan": "` + padding + `", "new-field": 1, "description": "` + padding + `"}`)
+	_, err := s.client(c, api.StrictResponses()).GetDefaultPlan(context.Background(), "cs:~testisv/charm-1")
+	c.Assert(err, gc.NotNil)
+	e, ok := errors.Cause(err).(*api.ResponseError)
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(e.Action, gc.Equals, "retrieve default plan")
+	c.Assert(e.Snippet, jc.Contains, `"new-field":1`)
+	c.Assert(strings.HasPrefix(e.Snippet, "..."), jc.IsTrue)
+	c.Assert(strings.HasSuffix(e.Snippet, "..."), jc.IsTrue)
+	c.Assert(len(e.Snippet), gc.Equals, 256+6)
+}
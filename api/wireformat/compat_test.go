@@ -4,6 +4,9 @@
 package wireformat_test
 
 import (
+	"encoding/json"
+	"time"
+
 	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
 
@@ -99,3 +102,141 @@ func (s *wireCompatSuite) TestAuthorizationQuery(c *gc.C) {
 	c.Assert(oldWire.ServiceName, gc.DeepEquals, "service-is-application")
 	c.Assert(newWire.ServiceName, gc.DeepEquals, "service-is-application")
 }
+
+func (s *wireCompatSuite) TestStrict(c *gc.C) {
+	newJSON := []byte(`{
+	"authorization-id": "some-authorization",
+	"plan": "some-plan",
+	"model-uuid": "env-is-model",
+	"charm-url": "some-charm",
+	"application": "service-is-application"
+}`)
+	unknownJSON := []byte(`{"plan": "some-plan", "budget": "some-budget"}`)
+
+	var auth wireformat.StrictAuthorization
+	c.Assert(json.Unmarshal(newJSON, &auth), jc.ErrorIsNil)
+	c.Assert(auth.EnvironmentUUID, gc.Equals, "env-is-model")
+	c.Assert(auth.ServiceName, gc.Equals, "service-is-application")
+	c.Assert(json.Unmarshal(unknownJSON, &auth), gc.ErrorMatches, `json: unknown field "budget"`)
+
+	var req wireformat.StrictAuthorizationRequest
+	c.Assert(json.Unmarshal(newJSON, &req), gc.ErrorMatches, `json: unknown field "authorization-id"`)
+	c.Assert(json.Unmarshal([]byte(`{"plan-url": "some-plan", "model-uuid": "env-is-model"}`), &req), jc.ErrorIsNil)
+	c.Assert(req.EnvironmentUUID, gc.Equals, "env-is-model")
+	c.Assert(json.Unmarshal(unknownJSON, &req), gc.ErrorMatches, `json: unknown field "plan"`)
+
+	// The transitional decoding ignores unknown fields.
+	var lenient wireformat.Authorization
+	c.Assert(json.Unmarshal(unknownJSON, &lenient), jc.ErrorIsNil)
+	c.Assert(lenient.PlanURL, gc.Equals, "some-plan")
+}
+
+var dialectTests = []struct {
+	dialect wireformat.Dialect
+	present []string
+	absent  []string
+}{{
+	dialect: wireformat.DialectLegacy,
+	present: []string{"env-uuid", "service-name"},
+	absent:  []string{"model-uuid", "application"},
+}, {
+	dialect: wireformat.DialectCompat,
+	present: []string{"env-uuid", "service-name", "model-uuid", "application"},
+}, {
+	dialect: wireformat.DialectJuju2,
+	present: []string{"model-uuid", "application"},
+	absent:  []string{"env-uuid", "service-name"},
+}}
+
+type dialectMarshaler interface {
+	MarshalJSONDialect(wireformat.Dialect) ([]byte, error)
+}
+
+// checkDialects checks that v is marshaled with the names of each dialect
+// and that it unmarshals back into the same value from each.
+func checkDialects(c *gc.C, v dialectMarshaler, newValue func() interface{}) {
+	for i, t := range dialectTests {
+		c.Logf("test %d: %v", i, t.dialect)
+		data, err := v.MarshalJSONDialect(t.dialect)
+		c.Assert(err, jc.ErrorIsNil)
+		var fields map[string]interface{}
+		c.Assert(json.Unmarshal(data, &fields), jc.ErrorIsNil)
+		for _, name := range t.present {
+			c.Check(fields[name], gc.Equals, map[string]string{
+				"env-uuid":     "env-is-model",
+				"model-uuid":   "env-is-model",
+				"service-name": "service-is-application",
+				"application":  "service-is-application",
+			}[name], gc.Commentf("field %q", name))
+		}
+		for _, name := range t.absent {
+			_, ok := fields[name]
+			c.Check(ok, jc.IsFalse, gc.Commentf("field %q", name))
+		}
+		decoded := newValue()
+		c.Assert(json.Unmarshal(data, decoded), jc.ErrorIsNil)
+		c.Check(decoded, jc.DeepEquals, v)
+	}
+	_, err := v.MarshalJSONDialect(wireformat.Dialect(42))
+	c.Assert(err, gc.ErrorMatches, `wire dialect 42 not valid`)
+}
+
+func (s *wireCompatSuite) TestAuthorizationRequestDialects(c *gc.C) {
+	ar := &wireformat.AuthorizationRequest{
+		EnvironmentUUID: "env-is-model",
+		CharmURL:        "some-charm",
+		ServiceName:     "service-is-application",
+		PlanURL:         "some-plan",
+	}
+	checkDialects(c, ar, func() interface{} { return &wireformat.AuthorizationRequest{} })
+
+	// The default encoding uses the legacy names.
+	data, err := json.Marshal(ar)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), jc.JSONEquals, map[string]string{
+		"env-uuid":     "env-is-model",
+		"charm-url":    "some-charm",
+		"service-name": "service-is-application",
+		"plan-url":     "some-plan",
+	})
+}
+
+func (s *wireCompatSuite) TestAuthorizationDialects(c *gc.C) {
+	a := &wireformat.Authorization{
+		AuthorizationID: "some-authorization",
+		User:            "some-user",
+		PlanURL:         "some-plan",
+		EnvironmentUUID: "env-is-model",
+		CharmURL:        "some-charm",
+		ServiceName:     "service-is-application",
+		CreatedOn:       time.Date(2016, 8, 6, 12, 34, 56, 0, time.UTC),
+		CredentialsID:   "some-creds",
+		PlanID:          "some-plan/1",
+	}
+	checkDialects(c, a, func() interface{} { return &wireformat.Authorization{} })
+}
+
+func (s *wireCompatSuite) TestAuthorizationQueryDialects(c *gc.C) {
+	q := &wireformat.AuthorizationQuery{
+		AuthorizationID: "some-auth",
+		User:            "some-user",
+		PlanURL:         "some-plan",
+		EnvironmentUUID: "env-is-model",
+		CharmURL:        "some-charm",
+		ServiceName:     "service-is-application",
+		IncludePlan:     true,
+		StatementPeriod: "2017-01",
+	}
+	checkDialects(c, q, func() interface{} { return &wireformat.AuthorizationQuery{} })
+}
+
+func (s *wireCompatSuite) TestParseDialect(c *gc.C) {
+	for _, d := range []wireformat.Dialect{wireformat.DialectLegacy, wireformat.DialectCompat, wireformat.DialectJuju2} {
+		parsed, err := wireformat.ParseDialect(d.String())
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(parsed, gc.Equals, d)
+	}
+	_, err := wireformat.ParseDialect("juju3")
+	c.Assert(err, gc.ErrorMatches, `wire dialect "juju3" not valid`)
+	c.Assert(wireformat.Dialect(42).String(), gc.Equals, "unknown")
+}
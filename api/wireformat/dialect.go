@@ -0,0 +1,153 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package wireformat
+
+import (
+	"encoding/json"
+
+	"github.com/juju/errors"
+)
+
+// Dialect selects the names under which the fields renamed in Juju 2.0
+// are emitted: environments became models and services became
+// applications. Decoding always accepts both names.
+type Dialect int
+
+const (
+	// DialectLegacy emits the names used before Juju 2.0: env-uuid and
+	// service-name. It is the default dialect.
+	DialectLegacy Dialect = iota
+	// DialectCompat emits both the names used before Juju 2.0 and the
+	// Juju 2.0 names, for services that may understand either.
+	DialectCompat
+	// DialectJuju2 emits the Juju 2.0 names: model-uuid and application.
+	DialectJuju2
+)
+
+var dialectNames = map[Dialect]string{
+	DialectLegacy: "legacy",
+	DialectCompat: "compat",
+	DialectJuju2:  "juju2",
+}
+
+// ParseDialect returns the dialect with the given name.
+func ParseDialect(name string) (Dialect, error) {
+	for d, n := range dialectNames {
+		if n == name {
+			return d, nil
+		}
+	}
+	return 0, errors.NotValidf("wire dialect %q", name)
+}
+
+// String returns the name of the dialect.
+func (d Dialect) String() string {
+	if n, ok := dialectNames[d]; ok {
+		return n
+	}
+	return "unknown"
+}
+
+// Validate returns an error if the dialect is not known.
+func (d Dialect) Validate() error {
+	if _, ok := dialectNames[d]; !ok {
+		return errors.NotValidf("wire dialect %d", int(d))
+	}
+	return nil
+}
+
+// EmitsLegacy returns true if the dialect emits the names used before
+// Juju 2.0.
+func (d Dialect) EmitsLegacy() bool {
+	return d != DialectJuju2
+}
+
+// EmitsJuju2 returns true if the dialect emits the Juju 2.0 names.
+func (d Dialect) EmitsJuju2() bool {
+	return d == DialectCompat || d == DialectJuju2
+}
+
+// renamedFields holds the fields renamed in Juju 2.0, under both their
+// names. Nil fields are omitted.
+type renamedFields struct {
+	EnvironmentUUID *string `json:"env-uuid,omitempty"`
+	ServiceName     *string `json:"service-name,omitempty"`
+	ModelUUID       *string `json:"model-uuid,omitempty"`
+	ApplicationName *string `json:"application,omitempty"`
+}
+
+// The shadowed types embed the V1 types one level deeper than
+// renamedFields, so that the renamed fields take precedence.
+type shadowedAuthorizationRequestV1 struct{ authorizationRequestV1 }
+type shadowedAuthorizationV1 struct{ authorizationV1 }
+type shadowedAuthorizationQueryV1 struct{ authorizationQueryV1 }
+
+// renamed returns the renamed fields to emit in the dialect.
+func (d Dialect) renamed(modelUUID, application string) (*renamedFields, error) {
+	if err := d.Validate(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	var f renamedFields
+	if d.EmitsLegacy() {
+		f.EnvironmentUUID, f.ServiceName = &modelUUID, &application
+	}
+	if d.EmitsJuju2() {
+		f.ModelUUID, f.ApplicationName = &modelUUID, &application
+	}
+	return &f, nil
+}
+
+// MarshalJSON implements json.Marshaler using DialectLegacy.
+func (ar AuthorizationRequest) MarshalJSON() ([]byte, error) {
+	return ar.MarshalJSONDialect(DialectLegacy)
+}
+
+// MarshalJSONDialect returns the JSON encoding of the authorization
+// request in the given dialect.
+func (ar AuthorizationRequest) MarshalJSONDialect(d Dialect) ([]byte, error) {
+	f, err := d.renamed(ar.EnvironmentUUID, ar.ServiceName)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return json.Marshal(struct {
+		shadowedAuthorizationRequestV1
+		*renamedFields
+	}{shadowedAuthorizationRequestV1{authorizationRequestV1(ar)}, f})
+}
+
+// MarshalJSON implements json.Marshaler using DialectLegacy.
+func (a Authorization) MarshalJSON() ([]byte, error) {
+	return a.MarshalJSONDialect(DialectLegacy)
+}
+
+// MarshalJSONDialect returns the JSON encoding of the authorization in
+// the given dialect.
+func (a Authorization) MarshalJSONDialect(d Dialect) ([]byte, error) {
+	f, err := d.renamed(a.EnvironmentUUID, a.ServiceName)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return json.Marshal(struct {
+		shadowedAuthorizationV1
+		*renamedFields
+	}{shadowedAuthorizationV1{authorizationV1(a)}, f})
+}
+
+// MarshalJSON implements json.Marshaler using DialectLegacy.
+func (q AuthorizationQuery) MarshalJSON() ([]byte, error) {
+	return q.MarshalJSONDialect(DialectLegacy)
+}
+
+// MarshalJSONDialect returns the JSON encoding of the authorization query
+// in the given dialect.
+func (q AuthorizationQuery) MarshalJSONDialect(d Dialect) ([]byte, error) {
+	f, err := d.renamed(q.EnvironmentUUID, q.ServiceName)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return json.Marshal(struct {
+		shadowedAuthorizationQueryV1
+		*renamedFields
+	}{shadowedAuthorizationQueryV1{authorizationQueryV1(q)}, f})
+}
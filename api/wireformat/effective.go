@@ -0,0 +1,143 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package wireformat
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PlanState describes which revision of a plan was effective for a charm
+// at a point in time, and whether the plan was suspended for the charm.
+type PlanState struct {
+	PlanURL  string    `json:"plan-url" yaml:"plan-url"`
+	CharmURL string    `json:"charm-url" yaml:"charm-url"`
+	Time     time.Time `json:"time" yaml:"time"`
+	// PlanID is the id of the effective revision, empty when no
+	// revision was effective.
+	PlanID         string     `json:"plan-id,omitempty" yaml:"plan-id,omitempty"`
+	EffectiveSince *time.Time `json:"effective-since,omitempty" yaml:"effective-since,omitempty"`
+	Attached       bool       `json:"attached" yaml:"attached"`
+	AttachedSince  *time.Time `json:"attached-since,omitempty" yaml:"attached-since,omitempty"`
+	Suspended      bool       `json:"suspended" yaml:"suspended"`
+	SuspendedSince *time.Time `json:"suspended-since,omitempty" yaml:"suspended-since,omitempty"`
+}
+
+// PlanStateAt replays the release events of the revisions of a plan and
+// the events of the charm to determine the state of the plan for the
+// charm at time t. Unreleased revisions are never effective. A released
+// revision is effective from its effective time, or its release time when
+// it has none, until the next revision becomes effective.
+func PlanStateAt(revisions []PlanDetails, charmURL string, t time.Time) PlanState {
+	state := PlanState{
+		CharmURL: charmURL,
+		Time:     t,
+	}
+	state.PlanID, state.EffectiveSince = EffectiveRevision(revisions, t)
+	var events []Event
+	seen := make(map[Event]bool)
+	addEvent := func(e Event) {
+		if !seen[e] {
+			seen[e] = true
+			events = append(events, e)
+		}
+	}
+	var attachedSince *time.Time
+	for _, d := range revisions {
+		if state.PlanURL == "" {
+			state.PlanURL = d.Plan.URL
+		}
+		for _, ch := range d.Charms {
+			if CharmBaseURL(ch.CharmURL) != CharmBaseURL(charmURL) {
+				continue
+			}
+			since := ch.Attached.Time
+			if ch.EffectiveSince != nil && ch.EffectiveSince.After(since) {
+				since = *ch.EffectiveSince
+			}
+			if attachedSince == nil || since.Before(*attachedSince) {
+				attachedSince = &since
+			}
+			for _, e := range ch.Events {
+				addEvent(e)
+			}
+		}
+	}
+	if attachedSince != nil && !attachedSince.After(t) {
+		state.Attached = true
+		state.AttachedSince = attachedSince
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	for i, e := range events {
+		if e.Time.After(t) {
+			break
+		}
+		switch e.EventType() {
+		case EventSuspended:
+			if !state.Suspended {
+				state.Suspended = true
+				state.SuspendedSince = &events[i].Time
+			}
+		case EventResumed:
+			state.Suspended = false
+			state.SuspendedSince = nil
+		}
+	}
+	return state
+}
+
+// EffectiveRevision returns the id of the revision of a plan effective
+// at time t, and the time it became effective, or an empty id if no
+// revision was effective. When several revisions became effective at the
+// same time, the highest revision wins.
+func EffectiveRevision(revisions []PlanDetails, t time.Time) (string, *time.Time) {
+	var planID string
+	var since *time.Time
+	effectiveRevision := 0
+	for _, d := range revisions {
+		effective, ok := revisionEffectiveTime(d)
+		if !ok || effective.After(t) {
+			continue
+		}
+		revision := 0
+		if id, err := ParsePlanID(d.Plan.Id); err == nil {
+			revision = id.Revision
+		}
+		if since == nil || effective.After(*since) ||
+			effective.Equal(*since) && revision > effectiveRevision {
+			planID = d.Plan.Id
+			since = &effective
+			effectiveRevision = revision
+		}
+	}
+	return planID, since
+}
+
+// revisionEffectiveTime returns the time the plan revision became
+// effective, or false if it has not been released.
+func revisionEffectiveTime(d PlanDetails) (time.Time, bool) {
+	if d.Released == nil {
+		return time.Time{}, false
+	}
+	if d.Plan.EffectiveTime != nil {
+		return *d.Plan.EffectiveTime, true
+	}
+	return d.Released.Time, true
+}
+
+// CharmBaseURL returns the charm url without its revision.
+func CharmBaseURL(charmURL string) string {
+	i := strings.LastIndex(charmURL, "-")
+	if i < 0 {
+		return charmURL
+	}
+	if _, err := strconv.Atoi(charmURL[i+1:]); err != nil {
+		return charmURL
+	}
+	return charmURL[:i]
+}
@@ -0,0 +1,140 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package wireformat_test
+
+import (
+	"time"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/plans-client/api/wireformat"
+)
+
+type PlanStateSuite struct{}
+
+var _ = gc.Suite(&PlanStateSuite{})
+
+func date(month time.Month, day int) time.Time {
+	return time.Date(2017, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func datep(month time.Month, day int) *time.Time {
+	t := date(month, day)
+	return &t
+}
+
+func testPlanRevisions() []wireformat.PlanDetails {
+	charmEvents := []wireformat.Event{
+		{User: "eve", Type: "suspend", Time: date(time.February, 10)},
+		{User: "eve", Type: "resume", Time: date(time.February, 20)},
+		{User: "eve", Type: "suspend", Time: date(time.April, 1)},
+	}
+	return []wireformat.PlanDetails{{
+		Plan:     wireformat.Plan{Id: "testisv/default/1", URL: "testisv/default", EffectiveTime: datep(time.January, 3)},
+		Created:  wireformat.Event{User: "jane", Type: "create", Time: date(time.January, 1)},
+		Released: &wireformat.Event{User: "jane", Type: "release", Time: date(time.January, 2)},
+		Charms: []wireformat.CharmPlanDetail{{
+			CharmURL: "cs:~testisv/charm1-2",
+			Attached: wireformat.Event{User: "jane", Type: "create", Time: date(time.January, 1)},
+			Events:   charmEvents,
+		}},
+	}, {
+		Plan:     wireformat.Plan{Id: "testisv/default/2", URL: "testisv/default", EffectiveTime: datep(time.March, 1)},
+		Created:  wireformat.Event{User: "jane", Type: "create", Time: date(time.February, 1)},
+		Released: &wireformat.Event{User: "jane", Type: "release", Time: date(time.February, 1)},
+		Charms: []wireformat.CharmPlanDetail{{
+			CharmURL: "cs:~testisv/charm1-3",
+			Attached: wireformat.Event{User: "jane", Type: "create", Time: date(time.January, 1)},
+			Events:   charmEvents,
+		}, {
+			CharmURL: "cs:~testisv/charm2-0",
+			Attached: wireformat.Event{User: "jane", Type: "create", Time: date(time.January, 1)},
+			Events:   []wireformat.Event{{User: "eve", Type: "suspend", Time: date(time.January, 5)}},
+		}},
+	}, {
+		Plan:    wireformat.Plan{Id: "testisv/default/3", URL: "testisv/default"},
+		Created: wireformat.Event{User: "jane", Type: "create", Time: date(time.March, 1)},
+	}}
+}
+
+func (s *PlanStateSuite) TestPlanStateAt(c *gc.C) {
+	tests := []struct {
+		about    string
+		time     time.Time
+		expected wireformat.PlanState
+	}{{
+		about: "before the plan was attached",
+		time:  date(time.January, 1).Add(-time.Hour),
+	}, {
+		about: "released but not yet effective",
+		time:  date(time.January, 2).Add(12 * time.Hour),
+		expected: wireformat.PlanState{
+			Attached:      true,
+			AttachedSince: datep(time.January, 1),
+		},
+	}, {
+		about: "first revision effective",
+		time:  date(time.January, 5),
+		expected: wireformat.PlanState{
+			PlanID:         "testisv/default/1",
+			EffectiveSince: datep(time.January, 3),
+			Attached:       true,
+			AttachedSince:  datep(time.January, 1),
+		},
+	}, {
+		about: "suspended",
+		time:  date(time.February, 15),
+		expected: wireformat.PlanState{
+			PlanID:         "testisv/default/1",
+			EffectiveSince: datep(time.January, 3),
+			Attached:       true,
+			AttachedSince:  datep(time.January, 1),
+			Suspended:      true,
+			SuspendedSince: datep(time.February, 10),
+		},
+	}, {
+		about: "resumed, second revision released but not effective",
+		time:  date(time.February, 25),
+		expected: wireformat.PlanState{
+			PlanID:         "testisv/default/1",
+			EffectiveSince: datep(time.January, 3),
+			Attached:       true,
+			AttachedSince:  datep(time.January, 1),
+		},
+	}, {
+		about: "second revision effective",
+		time:  date(time.March, 1),
+		expected: wireformat.PlanState{
+			PlanID:         "testisv/default/2",
+			EffectiveSince: datep(time.March, 1),
+			Attached:       true,
+			AttachedSince:  datep(time.January, 1),
+		},
+	}, {
+		about: "suspended again, unreleased revision ignored",
+		time:  date(time.May, 1),
+		expected: wireformat.PlanState{
+			PlanID:         "testisv/default/2",
+			EffectiveSince: datep(time.March, 1),
+			Attached:       true,
+			AttachedSince:  datep(time.January, 1),
+			Suspended:      true,
+			SuspendedSince: datep(time.April, 1),
+		},
+	}}
+	for i, t := range tests {
+		c.Logf("running test %d: %s", i, t.about)
+		t.expected.PlanURL = "testisv/default"
+		t.expected.CharmURL = "cs:~testisv/charm1-5"
+		t.expected.Time = t.time
+		state := wireformat.PlanStateAt(testPlanRevisions(), "cs:~testisv/charm1-5", t.time)
+		c.Assert(state, gc.DeepEquals, t.expected)
+	}
+}
+
+func (s *PlanStateSuite) TestCharmBaseURL(c *gc.C) {
+	c.Assert(wireformat.CharmBaseURL("cs:~testisv/charm1-5"), gc.Equals, "cs:~testisv/charm1")
+	c.Assert(wireformat.CharmBaseURL("cs:~testisv/charm1"), gc.Equals, "cs:~testisv/charm1")
+	c.Assert(wireformat.CharmBaseURL("cs:~testisv/my-charm"), gc.Equals, "cs:~testisv/my-charm")
+}
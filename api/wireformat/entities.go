@@ -6,6 +6,7 @@
 package wireformat
 
 import (
+	"bytes"
 	"encoding/json"
 	"regexp"
 	"time"
@@ -59,7 +60,16 @@ type Plan struct {
 	PlanPrice       string      `json:"price" yaml:"price"`
 	Released        bool        `json:"released" yaml:"released"`
 	EffectiveTime   *time.Time  `json:"effective-time,omitempty" yaml:"effective-on,omitempty"`
-	Model           interface{} `json:"model,omitempty" yaml:"model,omitempty"` // The rating plan model
+	Model           interface{} `json:"model,omitempty" yaml:"model,omitempty"`                 // The rating plan model
+	ReleaseNotes    string      `json:"release-notes,omitempty" yaml:"release-notes,omitempty"` // Notes on the changes made in the revision
+}
+
+// PlanMetadata holds the metadata of a plan revision set when the plan is
+// saved, alongside its definition.
+type PlanMetadata struct {
+	Description  string `yaml:"description"`
+	Price        string `yaml:"price"`
+	ReleaseNotes string `yaml:"release-notes"`
 }
 
 // UUIDResponse defines a response that just contains a uuid.
@@ -96,12 +106,16 @@ type authorizationRequestV1 AuthorizationRequest
 // UnmarshalJSON implements a transitional json.Unmarshaler to allow
 // forward-compatible processing of fields renamed in Juju 2.0.
 func (ar *AuthorizationRequest) UnmarshalJSON(data []byte) error {
+	return ar.unmarshalJSON(data, false)
+}
+
+func (ar *AuthorizationRequest) unmarshalJSON(data []byte, strict bool) error {
 	v := struct {
 		authorizationRequestV1
 		ModelUUID       string `json:"model-uuid"`
 		ApplicationName string `json:"application"`
 	}{}
-	if err := json.Unmarshal(data, &v); err != nil {
+	if err := unmarshalCompat(data, &v, strict); err != nil {
 		return err
 	}
 	*ar = AuthorizationRequest(v.authorizationRequestV1)
@@ -189,12 +203,16 @@ type Authorization struct {
 // UnmarshalJSON implements a transitional json.Unmarshaler to allow
 // forward-compatible processing of fields renamed in Juju 2.0.
 func (a *Authorization) UnmarshalJSON(data []byte) error {
+	return a.unmarshalJSON(data, false)
+}
+
+func (a *Authorization) unmarshalJSON(data []byte, strict bool) error {
 	v := struct {
 		authorizationV1
 		ModelUUID       string `json:"model-uuid"`
 		ApplicationName string `json:"application"`
 	}{}
-	if err := json.Unmarshal(data, &v); err != nil {
+	if err := unmarshalCompat(data, &v, strict); err != nil {
 		return err
 	}
 	*a = Authorization(v.authorizationV1)
@@ -207,6 +225,38 @@ func (a *Authorization) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// StrictAuthorizationRequest is an AuthorizationRequest whose JSON
+// decoding rejects unknown fields. The DisallowUnknownFields option of
+// json.Decoder does not apply to the transitional UnmarshalJSON method
+// of AuthorizationRequest.
+type StrictAuthorizationRequest AuthorizationRequest
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (ar *StrictAuthorizationRequest) UnmarshalJSON(data []byte) error {
+	return (*AuthorizationRequest)(ar).unmarshalJSON(data, true)
+}
+
+// StrictAuthorization is an Authorization whose JSON decoding rejects
+// unknown fields. The DisallowUnknownFields option of json.Decoder does
+// not apply to the transitional UnmarshalJSON method of Authorization.
+type StrictAuthorization Authorization
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (a *StrictAuthorization) UnmarshalJSON(data []byte) error {
+	return (*Authorization)(a).unmarshalJSON(data, true)
+}
+
+// unmarshalCompat decodes the document of a transitional UnmarshalJSON
+// method, rejecting unknown fields if strict is true.
+func unmarshalCompat(data []byte, v interface{}, strict bool) error {
+	if !strict {
+		return json.Unmarshal(data, v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
 // ResellerAuthorization defines the struct containing information on an issued
 // reseller plan authorization.
 type ResellerAuthorization struct {
@@ -235,6 +285,11 @@ type AuthorizationQuery struct {
 	StatementPeriod string `json:"statement-period"`
 }
 
+// Validate validates the AuthorizationQuery.
+func (q AuthorizationQuery) Validate() error {
+	return errors.Trace(validateStatementPeriod(q.StatementPeriod))
+}
+
 // TODO(api-compat): update tags above and remove this type when clients are ready.
 type authorizationQueryV1 AuthorizationQuery
 
@@ -278,11 +333,8 @@ type ResellerAuthorizationQuery struct {
 
 // Validate validates the ResellerAuthorizationQuery.
 func (q ResellerAuthorizationQuery) Validate() error {
-	if q.Reseller == "" {
-		if q.AuthUUID != "" {
-			return nil
-		}
+	if q.Reseller == "" && q.AuthUUID == "" {
 		return errors.BadRequestf("must specify the reseller name")
 	}
-	return nil
+	return errors.Trace(validateStatementPeriod(q.StatementPeriod))
 }
@@ -0,0 +1,215 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package wireformat
+
+import (
+	"sort"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// EventType is the kind of an event in the lifecycle of a plan.
+type EventType string
+
+// Event types recorded by the plans service.
+const (
+	EventCreated        EventType = "create"
+	EventReleased       EventType = "release"
+	EventAttached       EventType = "attach"
+	EventSuspended      EventType = "suspend"
+	EventResumed        EventType = "resume"
+	EventDefaultChanged EventType = "default-changed"
+)
+
+// EventEffective is not recorded by the plans service: it marks the
+// transition of a charm to the active state when the plan, or its
+// attachment to the charm, becomes effective.
+const EventEffective EventType = "effective"
+
+// EventType returns the type of the event.
+func (e Event) EventType() EventType {
+	return EventType(e.Type)
+}
+
+// CharmState is the state of a plan for a charm.
+type CharmState string
+
+const (
+	// CharmStatePendingEffective is the state of a charm attached to a
+	// plan that is not released yet, or whose release or attachment is
+	// not effective yet.
+	CharmStatePendingEffective CharmState = "pending-effective"
+	// CharmStateActive is the state of a charm for which the plan is
+	// effective.
+	CharmStateActive CharmState = "active"
+	// CharmStateSuspended is the state of a charm for which the plan
+	// has been suspended.
+	CharmStateSuspended CharmState = "suspended"
+)
+
+// Transition is a change of the state of a plan for a charm.
+type Transition struct {
+	State CharmState `json:"state" yaml:"state"`
+	Time  time.Time  `json:"time" yaml:"time"`
+	Event EventType  `json:"event" yaml:"event"`
+	User  string     `json:"user,omitempty" yaml:"user,omitempty"`
+}
+
+// CharmLifecycle is the state of a plan for a charm, folded from the
+// events of the plan.
+type CharmLifecycle struct {
+	CharmURL    string       `json:"charm" yaml:"charm"`
+	State       CharmState   `json:"state" yaml:"state"`
+	Since       time.Time    `json:"since" yaml:"since"`
+	Default     bool         `json:"default" yaml:"default"`
+	Transitions []Transition `json:"transitions" yaml:"transitions"`
+}
+
+// PlanLifecycles returns the state at time now of the plan for each
+// charm attached to it. It returns an error if the events of the plan
+// form an impossible sequence.
+func PlanLifecycles(details PlanDetails, now time.Time) ([]CharmLifecycle, error) {
+	lifecycles := make([]CharmLifecycle, len(details.Charms))
+	for i, ch := range details.Charms {
+		l, err := CharmLifecycleOf(details, ch, now)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		lifecycles[i] = *l
+	}
+	return lifecycles, nil
+}
+
+// lifecycleStep is an event applied to the state machine of a charm.
+type lifecycleStep struct {
+	order int
+	event Event
+}
+
+// Order of the steps occurring at the same time.
+const (
+	orderAttached = iota
+	orderEffective
+	orderEvent
+)
+
+// CharmLifecycleOf folds the events of the plan and of the charm, which
+// must be attached to the plan, into the state of the plan for the charm
+// at time now, recording the time of each transition.
+func CharmLifecycleOf(details PlanDetails, charm CharmPlanDetail, now time.Time) (*CharmLifecycle, error) {
+	if err := checkEventType(details.Created, EventCreated); err != nil {
+		return nil, errors.Annotate(err, "invalid plan created event")
+	}
+	if details.Released != nil {
+		if err := checkEventType(*details.Released, EventReleased); err != nil {
+			return nil, errors.Annotate(err, "invalid plan released event")
+		}
+		if details.Released.Time.Before(details.Created.Time) {
+			return nil, errors.Errorf("plan released at %v before it was created at %v", formatEventTime(details.Released.Time), formatEventTime(details.Created.Time))
+		}
+	}
+	attached := charm.Attached
+	if attached.EventType() != EventAttached {
+		if err := checkEventType(attached, EventCreated); err != nil {
+			return nil, errors.Annotatef(err, "invalid attached event of charm %v", charm.CharmURL)
+		}
+	}
+	if attached.Time.Before(details.Created.Time) {
+		return nil, errors.Errorf("charm %v attached at %v before the plan was created at %v", charm.CharmURL, formatEventTime(attached.Time), formatEventTime(details.Created.Time))
+	}
+
+	steps := []lifecycleStep{{order: orderAttached, event: Event{User: attached.User, Type: string(EventAttached), Time: attached.Time}}}
+	if effective, ok := revisionEffectiveTime(details); ok {
+		if effective.Before(attached.Time) {
+			effective = attached.Time
+		}
+		if charm.EffectiveSince != nil && charm.EffectiveSince.After(effective) {
+			effective = *charm.EffectiveSince
+		}
+		if !effective.After(now) {
+			steps = append(steps, lifecycleStep{order: orderEffective, event: Event{Type: string(EventEffective), Time: effective}})
+		}
+	}
+	for _, e := range charm.Events {
+		if e.Time.Before(attached.Time) {
+			return nil, errors.Errorf("charm %v %s event at %v before it was attached at %v", charm.CharmURL, e.Type, formatEventTime(e.Time), formatEventTime(attached.Time))
+		}
+		if e.Time.After(now) {
+			continue
+		}
+		steps = append(steps, lifecycleStep{order: orderEvent, event: e})
+	}
+	sort.SliceStable(steps, func(i, j int) bool {
+		if !steps[i].event.Time.Equal(steps[j].event.Time) {
+			return steps[i].event.Time.Before(steps[j].event.Time)
+		}
+		return steps[i].order < steps[j].order
+	})
+
+	l := &CharmLifecycle{
+		CharmURL: charm.CharmURL,
+		Default:  charm.Default,
+	}
+	var effective, suspended bool
+	for i, step := range steps {
+		e := step.event
+		switch e.EventType() {
+		case EventAttached:
+			if i > 0 {
+				return nil, errors.Errorf("charm %v attached at %v while already attached", charm.CharmURL, formatEventTime(e.Time))
+			}
+		case EventEffective:
+			effective = true
+		case EventSuspended:
+			if suspended {
+				return nil, errors.Errorf("charm %v suspended at %v while already suspended since %v", charm.CharmURL, formatEventTime(e.Time), formatEventTime(l.Since))
+			}
+			suspended = true
+		case EventResumed:
+			if !suspended {
+				return nil, errors.Errorf("charm %v resumed at %v while not suspended", charm.CharmURL, formatEventTime(e.Time))
+			}
+			suspended = false
+		case EventDefaultChanged:
+			continue
+		case EventCreated, EventReleased:
+			return nil, errors.Errorf("unexpected %s event at %v for charm %v", e.Type, formatEventTime(e.Time), charm.CharmURL)
+		default:
+			return nil, errors.Errorf("unknown event type %q at %v for charm %v", e.Type, formatEventTime(e.Time), charm.CharmURL)
+		}
+		state := CharmStatePendingEffective
+		switch {
+		case suspended:
+			state = CharmStateSuspended
+		case effective:
+			state = CharmStateActive
+		}
+		if i > 0 && state == l.State {
+			continue
+		}
+		l.State = state
+		l.Since = e.Time
+		l.Transitions = append(l.Transitions, Transition{
+			State: state,
+			Time:  e.Time,
+			Event: e.EventType(),
+			User:  e.User,
+		})
+	}
+	return l, nil
+}
+
+// checkEventType checks that the event has the expected type. Events
+// without a type are accepted.
+func checkEventType(e Event, expected EventType) error {
+	if e.Type != "" && e.EventType() != expected {
+		return errors.Errorf("expected %s event, got %q", expected, e.Type)
+	}
+	return nil
+}
+
+func formatEventTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
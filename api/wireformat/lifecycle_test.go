@@ -0,0 +1,206 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package wireformat_test
+
+import (
+	"encoding/json"
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/plans-client/api/wireformat"
+)
+
+type LifecycleSuite struct{}
+
+var _ = gc.Suite(&LifecycleSuite{})
+
+func testPlanDetails() wireformat.PlanDetails {
+	return wireformat.PlanDetails{
+		Plan:     wireformat.Plan{Id: "testisv/default/1", URL: "testisv/default"},
+		Created:  wireformat.Event{User: "jane", Type: "create", Time: date(time.January, 1)},
+		Released: &wireformat.Event{User: "jane", Type: "release", Time: date(time.January, 3)},
+	}
+}
+
+func (s *LifecycleSuite) TestEventTypeJSON(c *gc.C) {
+	var e wireformat.Event
+	err := json.Unmarshal([]byte(`{"user":"eve","type":"suspend","time":"2017-02-01T00:00:00Z"}`), &e)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(e.EventType(), gc.Equals, wireformat.EventSuspended)
+}
+
+func (s *LifecycleSuite) TestLifecycle(c *gc.C) {
+	details := testPlanDetails()
+	charm := wireformat.CharmPlanDetail{
+		CharmURL: "cs:~testisv/charm1-0",
+		Attached: wireformat.Event{User: "jane", Type: "create", Time: date(time.January, 2)},
+		Default:  true,
+		// Events are not ordered by the plans service.
+		Events: []wireformat.Event{
+			{User: "eve", Type: "resume", Time: date(time.February, 20)},
+			{User: "jane", Type: "default-changed", Time: date(time.February, 1)},
+			{User: "eve", Type: "suspend", Time: date(time.February, 10)},
+		},
+	}
+	l, err := wireformat.CharmLifecycleOf(details, charm, date(time.March, 1))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(l, jc.DeepEquals, &wireformat.CharmLifecycle{
+		CharmURL: "cs:~testisv/charm1-0",
+		State:    wireformat.CharmStateActive,
+		Since:    date(time.February, 20),
+		Default:  true,
+		Transitions: []wireformat.Transition{
+			{State: wireformat.CharmStatePendingEffective, Time: date(time.January, 2), Event: wireformat.EventAttached, User: "jane"},
+			{State: wireformat.CharmStateActive, Time: date(time.January, 3), Event: wireformat.EventEffective},
+			{State: wireformat.CharmStateSuspended, Time: date(time.February, 10), Event: wireformat.EventSuspended, User: "eve"},
+			{State: wireformat.CharmStateActive, Time: date(time.February, 20), Event: wireformat.EventResumed, User: "eve"},
+		},
+	})
+
+	l, err = wireformat.CharmLifecycleOf(details, charm, date(time.February, 15))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(l.State, gc.Equals, wireformat.CharmStateSuspended)
+	c.Assert(l.Since, gc.Equals, date(time.February, 10))
+}
+
+func (s *LifecycleSuite) TestPendingEffective(c *gc.C) {
+	charm := wireformat.CharmPlanDetail{
+		CharmURL: "cs:~testisv/charm1-0",
+		Attached: wireformat.Event{User: "jane", Type: "attach", Time: date(time.January, 2)},
+	}
+	unreleased := testPlanDetails()
+	unreleased.Released = nil
+	l, err := wireformat.CharmLifecycleOf(unreleased, charm, date(time.March, 1))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(l.State, gc.Equals, wireformat.CharmStatePendingEffective)
+	c.Assert(l.Since, gc.Equals, date(time.January, 2))
+
+	future := testPlanDetails()
+	future.Plan.EffectiveTime = datep(time.April, 1)
+	l, err = wireformat.CharmLifecycleOf(future, charm, date(time.March, 1))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(l.State, gc.Equals, wireformat.CharmStatePendingEffective)
+	l, err = wireformat.CharmLifecycleOf(future, charm, date(time.April, 1))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(l.State, gc.Equals, wireformat.CharmStateActive)
+	c.Assert(l.Since, gc.Equals, date(time.April, 1))
+
+	charm.EffectiveSince = datep(time.May, 1)
+	l, err = wireformat.CharmLifecycleOf(future, charm, date(time.April, 10))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(l.State, gc.Equals, wireformat.CharmStatePendingEffective)
+
+	// A suspension before the plan is effective is kept when it
+	// becomes effective.
+	charm.EffectiveSince = nil
+	charm.Events = []wireformat.Event{{Type: "suspend", Time: date(time.March, 1)}}
+	l, err = wireformat.CharmLifecycleOf(future, charm, date(time.April, 10))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(l.State, gc.Equals, wireformat.CharmStateSuspended)
+	c.Assert(l.Since, gc.Equals, date(time.March, 1))
+}
+
+func (s *LifecycleSuite) TestImpossibleSequences(c *gc.C) {
+	tests := []struct {
+		about  string
+		mutate func(*wireformat.PlanDetails, *wireformat.CharmPlanDetail)
+		err    string
+	}{{
+		about: "suspended twice",
+		mutate: func(_ *wireformat.PlanDetails, ch *wireformat.CharmPlanDetail) {
+			ch.Events = []wireformat.Event{
+				{Type: "suspend", Time: date(time.February, 1)},
+				{Type: "suspend", Time: date(time.February, 2)},
+			}
+		},
+		err: `charm cs:~testisv/charm1-0 suspended at 2017-02-02T00:00:00Z while already suspended since 2017-02-01T00:00:00Z`,
+	}, {
+		about: "resumed while not suspended",
+		mutate: func(_ *wireformat.PlanDetails, ch *wireformat.CharmPlanDetail) {
+			ch.Events = []wireformat.Event{{Type: "resume", Time: date(time.February, 1)}}
+		},
+		err: `charm cs:~testisv/charm1-0 resumed at 2017-02-01T00:00:00Z while not suspended`,
+	}, {
+		about: "attached twice",
+		mutate: func(_ *wireformat.PlanDetails, ch *wireformat.CharmPlanDetail) {
+			ch.Events = []wireformat.Event{{Type: "attach", Time: date(time.February, 1)}}
+		},
+		err: `charm cs:~testisv/charm1-0 attached at 2017-02-01T00:00:00Z while already attached`,
+	}, {
+		about: "event before the attachment",
+		mutate: func(_ *wireformat.PlanDetails, ch *wireformat.CharmPlanDetail) {
+			ch.Events = []wireformat.Event{{Type: "suspend", Time: date(time.January, 1)}}
+		},
+		err: `charm cs:~testisv/charm1-0 suspend event at 2017-01-01T00:00:00Z before it was attached at 2017-01-02T00:00:00Z`,
+	}, {
+		about: "attached before the plan was created",
+		mutate: func(_ *wireformat.PlanDetails, ch *wireformat.CharmPlanDetail) {
+			ch.Attached.Time = date(time.January, 1).Add(-time.Hour)
+		},
+		err: `charm cs:~testisv/charm1-0 attached at 2016-12-31T23:00:00Z before the plan was created at 2017-01-01T00:00:00Z`,
+	}, {
+		about: "released before the plan was created",
+		mutate: func(d *wireformat.PlanDetails, _ *wireformat.CharmPlanDetail) {
+			d.Released.Time = date(time.January, 1).Add(-time.Hour)
+		},
+		err: `plan released at 2016-12-31T23:00:00Z before it was created at 2017-01-01T00:00:00Z`,
+	}, {
+		about: "invalid released event",
+		mutate: func(d *wireformat.PlanDetails, _ *wireformat.CharmPlanDetail) {
+			d.Released.Type = "suspend"
+		},
+		err: `invalid plan released event: expected release event, got "suspend"`,
+	}, {
+		about: "invalid attached event",
+		mutate: func(_ *wireformat.PlanDetails, ch *wireformat.CharmPlanDetail) {
+			ch.Attached.Type = "resume"
+		},
+		err: `invalid attached event of charm cs:~testisv/charm1-0: expected create event, got "resume"`,
+	}, {
+		about: "plan event in the charm events",
+		mutate: func(_ *wireformat.PlanDetails, ch *wireformat.CharmPlanDetail) {
+			ch.Events = []wireformat.Event{{Type: "release", Time: date(time.February, 1)}}
+		},
+		err: `unexpected release event at 2017-02-01T00:00:00Z for charm cs:~testisv/charm1-0`,
+	}, {
+		about: "unknown event",
+		mutate: func(_ *wireformat.PlanDetails, ch *wireformat.CharmPlanDetail) {
+			ch.Events = []wireformat.Event{{Type: "detach", Time: date(time.February, 1)}}
+		},
+		err: `unknown event type "detach" at 2017-02-01T00:00:00Z for charm cs:~testisv/charm1-0`,
+	}}
+	for i, t := range tests {
+		c.Logf("running test %d: %s", i, t.about)
+		details := testPlanDetails()
+		charm := wireformat.CharmPlanDetail{
+			CharmURL: "cs:~testisv/charm1-0",
+			Attached: wireformat.Event{Type: "create", Time: date(time.January, 2)},
+		}
+		t.mutate(&details, &charm)
+		_, err := wireformat.CharmLifecycleOf(details, charm, date(time.March, 1))
+		c.Assert(err, gc.ErrorMatches, t.err)
+		details.Charms = []wireformat.CharmPlanDetail{charm}
+		_, err = wireformat.PlanLifecycles(details, date(time.March, 1))
+		c.Assert(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *LifecycleSuite) TestPlanLifecycles(c *gc.C) {
+	details := testPlanDetails()
+	details.Charms = []wireformat.CharmPlanDetail{{
+		CharmURL: "cs:~testisv/charm1-0",
+		Attached: wireformat.Event{Type: "create", Time: date(time.January, 2)},
+	}, {
+		CharmURL: "cs:~testisv/charm2-0",
+		Attached: wireformat.Event{Type: "create", Time: date(time.January, 2)},
+		Events:   []wireformat.Event{{Type: "suspend", Time: date(time.February, 1)}},
+	}}
+	lifecycles, err := wireformat.PlanLifecycles(details, date(time.March, 1))
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(lifecycles, gc.HasLen, 2)
+	c.Assert(lifecycles[0].State, gc.Equals, wireformat.CharmStateActive)
+	c.Assert(lifecycles[1].State, gc.Equals, wireformat.CharmStateSuspended)
+}
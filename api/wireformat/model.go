@@ -0,0 +1,107 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package wireformat
+
+import (
+	"encoding/json"
+
+	"github.com/juju/errors"
+)
+
+// RatingModel is the rating model of a plan, as compiled by the plans
+// service from the plan definition.
+type RatingModel struct {
+	Description *ModelDescription      `json:"description,omitempty" yaml:"description,omitempty"`
+	Metrics     map[string]MetricModel `json:"metrics,omitempty" yaml:"metrics,omitempty"`
+}
+
+// ModelDescription describes the plan to its users.
+type ModelDescription struct {
+	Price string `json:"price,omitempty" yaml:"price,omitempty"`
+	Text  string `json:"text,omitempty" yaml:"text,omitempty"`
+}
+
+// MetricModel defines how a metric is rated.
+type MetricModel struct {
+	Unit        *MetricUnit `json:"unit,omitempty" yaml:"unit,omitempty"`
+	Price       float64     `json:"price" yaml:"price"`
+	Description string      `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// MetricUnit defines how metric values are aggregated.
+type MetricUnit struct {
+	Transform string `json:"transform,omitempty" yaml:"transform,omitempty"`
+	Period    string `json:"period,omitempty" yaml:"period,omitempty"`
+	Gaps      string `json:"gaps,omitempty" yaml:"gaps,omitempty"`
+}
+
+// RatingModel decodes the rating model of the plan. The model is kept
+// untyped in the wire format so that plans round-trip unchanged: fields
+// unknown to RatingModel are ignored here but preserved in Plan.Model.
+func (p Plan) RatingModel() (*RatingModel, error) {
+	if p.Model == nil {
+		return nil, errors.NotFoundf("rating model of plan %v", p.Id)
+	}
+	var data []byte
+	switch m := p.Model.(type) {
+	case string:
+		// The model may have been encoded as a JSON document.
+		data = []byte(m)
+	default:
+		v, err := jsonValue(m)
+		if err != nil {
+			return nil, errors.Annotatef(err, "invalid rating model of plan %v", p.Id)
+		}
+		if data, err = json.Marshal(v); err != nil {
+			return nil, errors.Annotatef(err, "invalid rating model of plan %v", p.Id)
+		}
+	}
+	var model RatingModel
+	if err := json.Unmarshal(data, &model); err != nil {
+		return nil, errors.Annotatef(err, "invalid rating model of plan %v", p.Id)
+	}
+	return &model, nil
+}
+
+// jsonValue converts the maps decoded from YAML, which are keyed by
+// interface{}, into maps that can be encoded as JSON.
+func jsonValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			k, ok := key.(string)
+			if !ok {
+				return nil, errors.Errorf("unexpected key %v of type %T", key, key)
+			}
+			converted, err := jsonValue(value)
+			if err != nil {
+				return nil, errors.Annotatef(err, "in %q", k)
+			}
+			m[k] = converted
+		}
+		return m, nil
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			converted, err := jsonValue(value)
+			if err != nil {
+				return nil, errors.Annotatef(err, "in %q", k)
+			}
+			m[k] = converted
+		}
+		return m, nil
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, value := range v {
+			converted, err := jsonValue(value)
+			if err != nil {
+				return nil, errors.Annotatef(err, "at index %d", i)
+			}
+			s[i] = converted
+		}
+		return s, nil
+	}
+	return v, nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package wireformat_test
+
+import (
+	"encoding/json"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/yaml.v2"
+
+	"github.com/juju/plans-client/api/wireformat"
+)
+
+type ModelSuite struct{}
+
+var _ = gc.Suite(&ModelSuite{})
+
+const modelJSON = `{
+	"id": "owner/name/1",
+	"model": {
+		"description": {"price": "10USD per unit/month", "text": "a test plan"},
+		"metrics": {
+			"pings": {
+				"unit": {"transform": "max", "period": "hour", "gaps": "zero"},
+				"price": 0.01,
+				"future-field": true
+			}
+		}
+	}
+}`
+
+var expectedModel = wireformat.RatingModel{
+	Description: &wireformat.ModelDescription{Price: "10USD per unit/month", Text: "a test plan"},
+	Metrics: map[string]wireformat.MetricModel{
+		"pings": {
+			Unit:  &wireformat.MetricUnit{Transform: "max", Period: "hour", Gaps: "zero"},
+			Price: 0.01,
+		},
+	},
+}
+
+func (s *ModelSuite) TestRatingModelFromJSON(c *gc.C) {
+	var plan wireformat.Plan
+	err := json.Unmarshal([]byte(modelJSON), &plan)
+	c.Assert(err, jc.ErrorIsNil)
+	model, err := plan.RatingModel()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(*model, jc.DeepEquals, expectedModel)
+
+	// Fields unknown to the rating model survive a round trip of the
+	// plan.
+	data, err := json.Marshal(plan)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Matches, `.*"future-field":true.*`)
+}
+
+func (s *ModelSuite) TestRatingModelFromYAML(c *gc.C) {
+	var plan wireformat.Plan
+	err := yaml.Unmarshal([]byte(`
+id: owner/name/1
+model:
+  description:
+    price: 10USD per unit/month
+    text: a test plan
+  metrics:
+    pings:
+      unit:
+        transform: max
+        period: hour
+        gaps: zero
+      price: 0.01
+`), &plan)
+	c.Assert(err, jc.ErrorIsNil)
+	model, err := plan.RatingModel()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(*model, jc.DeepEquals, expectedModel)
+}
+
+func (s *ModelSuite) TestRatingModelFromString(c *gc.C) {
+	plan := wireformat.Plan{Id: "owner/name/1", Model: `{"metrics": {"pings": {"price": 2}}}`}
+	model, err := plan.RatingModel()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(*model, jc.DeepEquals, wireformat.RatingModel{
+		Metrics: map[string]wireformat.MetricModel{"pings": {Price: 2}},
+	})
+}
+
+func (s *ModelSuite) TestRatingModelErrors(c *gc.C) {
+	_, err := wireformat.Plan{Id: "owner/name/1"}.RatingModel()
+	c.Assert(err, gc.ErrorMatches, `rating model of plan owner/name/1 not found`)
+	c.Assert(errors.IsNotFound(err), jc.IsTrue)
+
+	_, err = wireformat.Plan{Id: "owner/name/1", Model: []interface{}{"pings"}}.RatingModel()
+	c.Assert(err, gc.ErrorMatches, `invalid rating model of plan owner/name/1: json: cannot unmarshal array .*`)
+
+	_, err = wireformat.Plan{Id: "owner/name/1", Model: map[interface{}]interface{}{1: "pings"}}.RatingModel()
+	c.Assert(err, gc.ErrorMatches, `invalid rating model of plan owner/name/1: unexpected key 1 of type int`)
+}
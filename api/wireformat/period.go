@@ -0,0 +1,168 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package wireformat
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// statementPeriodRE matches statement periods in the format expected by
+// the plans service: a four digit year and a two digit month.
+var statementPeriodRE = regexp.MustCompile(`^([0-9]{4})-([0-9]{2})$`)
+
+// Relative statement periods accepted by ResolveStatementPeriod.
+const (
+	ThisMonth = "this-month"
+	LastMonth = "last-month"
+)
+
+// StatementPeriod is a calendar month for which statements are issued,
+// in UTC.
+type StatementPeriod struct {
+	Year  int
+	Month time.Month
+}
+
+// ParseStatementPeriod parses a statement period in the YYYY-MM format.
+func ParseStatementPeriod(s string) (StatementPeriod, error) {
+	parts := statementPeriodRE.FindStringSubmatch(s)
+	if parts == nil {
+		return StatementPeriod{}, errors.NotValidf("statement period %q", s)
+	}
+	year, _ := strconv.Atoi(parts[1])
+	month, _ := strconv.Atoi(parts[2])
+	if year == 0 || month < 1 || month > 12 {
+		return StatementPeriod{}, errors.NotValidf("statement period %q", s)
+	}
+	return StatementPeriod{Year: year, Month: time.Month(month)}, nil
+}
+
+// ResolveStatementPeriod parses a statement period in the YYYY-MM
+// format, or one of the relative periods "this-month" and "last-month",
+// which are resolved relative to now.
+func ResolveStatementPeriod(s string, now time.Time) (StatementPeriod, error) {
+	switch s {
+	case ThisMonth:
+		return StatementPeriodOf(now), nil
+	case LastMonth:
+		return StatementPeriodOf(now).Prev(), nil
+	}
+	return ParseStatementPeriod(s)
+}
+
+// StatementPeriodOf returns the statement period including the time.
+func StatementPeriodOf(t time.Time) StatementPeriod {
+	t = t.UTC()
+	return StatementPeriod{Year: t.Year(), Month: t.Month()}
+}
+
+// String returns the statement period in the YYYY-MM format.
+func (p StatementPeriod) String() string {
+	if p.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%04d-%02d", p.Year, p.Month)
+}
+
+// IsZero returns true for the zero statement period, used when no period
+// is specified.
+func (p StatementPeriod) IsZero() bool {
+	return p == StatementPeriod{}
+}
+
+// Validate checks that the statement period is a valid month.
+func (p StatementPeriod) Validate() error {
+	if p.Year < 1 || p.Year > 9999 || p.Month < time.January || p.Month > time.December {
+		return errors.NotValidf("statement period %d-%d", p.Year, p.Month)
+	}
+	return nil
+}
+
+// Start returns the first instant of the statement period.
+func (p StatementPeriod) Start() time.Time {
+	return time.Date(p.Year, p.Month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// End returns the first instant after the statement period, which is
+// the start of the next period.
+func (p StatementPeriod) End() time.Time {
+	return p.Next().Start()
+}
+
+// Contains returns true if the time is within the statement period.
+func (p StatementPeriod) Contains(t time.Time) bool {
+	return !t.Before(p.Start()) && t.Before(p.End())
+}
+
+// AddMonths returns the statement period n months after p, or before p
+// if n is negative.
+func (p StatementPeriod) AddMonths(n int) StatementPeriod {
+	return StatementPeriodOf(p.Start().AddDate(0, n, 0))
+}
+
+// Next returns the statement period following p.
+func (p StatementPeriod) Next() StatementPeriod {
+	return p.AddMonths(1)
+}
+
+// Prev returns the statement period preceding p.
+func (p StatementPeriod) Prev() StatementPeriod {
+	return p.AddMonths(-1)
+}
+
+// Before returns true if p precedes q.
+func (p StatementPeriod) Before(q StatementPeriod) bool {
+	return p.Year < q.Year || p.Year == q.Year && p.Month < q.Month
+}
+
+// StatementPeriodRange returns the statement periods from the first to
+// the last, inclusive. It returns nil if last precedes first.
+func StatementPeriodRange(first, last StatementPeriod) []StatementPeriod {
+	var periods []StatementPeriod
+	for p := first; !last.Before(p); p = p.Next() {
+		periods = append(periods, p)
+	}
+	return periods
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (p StatementPeriod) MarshalText() ([]byte, error) {
+	if p.IsZero() {
+		return []byte{}, nil
+	}
+	if err := p.Validate(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. An empty text
+// unmarshals to the zero statement period.
+func (p *StatementPeriod) UnmarshalText(data []byte) error {
+	if len(data) == 0 {
+		*p = StatementPeriod{}
+		return nil
+	}
+	period, err := ParseStatementPeriod(string(data))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	*p = period
+	return nil
+}
+
+// validateStatementPeriod checks the statement period of a query, which
+// may be empty.
+func validateStatementPeriod(s string) error {
+	if s == "" {
+		return nil
+	}
+	_, err := ParseStatementPeriod(s)
+	return errors.Trace(err)
+}
@@ -0,0 +1,145 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package wireformat_test
+
+import (
+	"encoding/json"
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/plans-client/api/wireformat"
+)
+
+type StatementPeriodSuite struct{}
+
+var _ = gc.Suite(&StatementPeriodSuite{})
+
+func (s *StatementPeriodSuite) TestParse(c *gc.C) {
+	tests := []struct {
+		about  string
+		period string
+		result wireformat.StatementPeriod
+		err    string
+	}{{
+		about:  "valid period",
+		period: "2026-09",
+		result: wireformat.StatementPeriod{Year: 2026, Month: time.September},
+	}, {
+		about:  "single digit month",
+		period: "2026-9",
+		err:    `statement period "2026-9" not valid`,
+	}, {
+		about:  "invalid month",
+		period: "2026-13",
+		err:    `statement period "2026-13" not valid`,
+	}, {
+		about:  "zero month",
+		period: "2026-00",
+		err:    `statement period "2026-00" not valid`,
+	}, {
+		about:  "zero year",
+		period: "0000-01",
+		err:    `statement period "0000-01" not valid`,
+	}, {
+		about:  "date",
+		period: "2026-09-01",
+		err:    `statement period "2026-09-01" not valid`,
+	}, {
+		about:  "relative period",
+		period: "last-month",
+		err:    `statement period "last-month" not valid`,
+	}}
+	for i, t := range tests {
+		c.Logf("running test %d: %s", i, t.about)
+		p, err := wireformat.ParseStatementPeriod(t.period)
+		if t.err != "" {
+			c.Assert(err, gc.ErrorMatches, t.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(p, gc.Equals, t.result)
+		c.Assert(p.String(), gc.Equals, t.period)
+	}
+}
+
+func (s *StatementPeriodSuite) TestResolve(c *gc.C) {
+	now := time.Date(2026, 1, 31, 23, 0, 0, 0, time.FixedZone("UTC-2", -2*3600))
+	p, err := wireformat.ResolveStatementPeriod("this-month", now)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(p.String(), gc.Equals, "2026-02")
+	p, err = wireformat.ResolveStatementPeriod("last-month", now)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(p.String(), gc.Equals, "2026-01")
+	p, err = wireformat.ResolveStatementPeriod("2025-06", now)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(p.String(), gc.Equals, "2025-06")
+	_, err = wireformat.ResolveStatementPeriod("next-month", now)
+	c.Assert(err, gc.ErrorMatches, `statement period "next-month" not valid`)
+}
+
+func (s *StatementPeriodSuite) TestTimes(c *gc.C) {
+	p := wireformat.StatementPeriod{Year: 2026, Month: time.December}
+	c.Assert(p.Start(), gc.Equals, time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC))
+	c.Assert(p.End(), gc.Equals, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC))
+	c.Assert(p.Contains(time.Date(2026, 12, 31, 23, 59, 59, 0, time.UTC)), jc.IsTrue)
+	c.Assert(p.Contains(p.End()), jc.IsFalse)
+	c.Assert(p.Contains(p.Start()), jc.IsTrue)
+	c.Assert(p.Next().String(), gc.Equals, "2027-01")
+	c.Assert(p.Prev().String(), gc.Equals, "2026-11")
+	c.Assert(p.AddMonths(-12).String(), gc.Equals, "2025-12")
+	c.Assert(p.Prev().Before(p), jc.IsTrue)
+	c.Assert(p.Before(p), jc.IsFalse)
+	c.Assert(wireformat.StatementPeriodOf(time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)).String(), gc.Equals, "2026-03")
+}
+
+func (s *StatementPeriodSuite) TestRange(c *gc.C) {
+	first := wireformat.StatementPeriod{Year: 2026, Month: time.November}
+	periods := wireformat.StatementPeriodRange(first, first.AddMonths(3))
+	var names []string
+	for _, p := range periods {
+		names = append(names, p.String())
+	}
+	c.Assert(names, jc.DeepEquals, []string{"2026-11", "2026-12", "2027-01", "2027-02"})
+	c.Assert(wireformat.StatementPeriodRange(first, first), gc.HasLen, 1)
+	c.Assert(wireformat.StatementPeriodRange(first, first.Prev()), gc.HasLen, 0)
+}
+
+func (s *StatementPeriodSuite) TestMarshal(c *gc.C) {
+	v := struct {
+		Period wireformat.StatementPeriod `json:"period"`
+	}{wireformat.StatementPeriod{Year: 2026, Month: time.September}}
+	data, err := json.Marshal(v)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, `{"period":"2026-09"}`)
+
+	v.Period = wireformat.StatementPeriod{}
+	err = json.Unmarshal([]byte(`{"period":"2025-02"}`), &v)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(v.Period, gc.Equals, wireformat.StatementPeriod{Year: 2025, Month: time.February})
+
+	err = json.Unmarshal([]byte(`{"period":""}`), &v)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(v.Period.IsZero(), jc.IsTrue)
+	data, err = json.Marshal(v)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, `{"period":""}`)
+
+	err = json.Unmarshal([]byte(`{"period":"2025-2"}`), &v)
+	c.Assert(err, gc.ErrorMatches, `statement period "2025-2" not valid`)
+	_, err = json.Marshal(struct{ P wireformat.StatementPeriod }{wireformat.StatementPeriod{Year: 2025, Month: 13}})
+	c.Assert(err, gc.ErrorMatches, `.*statement period 2025-13 not valid`)
+}
+
+func (s *StatementPeriodSuite) TestQueryValidation(c *gc.C) {
+	err := wireformat.AuthorizationQuery{StatementPeriod: "2026-09"}.Validate()
+	c.Assert(err, jc.ErrorIsNil)
+	err = wireformat.AuthorizationQuery{}.Validate()
+	c.Assert(err, jc.ErrorIsNil)
+	err = wireformat.AuthorizationQuery{StatementPeriod: "September"}.Validate()
+	c.Assert(err, gc.ErrorMatches, `statement period "September" not valid`)
+	err = wireformat.ResellerAuthorizationQuery{Reseller: "acme", StatementPeriod: "2026-9"}.Validate()
+	c.Assert(err, gc.ErrorMatches, `statement period "2026-9" not valid`)
+}
@@ -0,0 +1,100 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package wireformat
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// planScheme qualifies a plan reference without naming the plans
+// service.
+const planScheme = "plan:"
+
+// Regular expression matching the revision component of a plan id or of
+// a revision selector.
+var revisionComponent = regexp.MustCompile(`^([0-9]+|latest|released|[0-9]+\.\.[0-9]+|-[0-9]+)$`)
+
+// PlanReference is a plan url or plan id, optionally qualified with the
+// plans service the plan belongs to.
+type PlanReference struct {
+	// ServiceURL is the url of the plans service, empty when the
+	// reference does not name one.
+	ServiceURL string
+	// PlanID identifies the plan. Its revision is 0 when the reference
+	// names the plan rather than one of its revisions.
+	PlanID PlanID
+}
+
+// ParsePlanReference parses a plan url or plan id, which may be qualified
+// with the plan: scheme or given as an http or https url of the plans
+// service followed by the plan url or plan id:
+//
+//	canonical/default/3
+//	plan:canonical/default/3
+//	https://plans.staging.example/canonical/default/3
+func ParsePlanReference(s string) (*PlanReference, error) {
+	serviceURL, plan, err := SplitPlanReference(s)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	id, err := ParsePlanIDWithOptionalRevision(plan)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &PlanReference{
+		ServiceURL: serviceURL,
+		PlanID:     *id,
+	}, nil
+}
+
+// SplitPlanReference splits a plan reference into the url of the plans
+// service, empty when the reference does not name one, and the plan part
+// of the reference. The plan part is not validated, so that it may hold a
+// revision selector.
+func SplitPlanReference(s string) (serviceURL, plan string, err error) {
+	if strings.HasPrefix(s, planScheme) {
+		return "", strings.TrimPrefix(s, planScheme), nil
+	}
+	if !strings.HasPrefix(s, "https://") && !strings.HasPrefix(s, "http://") {
+		return "", s, nil
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return "", "", errors.NotValidf("plan reference %q", s)
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	n := 2
+	if revisionComponent.MatchString(parts[len(parts)-1]) {
+		n = 3
+	}
+	if len(parts) < n || parts[0] == "" {
+		return "", "", errors.NotValidf("plan reference %q", s)
+	}
+	service := url.URL{
+		Scheme: u.Scheme,
+		User:   u.User,
+		Host:   u.Host,
+	}
+	if prefix := parts[:len(parts)-n]; len(prefix) > 0 {
+		service.Path = "/" + strings.Join(prefix, "/")
+	}
+	return service.String(), strings.Join(parts[len(parts)-n:], "/"), nil
+}
+
+// String returns the plan reference in the format parsed by
+// ParsePlanReference.
+func (r PlanReference) String() string {
+	plan := r.PlanID.PlanURL.String()
+	if r.PlanID.Revision != 0 {
+		plan = r.PlanID.String()
+	}
+	if r.ServiceURL == "" {
+		return plan
+	}
+	return strings.TrimSuffix(r.ServiceURL, "/") + "/" + plan
+}
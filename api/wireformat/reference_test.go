@@ -0,0 +1,127 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package wireformat_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/plans-client/api/wireformat"
+)
+
+type ReferenceSuite struct{}
+
+var _ = gc.Suite(&ReferenceSuite{})
+
+func (s *ReferenceSuite) TestParsePlanReference(c *gc.C) {
+	url := wireformat.PlanURL{Owner: "canonical", Name: "default"}
+	tests := []struct {
+		about     string
+		reference string
+		result    wireformat.PlanReference
+		str       string
+		err       string
+	}{{
+		about:     "plan url",
+		reference: "canonical/default",
+		result:    wireformat.PlanReference{PlanID: wireformat.PlanID{PlanURL: url}},
+	}, {
+		about:     "plan id",
+		reference: "canonical/default/3",
+		result:    wireformat.PlanReference{PlanID: url.Revision(3)},
+	}, {
+		about:     "plan scheme",
+		reference: "plan:canonical/default/3",
+		result:    wireformat.PlanReference{PlanID: url.Revision(3)},
+		str:       "canonical/default/3",
+	}, {
+		about:     "service url",
+		reference: "https://plans.staging.example/canonical/default/3",
+		result:    wireformat.PlanReference{ServiceURL: "https://plans.staging.example", PlanID: url.Revision(3)},
+	}, {
+		about:     "service url with a path",
+		reference: "http://localhost:8080/v2/plan/canonical/default",
+		result:    wireformat.PlanReference{ServiceURL: "http://localhost:8080/v2/plan", PlanID: wireformat.PlanID{PlanURL: url}},
+	}, {
+		about:     "owner with a domain",
+		reference: "https://plans.staging.example/bob@external/default/3",
+		result: wireformat.PlanReference{
+			ServiceURL: "https://plans.staging.example",
+			PlanID:     wireformat.PlanURL{Owner: "bob@external", Name: "default"}.Revision(3),
+		},
+	}, {
+		about:     "trailing slash",
+		reference: "https://plans.staging.example/canonical/default/",
+		result:    wireformat.PlanReference{ServiceURL: "https://plans.staging.example", PlanID: wireformat.PlanID{PlanURL: url}},
+		str:       "https://plans.staging.example/canonical/default",
+	}, {
+		about:     "no host",
+		reference: "https:///canonical/default",
+		err:       `plan reference "https:///canonical/default" not valid`,
+	}, {
+		about:     "query",
+		reference: "https://plans.staging.example/canonical/default?revision=3",
+		err:       `plan reference ".*" not valid`,
+	}, {
+		about:     "no plan",
+		reference: "https://plans.staging.example/default",
+		err:       `plan reference "https://plans.staging.example/default" not valid`,
+	}, {
+		about:     "invalid plan",
+		reference: "plan:canonical",
+		err:       `plan id "canonical" not valid`,
+	}}
+	for i, t := range tests {
+		c.Logf("test %d: %s", i, t.about)
+		ref, err := wireformat.ParsePlanReference(t.reference)
+		if t.err != "" {
+			c.Check(err, gc.ErrorMatches, t.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(*ref, jc.DeepEquals, t.result)
+		str := t.str
+		if str == "" {
+			str = t.reference
+		}
+		c.Check(ref.String(), gc.Equals, str)
+	}
+}
+
+func (s *ReferenceSuite) TestSplitPlanReferenceSelector(c *gc.C) {
+	tests := []struct {
+		reference string
+		service   string
+		plan      string
+	}{{
+		reference: "https://plans.staging.example/canonical/default/latest",
+		service:   "https://plans.staging.example",
+		plan:      "canonical/default/latest",
+	}, {
+		reference: "https://plans.staging.example/canonical/default/2..5",
+		service:   "https://plans.staging.example",
+		plan:      "canonical/default/2..5",
+	}, {
+		reference: "https://plans.staging.example/canonical/default/-1",
+		service:   "https://plans.staging.example",
+		plan:      "canonical/default/-1",
+	}, {
+		reference: "https://plans.staging.example/canonical/default@2017-03-01",
+		service:   "https://plans.staging.example",
+		plan:      "canonical/default@2017-03-01",
+	}, {
+		reference: "plan:canonical/default/released",
+		plan:      "canonical/default/released",
+	}, {
+		reference: "canonical/default/released",
+		plan:      "canonical/default/released",
+	}}
+	for i, t := range tests {
+		c.Logf("test %d: %s", i, t.reference)
+		service, plan, err := wireformat.SplitPlanReference(t.reference)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(service, gc.Equals, t.service)
+		c.Check(plan, gc.Equals, t.plan)
+	}
+}
@@ -0,0 +1,32 @@
+// Code generated by go generate; DO NOT EDIT.
+
+package schema
+
+// descriptions holds the doc comments of the wire format types and of
+// their fields.
+var descriptions = map[string]string{
+	"Authorization":                "Authorization defines the struct containing information on an issued request for a plan authorization macaroon.",
+	"AuthorizationQuery":           "AuthorizationQuery defines the struct used to query authorization records.",
+	"AuthorizationRequest":         "AuthorizationRequest defines the struct used to request a plan authorization.",
+	"Event":                        "Event defines the wireformat for a backend.event",
+	"Event.Time":                   "timestamp",
+	"Event.Type":                   "type of the event",
+	"Event.User":                   "user who triggered the event",
+	"MetricModel":                  "MetricModel defines how a metric is rated.",
+	"MetricUnit":                   "MetricUnit defines how metric values are aggregated.",
+	"ModelDescription":             "ModelDescription describes the plan to its users.",
+	"Plan":                         "Plan structure is used as a wire format to store information on ISV-created rating plan and charm URLs for which the plan is valid.",
+	"Plan.CreatedOn":               "When the plan was created - RFC3339 encoded timestamp",
+	"Plan.Definition":              "The rating plan source",
+	"Plan.Id":                      "Full id of the plan format",
+	"Plan.Model":                   "The rating plan model",
+	"Plan.ReleaseNotes":            "Notes on the changes made in the revision",
+	"Plan.URL":                     "Name of the rating plan",
+	"PlanDetails":                  "PlanDetails defines the wireformat for a plan with details abouts historical lifecycle.",
+	"RatingModel":                  "RatingModel is the rating model of a plan, as compiled by the plans service from the plan definition.",
+	"ResellerAuthorization":        "ResellerAuthorization defines the struct containing information on an issued reseller plan authorization.",
+	"ResellerAuthorizationQuery":   "ResellerAuthorizationQuery defines the struct used to query reseller authorization records.",
+	"ResellerAuthorizationRequest": "ResellerAuthorizationRequest defines the struct resellers use to obtain authorization credentials.",
+	"ResellerAuthorizationRequest.ApplicationOwner": "The reseller of the application.",
+	"ResellerAuthorizationRequest.ApplicationUser":  "User consuming resources provided by the application.",
+}
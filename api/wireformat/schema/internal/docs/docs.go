@@ -0,0 +1,119 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+// Package docs extracts the doc comments of the exported types of a Go
+// package, and of their fields, to describe them in the JSON schema.
+package docs
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// Parse returns the doc comments of the named types of the package in
+// dir, keyed by type name, and of their exported fields, keyed by
+// <type>.<field>. A field is described by its doc comment or, failing
+// that, by its line comment. TODO notes are not included.
+func Parse(dir string, types []string) (map[string]string, error) {
+	include := make(map[string]bool)
+	for _, t := range types {
+		include[t] = true
+	}
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to parse %v", dir)
+	}
+	if len(pkgs) != 1 {
+		return nil, errors.Errorf("expected one package in %v, found %d", dir, len(pkgs))
+	}
+	descriptions := make(map[string]string)
+	for _, pkg := range pkgs {
+		for _, t := range doc.New(pkg, "", 0).Types {
+			if !include[t.Name] {
+				continue
+			}
+			if d := clean(t.Doc); d != "" {
+				descriptions[t.Name] = d
+			}
+			for _, spec := range t.Decl.Specs {
+				ts, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				for _, field := range st.Fields.List {
+					d := clean(field.Doc.Text())
+					if d == "" {
+						d = clean(field.Comment.Text())
+					}
+					if d == "" {
+						continue
+					}
+					for _, name := range field.Names {
+						if name.IsExported() {
+							descriptions[ts.Name.Name+"."+name.Name] = d
+						}
+					}
+				}
+			}
+		}
+	}
+	return descriptions, nil
+}
+
+// clean joins the lines of a comment into a single line, dropping TODO
+// notes.
+func clean(comment string) string {
+	var words []string
+	for _, line := range strings.Split(comment, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "TODO") {
+			continue
+		}
+		words = append(words, strings.Fields(line)...)
+	}
+	return strings.Join(words, " ")
+}
+
+// WriteGo writes Go source declaring the descriptions as the value of a
+// variable named descriptions in the named package.
+func WriteGo(w io.Writer, pkg string, descriptions map[string]string) error {
+	keys := make([]string, 0, len(descriptions))
+	for k := range descriptions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by go generate; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	fmt.Fprintf(&buf, "// descriptions holds the doc comments of the wire format types and of\n")
+	fmt.Fprintf(&buf, "// their fields.\n")
+	fmt.Fprintf(&buf, "var descriptions = map[string]string{\n")
+	for _, k := range keys {
+		fmt.Fprintf(&buf, "\t%s: %s,\n", strconv.Quote(k), strconv.Quote(descriptions[k]))
+	}
+	fmt.Fprintf(&buf, "}\n")
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	_, err = w.Write(src)
+	return errors.Trace(err)
+}
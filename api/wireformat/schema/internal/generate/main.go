@@ -0,0 +1,66 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+// The generate command generates the descriptions of the wire format
+// types from their doc comments, and the JSON schema file. It is run by
+// go generate in the schema package:
+//
+//	generate descriptions <file>
+//	generate schema <file>
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/plans-client/api/wireformat/schema"
+	"github.com/juju/plans-client/api/wireformat/schema/internal/docs"
+)
+
+func main() {
+	if len(os.Args) != 3 {
+		fmt.Fprintln(os.Stderr, "usage: generate descriptions|schema <file>")
+		os.Exit(2)
+	}
+	if err := generate(os.Args[1], os.Args[2]); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func generate(what, file string) error {
+	var buf bytes.Buffer
+	switch what {
+	case "descriptions":
+		types, err := schema.TypeNames()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		descriptions, err := docs.Parse("..", types)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := docs.WriteGo(&buf, "schema", descriptions); err != nil {
+			return errors.Trace(err)
+		}
+	case "schema":
+		s, err := schema.Generate()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		data, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return errors.Trace(err)
+		}
+		buf.Write(data)
+		buf.WriteString("\n")
+	default:
+		return errors.Errorf("unknown generator %q", what)
+	}
+	return errors.Trace(ioutil.WriteFile(file, buf.Bytes(), 0644))
+}
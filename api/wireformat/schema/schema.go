@@ -0,0 +1,328 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+// Package schema generates the JSON schema of the documents exchanged
+// with the plans service, and of plan definitions, from the wireformat
+// types. The descriptions of the types and of their fields are taken from
+// their doc comments.
+//
+// The schema describes the documents as encoded by the wireformat package:
+// required fields are always present, other fields may be omitted.
+package schema
+
+//go:generate go run ./internal/generate descriptions descriptions.go
+//go:generate go run ./internal/generate schema plans.schema.json
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/plans-client/api/wireformat"
+)
+
+// Version is the version of the schema. It must be increased whenever the
+// wire format changes.
+const Version = 2
+
+// MetaSchema is the JSON schema dialect the schema is written in.
+const MetaSchema = "http://json-schema.org/draft-07/schema#"
+
+// PlanDefinition is the name of the definition of the plan definitions
+// pushed with push-plan, as YAML documents.
+const PlanDefinition = "PlanDefinition"
+
+// Schema is a JSON schema.
+type Schema struct {
+	Schema      string             `json:"$schema,omitempty"`
+	Ref         string             `json:"$ref,omitempty"`
+	Title       string             `json:"title,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Version     int                `json:"version,omitempty"`
+	Type        Types              `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Encoding    string             `json:"contentEncoding,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	AllOf       []*Schema          `json:"allOf,omitempty"`
+	Additional  *Schema            `json:"additionalProperties,omitempty"`
+	Definitions map[string]*Schema `json:"definitions,omitempty"`
+}
+
+// Types holds the JSON types allowed by a schema.
+type Types []string
+
+// MarshalJSON implements json.Marshaler, encoding a single type as a
+// string.
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = Types{s}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// definitions holds the types described by the schema, by definition
+// name. The types they refer to are added to the definitions as well.
+var definitions = map[string]reflect.Type{
+	"Plan":                         reflect.TypeOf(wireformat.Plan{}),
+	"PlanDetails":                  reflect.TypeOf(wireformat.PlanDetails{}),
+	"Authorization":                reflect.TypeOf(wireformat.Authorization{}),
+	"AuthorizationRequest":         reflect.TypeOf(wireformat.AuthorizationRequest{}),
+	"AuthorizationQuery":           reflect.TypeOf(wireformat.AuthorizationQuery{}),
+	"ResellerAuthorization":        reflect.TypeOf(wireformat.ResellerAuthorization{}),
+	"ResellerAuthorizationRequest": reflect.TypeOf(wireformat.ResellerAuthorizationRequest{}),
+	"ResellerAuthorizationQuery":   reflect.TypeOf(wireformat.ResellerAuthorizationQuery{}),
+	PlanDefinition:                 reflect.TypeOf(wireformat.RatingModel{}),
+}
+
+// planDefinitionDescription describes the plan definition, which shares
+// the structure of the rating model compiled from it.
+const planDefinitionDescription = "A plan definition, as pushed to the plans service in YAML format."
+
+// Renamed fields of the types supporting wire dialects: the names used
+// before Juju 2.0 and the Juju 2.0 names.
+var renamedFields = map[string]string{
+	"env-uuid":     "model-uuid",
+	"service-name": "application",
+}
+
+var (
+	timeType             = reflect.TypeOf(time.Time{})
+	bytesType            = reflect.TypeOf([]byte(nil))
+	dialectMarshalerType = reflect.TypeOf((*interface {
+		MarshalJSONDialect(wireformat.Dialect) ([]byte, error)
+	})(nil)).Elem()
+)
+
+// Names returns the names of the top level definitions of the schema.
+func Names() []string {
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Generate returns the JSON schema of all the top level definitions.
+func Generate() (*Schema, error) {
+	g := generator{definitions: make(map[string]*Schema)}
+	for _, name := range Names() {
+		if err := g.define(name, definitions[name]); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return &Schema{
+		Schema:      MetaSchema,
+		Title:       "Plans service wire format",
+		Version:     Version,
+		Definitions: g.definitions,
+	}, nil
+}
+
+// TypeNames returns the names of the wireformat types described by the
+// schema.
+func TypeNames() ([]string, error) {
+	g := generator{definitions: make(map[string]*Schema)}
+	for _, name := range Names() {
+		if err := g.define(name, definitions[name]); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	names := make([]string, 0, len(g.types))
+	for name := range g.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// GenerateDefinition returns the JSON schema of the named top level
+// definition, including the definitions it refers to.
+func GenerateDefinition(name string) (*Schema, error) {
+	t, ok := definitions[name]
+	if !ok {
+		return nil, errors.NotFoundf("schema definition %q", name)
+	}
+	g := generator{definitions: make(map[string]*Schema)}
+	if err := g.define(name, t); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &Schema{
+		Schema:      MetaSchema,
+		Ref:         "#/definitions/" + name,
+		Version:     Version,
+		Definitions: g.definitions,
+	}, nil
+}
+
+type generator struct {
+	definitions map[string]*Schema
+	types       map[string]bool
+}
+
+// define adds the definition of the struct type under the given name.
+func (g *generator) define(name string, t reflect.Type) error {
+	if _, ok := g.definitions[name]; ok {
+		return nil
+	}
+	s := &Schema{
+		Title:       name,
+		Description: descriptions[t.Name()],
+		Type:        Types{"object"},
+		Properties:  make(map[string]*Schema),
+	}
+	if name == PlanDefinition {
+		s.Description = planDefinitionDescription
+	}
+	// Register the definition before its fields, for recursive types.
+	g.definitions[name] = s
+	if g.types == nil {
+		g.types = make(map[string]bool)
+	}
+	g.types[t.Name()] = true
+	if err := g.addFields(s, t); err != nil {
+		return errors.Annotatef(err, "cannot generate the schema of %v", name)
+	}
+	if reflect.PtrTo(t).Implements(dialectMarshalerType) {
+		required := s.Required[:0]
+		for _, r := range s.Required {
+			if _, ok := renamedFields[r]; !ok {
+				required = append(required, r)
+			}
+		}
+		s.Required = required
+		for legacy, juju2 := range renamedFields {
+			if p, ok := s.Properties[legacy]; ok {
+				renamed := *p
+				renamed.Description = "The Juju 2.0 name of " + legacy + "."
+				s.Properties[juju2] = &renamed
+			}
+		}
+	}
+	sort.Strings(s.Required)
+	return nil
+}
+
+// addFields adds the properties of the fields of the struct type to the
+// schema.
+func (g *generator) addFields(s *Schema, t reflect.Type) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name, opts := parseTag(f.Tag.Get("json"))
+		if name == "-" && opts == "" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := g.addFields(s, ft); err != nil {
+					return errors.Trace(err)
+				}
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+		p, err := g.schemaOf(f.Type)
+		if err != nil {
+			return errors.Annotatef(err, "field %v", f.Name)
+		}
+		if d := descriptions[t.Name()+"."+f.Name]; d != "" {
+			if p.Ref != "" {
+				// Keywords alongside $ref are ignored.
+				p = &Schema{Description: d, AllOf: []*Schema{p}}
+			} else {
+				p.Description = d
+			}
+		}
+		omitEmpty := strings.Contains(","+opts+",", ",omitempty,")
+		switch f.Type.Kind() {
+		case reflect.Slice, reflect.Map, reflect.Interface:
+			if !omitEmpty && f.Type.Kind() != reflect.Interface {
+				// Nil slices and maps are encoded as null.
+				p.Type = append(p.Type, "null")
+			}
+		}
+		if !omitEmpty && f.Type.Kind() != reflect.Ptr {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = p
+	}
+	return nil
+}
+
+// schemaOf returns the schema of values of the type.
+func (g *generator) schemaOf(t reflect.Type) (*Schema, error) {
+	switch {
+	case t == timeType:
+		return &Schema{Type: Types{"string"}, Format: "date-time"}, nil
+	case t == bytesType:
+		return &Schema{Type: Types{"string"}, Encoding: "base64"}, nil
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schemaOf(t.Elem())
+	case reflect.String:
+		return &Schema{Type: Types{"string"}}, nil
+	case reflect.Bool:
+		return &Schema{Type: Types{"boolean"}}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: Types{"integer"}}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: Types{"number"}}, nil
+	case reflect.Interface:
+		return &Schema{}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := g.schemaOf(t.Elem())
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return &Schema{Type: Types{"array"}, Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, errors.NotSupportedf("map key type %v", t.Key())
+		}
+		values, err := g.schemaOf(t.Elem())
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return &Schema{Type: Types{"object"}, Additional: values}, nil
+	case reflect.Struct:
+		if err := g.define(t.Name(), t); err != nil {
+			return nil, errors.Trace(err)
+		}
+		return &Schema{Ref: "#/definitions/" + t.Name()}, nil
+	}
+	return nil, errors.NotSupportedf("type %v", t)
+}
+
+func parseTag(tag string) (name, opts string) {
+	if i := strings.Index(tag, ","); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+	return tag, ""
+}
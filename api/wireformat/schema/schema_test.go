@@ -0,0 +1,152 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package schema_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	stdtesting "testing"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/plans-client/api/wireformat"
+	"github.com/juju/plans-client/api/wireformat/schema"
+	"github.com/juju/plans-client/api/wireformat/schema/internal/docs"
+)
+
+func Test(t *stdtesting.T) { gc.TestingT(t) }
+
+type schemaSuite struct{}
+
+var _ = gc.Suite(&schemaSuite{})
+
+// digests holds the digest of each version of the schema, descriptions
+// excluded. When the wire format changes, increase schema.Version and
+// record the digest of the new version here.
+var digests = map[int]string{
+	1: "34b07b0cbffc614f465a3e58f6582ba73f9f5a6784758bbb3adef7990404268d",
+	2: "4654774ce8f4860fdd5ddd85b4e06f6aac9f34cadcaaef51bab226621946a9f5",
+}
+
+func (s *schemaSuite) TestDescriptionsUpToDate(c *gc.C) {
+	types, err := schema.TypeNames()
+	c.Assert(err, jc.ErrorIsNil)
+	descriptions, err := docs.Parse("..", types)
+	c.Assert(err, jc.ErrorIsNil)
+	var buf bytes.Buffer
+	err = docs.WriteGo(&buf, "schema", descriptions)
+	c.Assert(err, jc.ErrorIsNil)
+	data, err := ioutil.ReadFile("descriptions.go")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, buf.String(), gc.Commentf("descriptions.go is out of date: run go generate"))
+}
+
+func (s *schemaSuite) TestSchemaUpToDate(c *gc.C) {
+	sch, err := schema.Generate()
+	c.Assert(err, jc.ErrorIsNil)
+	generated, err := json.MarshalIndent(sch, "", "  ")
+	c.Assert(err, jc.ErrorIsNil)
+	data, err := ioutil.ReadFile("plans.schema.json")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, string(generated)+"\n", gc.Commentf("plans.schema.json is out of date: run go generate"))
+}
+
+func (s *schemaSuite) TestVersion(c *gc.C) {
+	sch, err := schema.Generate()
+	c.Assert(err, jc.ErrorIsNil)
+	digest := schemaDigest(c, sch)
+	expected, ok := digests[schema.Version]
+	c.Assert(ok, jc.IsTrue, gc.Commentf("no digest recorded for schema version %d: record %q", schema.Version, digest))
+	c.Assert(digest, gc.Equals, expected, gc.Commentf("the wire format changed: increase schema.Version and record the digest of the new version"))
+}
+
+// schemaDigest returns the digest of the schema, ignoring descriptions,
+// so that documentation changes do not require a new version.
+func schemaDigest(c *gc.C, sch *schema.Schema) string {
+	stripDescriptions(sch)
+	data, err := json.Marshal(sch)
+	c.Assert(err, jc.ErrorIsNil)
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+func stripDescriptions(s *schema.Schema) {
+	if s == nil {
+		return
+	}
+	s.Description = ""
+	for _, p := range s.Properties {
+		stripDescriptions(p)
+	}
+	for _, d := range s.Definitions {
+		stripDescriptions(d)
+	}
+	for _, a := range s.AllOf {
+		stripDescriptions(a)
+	}
+	stripDescriptions(s.Items)
+	stripDescriptions(s.Additional)
+}
+
+func (s *schemaSuite) TestGenerateDefinition(c *gc.C) {
+	sch, err := schema.GenerateDefinition("PlanDetails")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(sch.Ref, gc.Equals, "#/definitions/PlanDetails")
+	c.Assert(sch.Version, gc.Equals, schema.Version)
+	names := make(map[string]bool)
+	for name := range sch.Definitions {
+		names[name] = true
+	}
+	c.Assert(names, jc.DeepEquals, map[string]bool{
+		"PlanDetails":     true,
+		"Plan":            true,
+		"Event":           true,
+		"CharmPlanDetail": true,
+	})
+
+	_, err = schema.GenerateDefinition("Budget")
+	c.Assert(err, gc.ErrorMatches, `schema definition "Budget" not found`)
+	c.Assert(errors.IsNotFound(err), jc.IsTrue)
+}
+
+func (s *schemaSuite) TestEncodedDocuments(c *gc.C) {
+	// The documents encoded by the wireformat package only use the
+	// properties of the schema and include all the required ones.
+	sch, err := schema.Generate()
+	c.Assert(err, jc.ErrorIsNil)
+	documents := map[string]interface{}{
+		"Plan":                         wireformat.Plan{},
+		"PlanDetails":                  wireformat.PlanDetails{},
+		"Authorization":                wireformat.Authorization{},
+		"AuthorizationRequest":         wireformat.AuthorizationRequest{},
+		"AuthorizationQuery":           wireformat.AuthorizationQuery{},
+		"ResellerAuthorization":        wireformat.ResellerAuthorization{},
+		"ResellerAuthorizationRequest": wireformat.ResellerAuthorizationRequest{},
+		"ResellerAuthorizationQuery":   wireformat.ResellerAuthorizationQuery{},
+		schema.PlanDefinition:          wireformat.RatingModel{},
+	}
+	c.Assert(len(documents), gc.Equals, len(schema.Names()))
+	for name, v := range documents {
+		c.Logf("definition %s", name)
+		def, ok := sch.Definitions[name]
+		c.Assert(ok, jc.IsTrue)
+		data, err := json.Marshal(v)
+		c.Assert(err, jc.ErrorIsNil)
+		var fields map[string]interface{}
+		err = json.Unmarshal(data, &fields)
+		c.Assert(err, jc.ErrorIsNil)
+		for field := range fields {
+			_, ok := def.Properties[field]
+			c.Check(ok, jc.IsTrue, gc.Commentf("property %q", field))
+		}
+		for _, field := range def.Required {
+			_, ok := fields[field]
+			c.Check(ok, jc.IsTrue, gc.Commentf("required property %q", field))
+		}
+	}
+}
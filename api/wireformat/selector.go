@@ -0,0 +1,214 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package wireformat
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// SelectorKind is the kind of revisions selected by a PlanSelector.
+type SelectorKind int
+
+const (
+	// SelectPlan selects the plan as a whole: owner/name.
+	SelectPlan SelectorKind = iota
+	// SelectRevision selects a revision: owner/name/N.
+	SelectRevision
+	// SelectLatest selects the latest revision: owner/name/latest.
+	SelectLatest
+	// SelectReleased selects the latest released revision:
+	// owner/name/released.
+	SelectReleased
+	// SelectRange selects the revisions in an inclusive range:
+	// owner/name/N..M.
+	SelectRange
+	// SelectPrevious selects a revision relative to the latest one:
+	// owner/name/-N.
+	SelectPrevious
+	// SelectEffectiveAt selects the revision effective at a time:
+	// owner/name@YYYY-MM-DD or owner/name@<RFC3339 time>.
+	SelectEffectiveAt
+)
+
+// PlanSelector selects revisions of a plan.
+type PlanSelector struct {
+	PlanURL PlanURL
+	Kind    SelectorKind
+	// Revision is the selected revision, the first revision of a
+	// range, or the number of revisions before the latest one.
+	Revision int
+	// To is the last revision of a range.
+	To int
+	// Time is the time at which the selected revision is effective.
+	Time time.Time
+}
+
+// ParsePlanSelector parses a plan url, a plan id or one of the revision
+// selectors:
+//
+//	owner/name/latest      the latest revision
+//	owner/name/released    the latest released revision
+//	owner/name/N..M        revisions N to M
+//	owner/name/-N          the Nth revision before the latest
+//	owner/name@T           the revision effective at time T, a
+//	                       YYYY-MM-DD date or an RFC3339 time
+func ParsePlanSelector(s string) (*PlanSelector, error) {
+	// User names may contain a domain, so only an @ after the last
+	// slash introduces a time.
+	if i := strings.LastIndex(s, "@"); i > strings.LastIndex(s, "/") {
+		url, err := ParsePlanURL(s[:i])
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		t, err := ParseTimestamp(s[i+1:])
+		if err != nil {
+			return nil, errors.Annotatef(err, "invalid plan selector %q", s)
+		}
+		return &PlanSelector{PlanURL: *url, Kind: SelectEffectiveAt, Time: t}, nil
+	}
+	parts := strings.Split(s, "/")
+	if len(parts) == 2 {
+		url, err := ParsePlanURL(s)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return &PlanSelector{PlanURL: *url, Kind: SelectPlan}, nil
+	}
+	if len(parts) != 3 {
+		return nil, errors.NotValidf("plan selector %q", s)
+	}
+	url, err := ParsePlanURL(parts[0] + "/" + parts[1])
+	if err != nil {
+		return nil, errors.NotValidf("plan selector %q", s)
+	}
+	sel := PlanSelector{PlanURL: *url}
+	rev := parts[2]
+	switch {
+	case rev == "latest":
+		sel.Kind = SelectLatest
+	case rev == "released":
+		sel.Kind = SelectReleased
+	case strings.HasPrefix(rev, "-"):
+		sel.Kind = SelectPrevious
+		sel.Revision, err = strconv.Atoi(rev[1:])
+		if err != nil || sel.Revision <= 0 {
+			return nil, errors.NotValidf("plan selector %q", s)
+		}
+	case strings.Contains(rev, ".."):
+		sel.Kind = SelectRange
+		bounds := strings.SplitN(rev, "..", 2)
+		from, err1 := strconv.Atoi(bounds[0])
+		to, err2 := strconv.Atoi(bounds[1])
+		if err1 != nil || err2 != nil || from <= 0 {
+			return nil, errors.NotValidf("plan selector %q", s)
+		}
+		if to < from {
+			return nil, errors.Errorf("invalid plan selector %q: revision range is empty", s)
+		}
+		sel.Revision, sel.To = from, to
+	default:
+		id, err := ParsePlanID(s)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		sel.Kind = SelectRevision
+		sel.Revision = id.Revision
+	}
+	return &sel, nil
+}
+
+// String returns the selector in the format parsed by ParsePlanSelector.
+func (s PlanSelector) String() string {
+	switch s.Kind {
+	case SelectRevision:
+		return s.PlanURL.Revision(s.Revision).String()
+	case SelectLatest:
+		return s.PlanURL.String() + "/latest"
+	case SelectReleased:
+		return s.PlanURL.String() + "/released"
+	case SelectRange:
+		return fmt.Sprintf("%v/%d..%d", s.PlanURL, s.Revision, s.To)
+	case SelectPrevious:
+		return fmt.Sprintf("%v/-%d", s.PlanURL, s.Revision)
+	case SelectEffectiveAt:
+		return fmt.Sprintf("%v@%s", s.PlanURL, s.Time.Format(time.RFC3339))
+	}
+	return s.PlanURL.String()
+}
+
+// Concrete returns true if the selector names the plan or a revision of
+// the plan, and so does not need to be resolved against the revisions of
+// the plan.
+func (s PlanSelector) Concrete() bool {
+	return s.Kind == SelectPlan || s.Kind == SelectRevision
+}
+
+// Select returns the revisions of the plan matched by the selector, in
+// ascending revision order. SelectPlan matches all revisions. Revisions
+// effective at a time cannot be determined from the revisions alone:
+// use EffectiveRevision with the details of the revisions instead.
+func (s PlanSelector) Select(revisions []Plan) ([]Plan, error) {
+	if s.Kind == SelectEffectiveAt {
+		return nil, errors.NotSupportedf("selecting the revision effective at a time from plan revisions")
+	}
+	type revision struct {
+		plan Plan
+		rev  int
+	}
+	sorted := make([]revision, len(revisions))
+	for i, p := range revisions {
+		id, err := ParsePlanID(p.Id)
+		if err != nil {
+			return nil, errors.Annotatef(err, "failed to parse plan id %q", p.Id)
+		}
+		sorted[i] = revision{plan: p, rev: id.Revision}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].rev < sorted[j].rev
+	})
+	var selected []Plan
+	switch s.Kind {
+	case SelectLatest:
+		if len(sorted) > 0 {
+			selected = append(selected, sorted[len(sorted)-1].plan)
+		}
+	case SelectReleased:
+		for i := len(sorted) - 1; i >= 0; i-- {
+			if sorted[i].plan.Released {
+				selected = append(selected, sorted[i].plan)
+				break
+			}
+		}
+	case SelectPrevious:
+		if i := len(sorted) - 1 - s.Revision; i >= 0 {
+			selected = append(selected, sorted[i].plan)
+		}
+	default:
+		for _, r := range sorted {
+			if s.matchesRevision(r.rev) {
+				selected = append(selected, r.plan)
+			}
+		}
+	}
+	if len(selected) == 0 {
+		return nil, errors.NotFoundf("revisions of plan %v matching %q", s.PlanURL, s.String())
+	}
+	return selected, nil
+}
+
+func (s PlanSelector) matchesRevision(rev int) bool {
+	switch s.Kind {
+	case SelectRevision:
+		return rev == s.Revision
+	case SelectRange:
+		return rev >= s.Revision && rev <= s.To
+	}
+	return true
+}
@@ -0,0 +1,204 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package wireformat_test
+
+import (
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/plans-client/api/wireformat"
+)
+
+type SelectorSuite struct{}
+
+var _ = gc.Suite(&SelectorSuite{})
+
+func (s *SelectorSuite) TestParsePlanSelector(c *gc.C) {
+	url := wireformat.PlanURL{Owner: "owner", Name: "name"}
+	tests := []struct {
+		about    string
+		selector string
+		result   wireformat.PlanSelector
+		err      string
+	}{{
+		about:    "plan url",
+		selector: "owner/name",
+		result:   wireformat.PlanSelector{PlanURL: url, Kind: wireformat.SelectPlan},
+	}, {
+		about:    "plan id",
+		selector: "owner/name/4",
+		result:   wireformat.PlanSelector{PlanURL: url, Kind: wireformat.SelectRevision, Revision: 4},
+	}, {
+		about:    "latest",
+		selector: "owner/name/latest",
+		result:   wireformat.PlanSelector{PlanURL: url, Kind: wireformat.SelectLatest},
+	}, {
+		about:    "released",
+		selector: "owner/name/released",
+		result:   wireformat.PlanSelector{PlanURL: url, Kind: wireformat.SelectReleased},
+	}, {
+		about:    "range",
+		selector: "owner/name/3..7",
+		result:   wireformat.PlanSelector{PlanURL: url, Kind: wireformat.SelectRange, Revision: 3, To: 7},
+	}, {
+		about:    "previous",
+		selector: "owner/name/-1",
+		result:   wireformat.PlanSelector{PlanURL: url, Kind: wireformat.SelectPrevious, Revision: 1},
+	}, {
+		about:    "effective at a date",
+		selector: "owner/name@2026-01-01",
+		result:   wireformat.PlanSelector{PlanURL: url, Kind: wireformat.SelectEffectiveAt, Time: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}, {
+		about:    "effective at a time",
+		selector: "owner/name@2026-01-01T12:00:00+02:00",
+		result:   wireformat.PlanSelector{PlanURL: url, Kind: wireformat.SelectEffectiveAt, Time: time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)},
+	}, {
+		about:    "owner with a domain",
+		selector: "bob@external/name/latest",
+		result:   wireformat.PlanSelector{PlanURL: wireformat.PlanURL{Owner: "bob@external", Name: "name"}, Kind: wireformat.SelectLatest},
+	}, {
+		about:    "invalid time",
+		selector: "owner/name@yesterday",
+		err:      `invalid plan selector "owner/name@yesterday": timestamp "yesterday" not valid`,
+	}, {
+		about:    "revision and time",
+		selector: "owner/name/3@2026-01-01",
+		err:      `plan url "owner/name/3" not valid`,
+	}, {
+		about:    "empty range",
+		selector: "owner/name/7..3",
+		err:      `invalid plan selector "owner/name/7..3": revision range is empty`,
+	}, {
+		about:    "open range",
+		selector: "owner/name/3..",
+		err:      `plan selector "owner/name/3.." not valid`,
+	}, {
+		about:    "range from zero",
+		selector: "owner/name/0..3",
+		err:      `plan selector "owner/name/0..3" not valid`,
+	}, {
+		about:    "previous zero",
+		selector: "owner/name/-0",
+		err:      `plan selector "owner/name/-0" not valid`,
+	}, {
+		about:    "unknown alias",
+		selector: "owner/name/oldest",
+		err:      `invalid revision format: .*`,
+	}, {
+		about:    "invalid plan name",
+		selector: "owner/Name/latest",
+		err:      `plan selector "owner/Name/latest" not valid`,
+	}, {
+		about:    "extra fields",
+		selector: "owner/name/1/2",
+		err:      `plan selector "owner/name/1/2" not valid`,
+	}}
+	for i, t := range tests {
+		c.Logf("test %d: %s", i, t.about)
+		sel, err := wireformat.ParsePlanSelector(t.selector)
+		if t.err != "" {
+			c.Check(err, gc.ErrorMatches, t.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(*sel, jc.DeepEquals, t.result)
+		c.Check(sel.Concrete(), gc.Equals, t.result.Kind == wireformat.SelectPlan || t.result.Kind == wireformat.SelectRevision)
+		// The string representation of the selector parses back
+		// into the same selector.
+		again, err := wireformat.ParsePlanSelector(sel.String())
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(*again, jc.DeepEquals, *sel)
+	}
+}
+
+func (s *SelectorSuite) TestSelect(c *gc.C) {
+	revisions := []wireformat.Plan{
+		{Id: "owner/name/2", Released: true},
+		{Id: "owner/name/1", Released: true},
+		{Id: "owner/name/4"},
+		{Id: "owner/name/3", Released: true},
+	}
+	tests := []struct {
+		selector string
+		ids      []string
+		err      string
+	}{{
+		selector: "owner/name",
+		ids:      []string{"owner/name/1", "owner/name/2", "owner/name/3", "owner/name/4"},
+	}, {
+		selector: "owner/name/2",
+		ids:      []string{"owner/name/2"},
+	}, {
+		selector: "owner/name/latest",
+		ids:      []string{"owner/name/4"},
+	}, {
+		selector: "owner/name/released",
+		ids:      []string{"owner/name/3"},
+	}, {
+		selector: "owner/name/2..3",
+		ids:      []string{"owner/name/2", "owner/name/3"},
+	}, {
+		selector: "owner/name/3..9",
+		ids:      []string{"owner/name/3", "owner/name/4"},
+	}, {
+		selector: "owner/name/-1",
+		ids:      []string{"owner/name/3"},
+	}, {
+		selector: "owner/name/-3",
+		ids:      []string{"owner/name/1"},
+	}, {
+		selector: "owner/name/-4",
+		err:      `revisions of plan owner/name matching "owner/name/-4" not found`,
+	}, {
+		selector: "owner/name/5..6",
+		err:      `revisions of plan owner/name matching "owner/name/5..6" not found`,
+	}, {
+		selector: "owner/name@2017-01-01",
+		err:      `selecting the revision effective at a time from plan revisions not supported`,
+	}}
+	for i, t := range tests {
+		c.Logf("test %d: %s", i, t.selector)
+		sel, err := wireformat.ParsePlanSelector(t.selector)
+		c.Assert(err, jc.ErrorIsNil)
+		selected, err := sel.Select(revisions)
+		if t.err != "" {
+			c.Check(err, gc.ErrorMatches, t.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		ids := make([]string, len(selected))
+		for j, p := range selected {
+			ids[j] = p.Id
+		}
+		c.Check(ids, jc.DeepEquals, t.ids)
+	}
+
+	sel, err := wireformat.ParsePlanSelector("owner/name/released")
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = sel.Select([]wireformat.Plan{{Id: "owner/name/1"}})
+	c.Assert(err, gc.ErrorMatches, `revisions of plan owner/name matching "owner/name/released" not found`)
+}
+
+func (s *SelectorSuite) TestEffectiveRevision(c *gc.C) {
+	revisions := []wireformat.PlanDetails{{
+		Plan:     wireformat.Plan{Id: "owner/name/1"},
+		Released: &wireformat.Event{Type: "release", Time: date(time.January, 1)},
+	}, {
+		Plan:     wireformat.Plan{Id: "owner/name/2", EffectiveTime: datep(time.March, 1)},
+		Released: &wireformat.Event{Type: "release", Time: date(time.February, 1)},
+	}, {
+		Plan: wireformat.Plan{Id: "owner/name/3"},
+	}}
+	id, since := wireformat.EffectiveRevision(revisions, date(time.February, 15))
+	c.Assert(id, gc.Equals, "owner/name/1")
+	c.Assert(*since, gc.Equals, date(time.January, 1))
+	id, since = wireformat.EffectiveRevision(revisions, date(time.March, 1))
+	c.Assert(id, gc.Equals, "owner/name/2")
+	c.Assert(*since, gc.Equals, date(time.March, 1))
+	id, since = wireformat.EffectiveRevision(revisions, date(time.January, 1).Add(-time.Second))
+	c.Assert(id, gc.Equals, "")
+	c.Assert(since, gc.IsNil)
+}
@@ -0,0 +1,78 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package wireformat
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// timestampLayouts are the layouts of the timestamps accepted by
+// ParseTimestamp, in the order they are tried. Layouts without a time zone
+// are interpreted as UTC.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC1123Z,
+	time.RFC1123,
+	"2006-01-02",
+}
+
+// ParseTimestamp parses a timestamp as encoded by the plans service or
+// given on the command line. It accepts RFC3339 timestamps, YYYY-MM-DD
+// dates (midnight UTC) and the other layouts the service and its clients
+// have used over time, and returns the time in UTC.
+func ParseTimestamp(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, errors.NotValidf("timestamp %q", s)
+}
+
+// CreatedTime returns the time the plan was created.
+func (p Plan) CreatedTime() (time.Time, error) {
+	if p.CreatedOn == "" {
+		return time.Time{}, errors.NotFoundf("creation time of plan %v", p.Id)
+	}
+	t, err := ParseTimestamp(p.CreatedOn)
+	if err != nil {
+		return time.Time{}, errors.Annotatef(err, "invalid creation time of plan %v", p.Id)
+	}
+	return t, nil
+}
+
+// SortPlansByCreation sorts the plans by the time they were created,
+// keeping the order of plans created at the same time. Plans without a
+// valid creation time are sorted last.
+func SortPlansByCreation(plans []Plan) {
+	type created struct {
+		plan  Plan
+		time  time.Time
+		valid bool
+	}
+	sorted := make([]created, len(plans))
+	for i, p := range plans {
+		t, err := p.CreatedTime()
+		sorted[i] = created{plan: p, time: t, valid: err == nil}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].valid != sorted[j].valid {
+			return sorted[i].valid
+		}
+		return sorted[i].time.Before(sorted[j].time)
+	})
+	for i, c := range sorted {
+		plans[i] = c.plan
+	}
+}
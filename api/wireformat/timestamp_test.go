@@ -0,0 +1,98 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package wireformat_test
+
+import (
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/plans-client/api/wireformat"
+)
+
+type TimestampSuite struct{}
+
+var _ = gc.Suite(&TimestampSuite{})
+
+func (s *TimestampSuite) TestParseTimestamp(c *gc.C) {
+	t0 := time.Date(2017, 12, 1, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		timestamp string
+		time      time.Time
+		err       string
+	}{{
+		timestamp: "2017-12-01T10:30:00Z",
+		time:      t0,
+	}, {
+		timestamp: "2017-12-01T12:30:00+02:00",
+		time:      t0,
+	}, {
+		timestamp: "2017-12-01T10:30:00.25Z",
+		time:      t0.Add(250 * time.Millisecond),
+	}, {
+		timestamp: "2017-12-01T12:30:00+0200",
+		time:      t0,
+	}, {
+		timestamp: "2017-12-01T10:30:00",
+		time:      t0,
+	}, {
+		timestamp: "2017-12-01 10:30:00Z",
+		time:      t0,
+	}, {
+		timestamp: "2017-12-01 10:30:00 +0000 UTC",
+		time:      t0,
+	}, {
+		timestamp: "2017-12-01 10:30:00",
+		time:      t0,
+	}, {
+		timestamp: "Fri, 01 Dec 2017 10:30:00 +0000",
+		time:      t0,
+	}, {
+		timestamp: " 2017-12-01 ",
+		time:      time.Date(2017, 12, 1, 0, 0, 0, 0, time.UTC),
+	}, {
+		timestamp: "yesterday",
+		err:       `timestamp "yesterday" not valid`,
+	}}
+	for i, t := range tests {
+		c.Logf("test %d: %s", i, t.timestamp)
+		parsed, err := wireformat.ParseTimestamp(t.timestamp)
+		if t.err != "" {
+			c.Check(err, gc.ErrorMatches, t.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(parsed, gc.Equals, t.time)
+	}
+}
+
+func (s *TimestampSuite) TestCreatedTime(c *gc.C) {
+	t, err := wireformat.Plan{Id: "owner/name/1", CreatedOn: "2017-12-01T10:30:00Z"}.CreatedTime()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(t, gc.Equals, time.Date(2017, 12, 1, 10, 30, 0, 0, time.UTC))
+
+	_, err = wireformat.Plan{Id: "owner/name/1"}.CreatedTime()
+	c.Assert(err, gc.ErrorMatches, `creation time of plan owner/name/1 not found`)
+
+	_, err = wireformat.Plan{Id: "owner/name/1", CreatedOn: "soon"}.CreatedTime()
+	c.Assert(err, gc.ErrorMatches, `invalid creation time of plan owner/name/1: timestamp "soon" not valid`)
+}
+
+func (s *TimestampSuite) TestSortPlansByCreation(c *gc.C) {
+	plans := []wireformat.Plan{
+		{Id: "owner/name/1", CreatedOn: "2017-12-01T10:30:00+02:00"},
+		{Id: "owner/name/2"},
+		// Sorting by the string would put this plan first.
+		{Id: "owner/name/3", CreatedOn: "2017-12-01 09:00:00"},
+		{Id: "owner/name/4", CreatedOn: "2017-11-30"},
+		{Id: "owner/name/5", CreatedOn: "2017-12-01T09:00:00Z"},
+	}
+	wireformat.SortPlansByCreation(plans)
+	ids := make([]string, len(plans))
+	for i, p := range plans {
+		ids[i] = p.Id
+	}
+	c.Assert(ids, jc.DeepEquals, []string{"owner/name/4", "owner/name/1", "owner/name/3", "owner/name/5", "owner/name/2"})
+}
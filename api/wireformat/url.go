@@ -4,6 +4,8 @@
 package wireformat
 
 import (
+	"encoding"
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -151,3 +153,174 @@ func (p PlanID) Validate() error {
 	}
 	return nil
 }
+
+// MarshalText implements encoding.TextMarshaler. The zero PlanURL is
+// marshaled as an empty string.
+func (p PlanURL) MarshalText() ([]byte, error) {
+	if p == (PlanURL{}) {
+		return []byte{}, nil
+	}
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. An empty string is
+// unmarshaled as the zero PlanURL.
+func (p *PlanURL) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*p = PlanURL{}
+		return nil
+	}
+	url, err := ParsePlanURL(string(text))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	*p = *url
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p PlanURL) MarshalJSON() ([]byte, error) {
+	return marshalTextJSON(p)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (p *PlanURL) UnmarshalJSON(data []byte) error {
+	return unmarshalTextJSON(data, p)
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (p PlanURL) MarshalYAML() (interface{}, error) {
+	text, err := p.MarshalText()
+	return string(text), err
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (p *PlanURL) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return unmarshalTextYAML(unmarshal, p)
+}
+
+// Set implements gnuflag.Value and flag.Value.
+func (p *PlanURL) Set(s string) error {
+	url, err := ParsePlanURL(s)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	*p = *url
+	return nil
+}
+
+// Compare returns -1, 0 or 1 if the plan url sorts before, equal to or
+// after other, ordering by owner and then by name.
+func (p PlanURL) Compare(other PlanURL) int {
+	if c := strings.Compare(p.Owner, other.Owner); c != 0 {
+		return c
+	}
+	return strings.Compare(p.Name, other.Name)
+}
+
+// Less returns true if the plan url sorts before other.
+func (p PlanURL) Less(other PlanURL) bool {
+	return p.Compare(other) < 0
+}
+
+// MarshalText implements encoding.TextMarshaler. The zero PlanID is
+// marshaled as an empty string.
+func (p PlanID) MarshalText() ([]byte, error) {
+	if p == (PlanID{}) {
+		return []byte{}, nil
+	}
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. An empty string is
+// unmarshaled as the zero PlanID.
+func (p *PlanID) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*p = PlanID{}
+		return nil
+	}
+	id, err := ParsePlanID(string(text))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	*p = *id
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p PlanID) MarshalJSON() ([]byte, error) {
+	return marshalTextJSON(p)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (p *PlanID) UnmarshalJSON(data []byte) error {
+	return unmarshalTextJSON(data, p)
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (p PlanID) MarshalYAML() (interface{}, error) {
+	text, err := p.MarshalText()
+	return string(text), err
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (p *PlanID) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return unmarshalTextYAML(unmarshal, p)
+}
+
+// Set implements gnuflag.Value and flag.Value.
+func (p *PlanID) Set(s string) error {
+	id, err := ParsePlanID(s)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	*p = *id
+	return nil
+}
+
+// Compare returns -1, 0 or 1 if the plan id sorts before, equal to or
+// after other, ordering by plan url and then by revision.
+func (p PlanID) Compare(other PlanID) int {
+	if c := p.PlanURL.Compare(other.PlanURL); c != 0 {
+		return c
+	}
+	switch {
+	case p.Revision < other.Revision:
+		return -1
+	case p.Revision > other.Revision:
+		return 1
+	}
+	return 0
+}
+
+// Less returns true if the plan id sorts before other.
+func (p PlanID) Less(other PlanID) bool {
+	return p.Compare(other) < 0
+}
+
+// marshalTextJSON marshals the text representation of v as a JSON string.
+func marshalTextJSON(v encoding.TextMarshaler) ([]byte, error) {
+	text, err := v.MarshalText()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return json.Marshal(string(text))
+}
+
+// unmarshalTextJSON unmarshals a JSON string into v.
+func unmarshalTextJSON(data []byte, v encoding.TextUnmarshaler) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(v.UnmarshalText([]byte(s)))
+}
+
+// unmarshalTextYAML unmarshals a YAML string into v.
+func unmarshalTextYAML(unmarshal func(interface{}) error, v encoding.TextUnmarshaler) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(v.UnmarshalText([]byte(s)))
+}
@@ -4,8 +4,15 @@
 package wireformat_test
 
 import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"sort"
+
+	"github.com/juju/gnuflag"
 	jc "github.com/juju/testing/checkers"
 	gc "gopkg.in/check.v1"
+	"gopkg.in/yaml.v2"
 
 	"github.com/juju/plans-client/api/wireformat"
 )
@@ -165,3 +172,100 @@ func (t *URLSuite) TestPlanIDParsing(c *gc.C) {
 		}
 	}
 }
+
+func (t *URLSuite) TestPlanURLEncoding(c *gc.C) {
+	type wire struct {
+		URL      wireformat.PlanURL `json:"url" yaml:"url"`
+		Optional wireformat.PlanURL `json:"optional" yaml:"optional"`
+	}
+	w := wire{URL: wireformat.PlanURL{Owner: "owner", Name: "name"}}
+
+	data, err := json.Marshal(w)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, `{"url":"owner/name","optional":""}`)
+	var fromJSON wire
+	err = json.Unmarshal(data, &fromJSON)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(fromJSON, jc.DeepEquals, w)
+
+	data, err = yaml.Marshal(w)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, "url: owner/name\noptional: \"\"\n")
+	var fromYAML wire
+	err = yaml.Unmarshal(data, &fromYAML)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(fromYAML, jc.DeepEquals, w)
+
+	err = json.Unmarshal([]byte(`{"url":"owner/name/1"}`), &fromJSON)
+	c.Assert(err, gc.ErrorMatches, `plan url "owner/name/1" not valid`)
+	err = yaml.Unmarshal([]byte(`url: owner`), &fromYAML)
+	c.Assert(err, gc.ErrorMatches, `plan url "owner" not valid`)
+	err = json.Unmarshal([]byte(`{"url":1}`), &fromJSON)
+	c.Assert(err, gc.ErrorMatches, `json: cannot unmarshal number into .*`)
+}
+
+func (t *URLSuite) TestPlanIDEncoding(c *gc.C) {
+	type wire struct {
+		ID       wireformat.PlanID   `json:"id" yaml:"id"`
+		IDs      []wireformat.PlanID `json:"ids" yaml:"ids"`
+		Optional *wireformat.PlanID  `json:"optional,omitempty" yaml:"optional,omitempty"`
+	}
+	id := wireformat.PlanURL{Owner: "owner", Name: "name"}.Revision(3)
+	w := wire{ID: id, IDs: []wireformat.PlanID{id, id.PlanURL.Revision(4)}}
+
+	data, err := json.Marshal(w)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, `{"id":"owner/name/3","ids":["owner/name/3","owner/name/4"]}`)
+	var fromJSON wire
+	err = json.Unmarshal(data, &fromJSON)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(fromJSON, jc.DeepEquals, w)
+
+	data, err = yaml.Marshal(w)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(data), gc.Equals, "id: owner/name/3\nids:\n- owner/name/3\n- owner/name/4\n")
+	var fromYAML wire
+	err = yaml.Unmarshal(data, &fromYAML)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(fromYAML, jc.DeepEquals, w)
+
+	text, err := wireformat.PlanID{}.MarshalText()
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(string(text), gc.Equals, "")
+	err = json.Unmarshal([]byte(`{"id":"owner/name"}`), &fromJSON)
+	c.Assert(err, gc.ErrorMatches, `plan id "owner/name" not valid`)
+}
+
+func (t *URLSuite) TestFlagValues(c *gc.C) {
+	var url wireformat.PlanURL
+	var id wireformat.PlanID
+	f := gnuflag.NewFlagSet("test", gnuflag.ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	f.Var(&url, "plan", "plan url")
+	f.Var(&id, "plan-id", "plan id")
+	err := f.Parse(true, []string{"--plan", "owner/name", "--plan-id", "owner/other/2"})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(url, gc.Equals, wireformat.PlanURL{Owner: "owner", Name: "name"})
+	c.Assert(id, gc.Equals, wireformat.PlanURL{Owner: "owner", Name: "other"}.Revision(2))
+
+	var value flag.Value = &id
+	c.Assert(value.String(), gc.Equals, "owner/other/2")
+	err = value.Set("owner/other")
+	c.Assert(err, gc.ErrorMatches, `plan id "owner/other" not valid`)
+	err = f.Parse(true, []string{"--plan", "owner/name/1"})
+	c.Assert(err, gc.ErrorMatches, `invalid value "owner/name/1" for flag --plan: plan url "owner/name/1" not valid`)
+}
+
+func (t *URLSuite) TestOrdering(c *gc.C) {
+	a := wireformat.PlanURL{Owner: "alice", Name: "zeta"}
+	b := wireformat.PlanURL{Owner: "bob", Name: "alpha"}
+	c.Assert(a.Compare(b), gc.Equals, -1)
+	c.Assert(b.Compare(a), gc.Equals, 1)
+	c.Assert(a.Compare(a), gc.Equals, 0)
+	c.Assert(a.Less(b), jc.IsTrue)
+
+	ids := []wireformat.PlanID{b.Revision(1), a.Revision(10), a.Revision(2)}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Less(ids[j]) })
+	c.Assert(ids, jc.DeepEquals, []wireformat.PlanID{a.Revision(2), a.Revision(10), b.Revision(1)})
+	c.Assert(a.Revision(2).Compare(a.Revision(2)), gc.Equals, 0)
+}
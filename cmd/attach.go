@@ -50,7 +50,7 @@ func NewAttachCommand() cmd.Command {
 func (c *AttachCommand) SetFlags(f *gnuflag.FlagSet) {
 	c.baseCommand.ServiceURL = defaultServiceURL()
 	c.baseCommand.SetFlags(f)
-	c.out.AddFlags(f, "yaml", output.DefaultFormatters)
+	c.addOutputFlags(f, &c.out, "yaml", output.DefaultFormatters)
 	f.BoolVar(&c.IsDefault, "default", false, "set this plan as the default for the charm")
 }
 
@@ -84,12 +84,19 @@ func (c *AttachCommand) Init(args []string) error {
 	if err != nil {
 		return errors.Trace(err)
 	}
-	c.PlanURL = planURL
+	if c.PlanURL, err = c.unqualifyPlan(planURL); err != nil {
+		return errors.Trace(err)
+	}
 	client, cleanup, err := c.NewClient(ctx)
 	if err != nil {
 		return errors.Annotate(err, "could not create API client")
 	}
 	defer cleanup()
+	if r, ok := c.CharmResolver.(*charmStoreResolver); ok {
+		if r.csURL, err = c.charmStoreURL(); err != nil {
+			return errors.Trace(err)
+		}
+	}
 	resolved, err := c.CharmResolver.Resolve(client, charmURL)
 	if err != nil {
 		return errors.Annotate(err, "could not resolve charm url")
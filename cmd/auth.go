@@ -0,0 +1,200 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/utils"
+	"gopkg.in/macaroon-bakery.v2/bakery/checkers"
+	"gopkg.in/macaroon-bakery.v2/httpbakery"
+	"gopkg.in/macaroon-bakery.v2/httpbakery/agent"
+	"gopkg.in/macaroon.v2"
+)
+
+const (
+	// agentFileEnvVar holds the name of the environment variable
+	// specifying the default agent file.
+	agentFileEnvVar = "BAKERY_AGENT_FILE"
+
+	// macaroonFileEnvVar holds the name of the environment variable
+	// specifying the default file of pre-discharged macaroons.
+	macaroonFileEnvVar = "PLANS_MACAROON_FILE"
+)
+
+// interactiveKinds holds the kinds of the interactive login methods
+// supported by the commands: web browser and USSO login.
+var interactiveKinds = []string{
+	httpbakery.WebBrowserInteractionKind,
+	"usso_oauth",
+}
+
+// readAgentFile reads the agent authentication information (a key pair
+// and the agent users) from the specified file.
+func readAgentFile(path string) (*agent.AuthInfo, error) {
+	path, err := utils.NormalizePath(path)
+	if err != nil {
+		return nil, errors.Annotate(err, "invalid agent file path")
+	}
+	data, err := readFile(path)
+	if err != nil {
+		return nil, errors.Annotate(err, "failed to read agent file")
+	}
+	var info agent.AuthInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, errors.Annotatef(err, "failed to parse agent file %q", path)
+	}
+	if info.Key == nil {
+		return nil, errors.NotValidf("agent file %q with no key", path)
+	}
+	if len(info.Agents) == 0 {
+		return nil, errors.NotValidf("agent file %q with no agents", path)
+	}
+	for _, a := range info.Agents {
+		if u, err := url.Parse(a.URL); err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, errors.NotValidf("agent url %q in %q", a.URL, path)
+		}
+		if a.Username == "" {
+			return nil, errors.NotValidf("agent for %q in %q with no username", a.URL, path)
+		}
+	}
+	return &info, nil
+}
+
+// readMacaroonFile reads pre-discharged macaroons from the specified
+// file. The file holds either a single macaroon slice (a primary
+// macaroon followed by its discharges) or a list of macaroon slices,
+// encoded as JSON or as base64-encoded JSON.
+func readMacaroonFile(path string) ([]macaroon.Slice, error) {
+	path, err := utils.NormalizePath(path)
+	if err != nil {
+		return nil, errors.Annotate(err, "invalid macaroon file path")
+	}
+	data, err := readFile(path)
+	if err != nil {
+		return nil, errors.Annotate(err, "failed to read macaroon file")
+	}
+	text := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(text, "[") {
+		decoded, err := base64.StdEncoding.DecodeString(text)
+		if err != nil {
+			decoded, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(text, "="))
+		}
+		if err != nil {
+			return nil, errors.Errorf("failed to parse macaroon file %q: expected JSON or base64-encoded JSON", path)
+		}
+		data = decoded
+	}
+	var slices []macaroon.Slice
+	if err := json.Unmarshal(data, &slices); err != nil {
+		var ms macaroon.Slice
+		if err := json.Unmarshal(data, &ms); err != nil {
+			return nil, errors.Annotatef(err, "failed to parse macaroon file %q", path)
+		}
+		slices = []macaroon.Slice{ms}
+	}
+	if len(slices) == 0 {
+		return nil, errors.NotValidf("macaroon file %q with no macaroons", path)
+	}
+	for i, ms := range slices {
+		if len(ms) == 0 {
+			return nil, errors.NotValidf("empty macaroon slice %d in %q", i, path)
+		}
+	}
+	return slices, nil
+}
+
+// addMacaroons adds the macaroons to the cookie jar of the client, for
+// use with requests to the service URL. Expired macaroons are rejected
+// since the service would require a new discharge.
+func addMacaroons(client *httpbakery.Client, serviceURL string, slices []macaroon.Slice) error {
+	u, err := url.Parse(serviceURL)
+	if err != nil {
+		return errors.Annotatef(err, "invalid service url %q", serviceURL)
+	}
+	ns := checkers.New(nil).Namespace()
+	for _, ms := range slices {
+		if expires, ok := checkers.MacaroonsExpiryTime(ns, ms); ok && !expires.After(time.Now()) {
+			return errors.Errorf("macaroon %x expired at %s", ms[0].Signature(), expires.UTC().Format(time.RFC3339))
+		}
+		if err := httpbakery.SetCookie(client.Jar, u, ns, ms); err != nil {
+			return errors.Annotate(err, "failed to add macaroons")
+		}
+	}
+	return nil
+}
+
+// nonInteractiveInteractor takes the place of an interactive login
+// method when the client authenticates as an agent or with
+// pre-discharged macaroons, reporting why the discharge failed rather
+// than waiting for a user.
+type nonInteractiveInteractor struct {
+	kind   string
+	reason string
+}
+
+// Kind implements httpbakery.Interactor.
+func (i nonInteractiveInteractor) Kind() string {
+	return i.kind
+}
+
+// Interact implements httpbakery.Interactor.
+func (i nonInteractiveInteractor) Interact(_ context.Context, _ *httpbakery.Client, location string, _ *httpbakery.Error) (*httpbakery.DischargeToken, error) {
+	return nil, i.err(location)
+}
+
+// LegacyInteract implements httpbakery.LegacyInteractor.
+func (i nonInteractiveInteractor) LegacyInteract(_ context.Context, _ *httpbakery.Client, location string, _ *url.URL) error {
+	return i.err(location)
+}
+
+func (i nonInteractiveInteractor) err(location string) error {
+	return errors.Errorf("discharge from %s requires interactive login, which is disabled when %s", location, i.reason)
+}
+
+// addNonInteractiveAuth configures the client to authenticate with the
+// agent file and pre-discharged macaroons of the command, if any. It
+// returns false if neither is specified, in which case the interactive
+// login methods should be used.
+func (s *baseCommand) addNonInteractiveAuth(client *httpbakery.Client) (bool, error) {
+	var reasons []string
+	if s.MacaroonFile != "" {
+		slices, err := readMacaroonFile(s.MacaroonFile)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		if err := addMacaroons(client, s.ServiceURL, slices); err != nil {
+			return false, errors.Trace(err)
+		}
+		reasons = append(reasons, "using pre-discharged macaroons")
+	}
+	if s.AgentFile != "" {
+		info, err := readAgentFile(s.AgentFile)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		if err := agent.SetUpAuth(client, info); err != nil {
+			return false, errors.Annotate(err, "failed to set up agent authentication")
+		}
+		users := make([]string, len(info.Agents))
+		for i, a := range info.Agents {
+			users[i] = a.Username
+		}
+		reasons = append(reasons, "authenticating as agent "+strings.Join(users, ", "))
+	}
+	if len(reasons) == 0 {
+		return false, nil
+	}
+	reason := strings.Join(reasons, " and ")
+	for _, kind := range interactiveKinds {
+		client.AddInteractor(nonInteractiveInteractor{kind: kind, reason: reason})
+	}
+	return true, nil
+}
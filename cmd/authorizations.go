@@ -0,0 +1,201 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gosuri/uitable"
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+
+	"github.com/juju/plans-client/api/wireformat"
+)
+
+const listAuthorizationsDoc = `
+list-authorizations lists the plan authorizations matching the specified
+criteria.
+Examples
+list-authorizations --plan canonical/landscape-default
+	lists all authorizations of the canonical/landscape-default plan.
+list-authorizations --model 9d4ba8a9-1b2c-4f5e-8e3a-2f0c63a1c0de --application landscape
+	lists the authorizations of the landscape application in the model.
+list-authorizations --user bob --include-plan --format csv
+	lists the authorizations issued to bob, including the plan definitions,
+	as comma separated values.
+list-authorizations --plan canonical/landscape-default --statement-period last-month
+	lists the authorizations of the plan for the previous month. Statement
+	periods are specified as YYYY-MM, this-month or last-month.
+`
+
+const listAuthorizationsPurpose = "list plan authorizations"
+
+// NewListAuthorizationsCommand returns a new ListAuthorizationsCommand.
+func NewListAuthorizationsCommand() cmd.Command {
+	return &ListAuthorizationsCommand{}
+}
+
+// ListAuthorizationsCommand lists plan authorizations.
+type ListAuthorizationsCommand struct {
+	baseCommand
+
+	out   cmd.Output
+	Query wireformat.AuthorizationQuery
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *ListAuthorizationsCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.baseCommand.SetFlags(f)
+	c.addOutputFlags(f, &c.out, "tabular", map[string]cmd.Formatter{
+		"json":    cmd.FormatJson,
+		"yaml":    cmd.FormatYaml,
+		"csv":     formatAuthorizationsCSV,
+		"tabular": formatAuthorizationsTabular,
+	})
+	f.StringVar(&c.Query.AuthorizationID, "authorization-id", "", "authorization id")
+	f.StringVar(&c.Query.User, "user", "", "user the authorization was issued to")
+	f.StringVar(&c.Query.PlanURL, "plan", "", "plan url")
+	f.StringVar(&c.Query.EnvironmentUUID, "model", "", "model uuid")
+	f.StringVar(&c.Query.EnvironmentUUID, "env", "", "")
+	f.StringVar(&c.Query.CharmURL, "charm", "", "charm url")
+	f.StringVar(&c.Query.ServiceName, "application", "", "application name")
+	f.StringVar(&c.Query.ServiceName, "service", "", "")
+	f.BoolVar(&c.Query.IncludePlan, "include-plan", false, "include the plan definition")
+	f.Var(statementPeriodValue{&c.Query.StatementPeriod}, "statement-period", "statement period of the authorizations "+statementPeriodUsage)
+}
+
+// Info implements Command.Info.
+func (c *ListAuthorizationsCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "list-authorizations",
+		Purpose: listAuthorizationsPurpose,
+		Doc:     listAuthorizationsDoc,
+	}
+}
+
+// Init implements Command.Init.
+func (c *ListAuthorizationsCommand) Init(args []string) error {
+	if err := cmd.CheckEmpty(args); err != nil {
+		return errors.Errorf("unknown command line arguments: " + strings.Join(args, ","))
+	}
+	if c.Query.PlanURL != "" {
+		planURL, err := c.unqualifyPlan(c.Query.PlanURL)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		c.Query.PlanURL = planURL
+		if _, err := wireformat.ParsePlanURL(c.Query.PlanURL); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(c.Query.Validate())
+}
+
+// Run implements Command.Run.
+func (c *ListAuthorizationsCommand) Run(ctx *cmd.Context) error {
+	client, cleanup, err := c.NewClient(ctx)
+	if err != nil {
+		return errors.Annotate(err, "failed to create an http client")
+	}
+	defer cleanup()
+	apiClient, err := newClient(c.ServiceURL, client)
+	if err != nil {
+		return errors.Annotate(err, "failed to create a plan API client")
+	}
+	auths, err := apiClient.GetAuthorizations(context.Background(), c.Query)
+	if err != nil {
+		return errors.Annotate(err, "failed to retrieve authorizations")
+	}
+	details := make([]authorizationDetails, len(auths))
+	for i, a := range auths {
+		details[i] = authorizationFromWire(a)
+	}
+	return errors.Trace(c.out.Write(ctx, details))
+}
+
+// authorizationDetails is the output format of a plan authorization,
+// using the model and application names of the Juju 2.0 vocabulary.
+type authorizationDetails struct {
+	ID             string    `json:"authorization-id" yaml:"authorization-id"`
+	User           string    `json:"user" yaml:"user"`
+	Plan           string    `json:"plan" yaml:"plan"`
+	PlanID         string    `json:"plan-id,omitempty" yaml:"plan-id,omitempty"`
+	Model          string    `json:"model-uuid" yaml:"model-uuid"`
+	Charm          string    `json:"charm-url" yaml:"charm-url"`
+	Application    string    `json:"application" yaml:"application"`
+	CreatedOn      time.Time `json:"created-on" yaml:"created-on"`
+	CredentialsID  string    `json:"credentials-id,omitempty" yaml:"credentials-id,omitempty"`
+	PlanDefinition string    `json:"plan-definition,omitempty" yaml:"plan-definition,omitempty"`
+}
+
+func authorizationFromWire(a wireformat.Authorization) authorizationDetails {
+	return authorizationDetails{
+		ID:             a.AuthorizationID,
+		User:           a.User,
+		Plan:           a.PlanURL,
+		PlanID:         a.PlanID,
+		Model:          a.EnvironmentUUID,
+		Charm:          a.CharmURL,
+		Application:    a.ServiceName,
+		CreatedOn:      a.CreatedOn.UTC(),
+		CredentialsID:  a.CredentialsID,
+		PlanDefinition: a.PlanDefinition,
+	}
+}
+
+func formatAuthorizationsTabular(w io.Writer, value interface{}) error {
+	auths, ok := value.([]authorizationDetails)
+	if !ok {
+		return errors.Errorf("expected value of type %T, got %T", auths, value)
+	}
+	table := uitable.New()
+	table.MaxColWidth = 50
+	table.Wrap = true
+	table.AddRow("ID", "USER", "PLAN", "MODEL", "CHARM", "APPLICATION", "CREATED ON")
+	for _, a := range auths {
+		plan := a.Plan
+		if a.PlanID != "" {
+			plan = a.PlanID
+		}
+		table.AddRow(a.ID, a.User, plan, a.Model, a.Charm, a.Application, a.CreatedOn.Format(time.RFC3339))
+	}
+	_, err := w.Write(table.Bytes())
+	if err != nil {
+		return errors.Annotatef(err, "failed to print table")
+	}
+	return nil
+}
+
+func formatAuthorizationsCSV(w io.Writer, value interface{}) error {
+	auths, ok := value.([]authorizationDetails)
+	if !ok {
+		return errors.Errorf("expected value of type %T, got %T", auths, value)
+	}
+	rows := [][]string{{"authorization-id", "user", "plan", "plan-id", "model-uuid", "charm-url", "application", "created-on", "credentials-id", "plan-definition"}}
+	for _, a := range auths {
+		rows = append(rows, []string{a.ID, a.User, a.Plan, a.PlanID, a.Model, a.Charm, a.Application, a.CreatedOn.Format(time.RFC3339), a.CredentialsID, a.PlanDefinition})
+	}
+	return errors.Trace(writeCSV(w, rows))
+}
+
+// writeCSV writes the rows to w as comma separated values. The final
+// line break is omitted since the output adds one.
+func writeCSV(w io.Writer, rows [][]string) error {
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	if err := cw.WriteAll(rows); err != nil {
+		return errors.Annotate(err, "failed to write csv")
+	}
+	_, err := w.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	if err != nil {
+		return errors.Annotate(err, "failed to write csv")
+	}
+	return nil
+}
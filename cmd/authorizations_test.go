@@ -0,0 +1,150 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd_test
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/juju/cmd/cmdtesting"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/macaroon-bakery.v2/httpbakery"
+
+	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/api/wireformat"
+	"github.com/juju/plans-client/cmd"
+	plantesting "github.com/juju/plans-client/testing"
+)
+
+type listAuthorizationsSuite struct {
+	testing.CleanupSuite
+	mockAPI *plantesting.MockPlanClient
+}
+
+var _ = gc.Suite(&listAuthorizationsSuite{})
+
+func (s *listAuthorizationsSuite) SetUpTest(c *gc.C) {
+	s.PatchEnvironment("GOCOOKIES", filepath.Join(c.MkDir(), "cookies"))
+	s.mockAPI = plantesting.NewMockPlanClient()
+	s.mockAPI.Authorizations = []wireformat.Authorization{{
+		AuthorizationID: "auth-1",
+		User:            "bob",
+		PlanURL:         "testisv/default",
+		EnvironmentUUID: "model-uuid-1",
+		CharmURL:        "cs:~testisv/charm1-0",
+		ServiceName:     "app1",
+		CreatedOn:       time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC),
+		CredentialsID:   "credentials-1",
+	}}
+	s.PatchValue(cmd.NewClient, func(string, *httpbakery.Client) (api.PlanClient, error) {
+		return s.mockAPI, nil
+	})
+	s.PatchValue(cmd.Now, func() time.Time {
+		return time.Date(2017, 1, 15, 0, 0, 0, 0, time.UTC)
+	})
+}
+
+func (s *listAuthorizationsSuite) TestCommand(c *gc.C) {
+	tests := []struct {
+		about          string
+		args           []string
+		authorizations []wireformat.Authorization
+		err            string
+		stdout         string
+		query          wireformat.AuthorizationQuery
+	}{{
+		about: "unrecognized args causes error",
+		args:  []string{"foobar"},
+		err:   `unknown command line arguments: foobar`,
+	}, {
+		about: "invalid plan url",
+		args:  []string{"--plan", "default"},
+		err:   `plan url "default" not valid`,
+	}, {
+		about: "invalid statement period",
+		args:  []string{"--statement-period", "2017-13"},
+		err:   `invalid value "2017-13" for flag --statement-period: statement period "2017-13" not valid`,
+	}, {
+		about: "relative statement period",
+		args:  []string{"--statement-period", "last-month", "--format", "json"},
+		stdout: `[{"authorization-id":"auth-1","user":"bob","plan":"testisv/default","model-uuid":"model-uuid-1","charm-url":"cs:~testisv/charm1-0","application":"app1","created-on":"2017-01-02T03:04:05Z","credentials-id":"credentials-1"}]
+`,
+		query: wireformat.AuthorizationQuery{StatementPeriod: "2016-12"},
+	}, {
+		about: "tabular output",
+		args:  []string{"--plan", "testisv/default"},
+		stdout: `ID    	USER	PLAN           	MODEL       	CHARM               	APPLICATION	CREATED ON          
+auth-1	bob 	testisv/default	model-uuid-1	cs:~testisv/charm1-0	app1       	2017-01-02T03:04:05Z
+`,
+		query: wireformat.AuthorizationQuery{PlanURL: "testisv/default"},
+	}, {
+		about: "model and application flags",
+		args:  []string{"--model", "model-uuid-1", "--application", "app1", "--user", "bob", "--charm", "cs:~testisv/charm1-0", "--authorization-id", "auth-1", "--format", "json"},
+		stdout: `[{"authorization-id":"auth-1","user":"bob","plan":"testisv/default","model-uuid":"model-uuid-1","charm-url":"cs:~testisv/charm1-0","application":"app1","created-on":"2017-01-02T03:04:05Z","credentials-id":"credentials-1"}]
+`,
+		query: wireformat.AuthorizationQuery{
+			AuthorizationID: "auth-1",
+			User:            "bob",
+			EnvironmentUUID: "model-uuid-1",
+			CharmURL:        "cs:~testisv/charm1-0",
+			ServiceName:     "app1",
+		},
+	}, {
+		about: "env and service flags",
+		args:  []string{"--env", "model-uuid-1", "--service", "app1", "--statement-period", "2017-01", "--format", "yaml"},
+		stdout: `- authorization-id: auth-1
+  user: bob
+  plan: testisv/default
+  model-uuid: model-uuid-1
+  charm-url: cs:~testisv/charm1-0
+  application: app1
+  created-on: 2017-01-02T03:04:05Z
+  credentials-id: credentials-1
+`,
+		query: wireformat.AuthorizationQuery{
+			EnvironmentUUID: "model-uuid-1",
+			ServiceName:     "app1",
+			StatementPeriod: "2017-01",
+		},
+	}, {
+		about: "csv output with plan",
+		args:  []string{"--include-plan", "--format", "csv"},
+		authorizations: []wireformat.Authorization{{
+			AuthorizationID: "auth-1",
+			User:            "bob",
+			PlanURL:         "testisv/default",
+			PlanID:          "testisv/default/3",
+			EnvironmentUUID: "model-uuid-1",
+			CharmURL:        "cs:~testisv/charm1-0",
+			ServiceName:     "app1",
+			CreatedOn:       time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC),
+			PlanDefinition:  "metrics:\n  units: {}\n",
+		}},
+		stdout: `authorization-id,user,plan,plan-id,model-uuid,charm-url,application,created-on,credentials-id,plan-definition
+auth-1,bob,testisv/default,testisv/default/3,model-uuid-1,cs:~testisv/charm1-0,app1,2017-01-02T03:04:05Z,,"metrics:
+  units: {}
+"
+`,
+		query: wireformat.AuthorizationQuery{IncludePlan: true},
+	}}
+	defaultAuthorizations := s.mockAPI.Authorizations
+	for i, t := range tests {
+		c.Logf("running test %d: %s", i, t.about)
+		s.mockAPI.ResetCalls()
+		s.mockAPI.Authorizations = defaultAuthorizations
+		if t.authorizations != nil {
+			s.mockAPI.Authorizations = t.authorizations
+		}
+		ctx, err := cmdtesting.RunCommand(c, cmd.NewListAuthorizationsCommand(), t.args...)
+		if t.err != "" {
+			c.Assert(err, gc.ErrorMatches, t.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(cmdtesting.Stdout(ctx), gc.Equals, t.stdout)
+		s.mockAPI.CheckCall(c, 0, "GetAuthorizations", t.query)
+	}
+}
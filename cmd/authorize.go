@@ -0,0 +1,161 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+
+	"github.com/juju/plans-client/api/wireformat"
+)
+
+const authorizePlanDoc = `
+authorize-plan obtains the authorization macaroon used to deploy an
+application of the charm with the plan, as done by Juju when deploying.
+The macaroon is written to standard output or to the file specified with
+--output.
+
+With --check, no authorization is issued: the command verifies that the
+plan is released and attached to the charm, and that the plan is not
+suspended for the charm.
+Examples
+authorize-plan --model 9d4ba8a9-1b2c-4f5e-8e3a-2f0c63a1c0de --application landscape cs:~canonical/landscape-1 canonical/landscape-default
+	writes the authorization macaroon, as JSON, to standard output.
+authorize-plan --model 9d4ba8a9-1b2c-4f5e-8e3a-2f0c63a1c0de --application landscape cs:~canonical/landscape-1 canonical/landscape-default --format base64 -o auth.macaroon
+	writes the base64-encoded macaroon to auth.macaroon.
+authorize-plan --model 9d4ba8a9-1b2c-4f5e-8e3a-2f0c63a1c0de --application landscape cs:~canonical/landscape-1 canonical/landscape-default --format inspect
+	displays the contents of the macaroon, as inspect-macaroon does.
+authorize-plan --check --model 9d4ba8a9-1b2c-4f5e-8e3a-2f0c63a1c0de --application landscape cs:~canonical/landscape-1 canonical/landscape-default
+	checks that the authorization would be issued.
+`
+
+const authorizePlanPurpose = "authorize the deployment of a charm with a plan"
+
+// NewAuthorizePlanCommand returns a new AuthorizePlanCommand.
+func NewAuthorizePlanCommand() cmd.Command {
+	return &AuthorizePlanCommand{}
+}
+
+// AuthorizePlanCommand obtains a plan authorization macaroon.
+type AuthorizePlanCommand struct {
+	baseCommand
+
+	out     cmd.Output
+	Request wireformat.AuthorizationRequest
+	Check   bool
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *AuthorizePlanCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.baseCommand.SetFlags(f)
+	c.addOutputFlags(f, &c.out, "json", macaroonFormatters)
+	f.StringVar(&c.Request.EnvironmentUUID, "model", "", "model uuid")
+	f.StringVar(&c.Request.EnvironmentUUID, "env", "", "")
+	f.StringVar(&c.Request.ServiceName, "application", "", "application name")
+	f.StringVar(&c.Request.ServiceName, "service", "", "")
+	f.BoolVar(&c.Check, "check", false, "check that the authorization would be issued, without issuing it")
+}
+
+// Info implements Command.Info.
+func (c *AuthorizePlanCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "authorize-plan",
+		Args:    "<charm url> <plan url>",
+		Purpose: authorizePlanPurpose,
+		Doc:     authorizePlanDoc,
+	}
+}
+
+// Init implements Command.Init.
+func (c *AuthorizePlanCommand) Init(args []string) error {
+	if len(args) < 2 {
+		return errors.New("missing arguments")
+	}
+	c.Request.CharmURL = args[0]
+	if err := cmd.CheckEmpty(args[2:]); err != nil {
+		return errors.Errorf("unknown command line arguments: " + strings.Join(args[2:], ","))
+	}
+	planURL, err := c.unqualifyPlan(args[1])
+	if err != nil {
+		return errors.Trace(err)
+	}
+	c.Request.PlanURL = planURL
+	if err := c.Request.Validate(); err != nil {
+		return errors.Trace(err)
+	}
+	if _, err := wireformat.ParsePlanIDWithOptionalRevision(c.Request.PlanURL); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// Run implements Command.Run.
+func (c *AuthorizePlanCommand) Run(ctx *cmd.Context) error {
+	client, cleanup, err := c.NewClient(ctx)
+	if err != nil {
+		return errors.Annotate(err, "failed to create an http client")
+	}
+	defer cleanup()
+	apiClient, err := newClient(c.ServiceURL, client)
+	if err != nil {
+		return errors.Annotate(err, "failed to create a plan API client")
+	}
+	r := c.Request
+	if c.Check {
+		planID, err := wireformat.ParsePlanIDWithOptionalRevision(r.PlanURL)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		details, err := apiClient.GetPlanDetails(context.Background(), planID.PlanURL.String())
+		if err != nil {
+			return errors.Annotatef(err, "failed to retrieve plan %v details", planID.PlanURL)
+		}
+		if err := checkAuthorization(details, planID, r.CharmURL); err != nil {
+			return errors.Annotatef(err, "authorization of %v for %v would fail", r.PlanURL, r.CharmURL)
+		}
+		fmt.Fprintf(ctx.Stdout, "authorization of %v for %v would be issued\n", r.PlanURL, r.CharmURL)
+		return nil
+	}
+	m, err := apiClient.Authorize(context.Background(), r.EnvironmentUUID, r.CharmURL, r.ServiceName, r.PlanURL)
+	if err != nil {
+		return errors.Annotate(err, "failed to authorize plan")
+	}
+	return errors.Trace(c.out.Write(ctx, m))
+}
+
+// checkAuthorization verifies that the plans service would authorize the
+// deployment of the charm with the plan.
+func checkAuthorization(details *wireformat.PlanDetails, planID *wireformat.PlanID, charmURL string) error {
+	if details.Released == nil {
+		return errors.Errorf("plan %v is not released", planID.PlanURL)
+	}
+	if planID.Revision != 0 {
+		released, err := wireformat.ParsePlanID(details.Plan.Id)
+		if err == nil && released.Revision != planID.Revision {
+			return errors.Errorf("plan revision %d is not the released revision %d", planID.Revision, released.Revision)
+		}
+	}
+	for _, charm := range details.Charms {
+		if wireformat.CharmBaseURL(charm.CharmURL) != wireformat.CharmBaseURL(charmURL) {
+			continue
+		}
+		latest := charm.Attached
+		for _, e := range charm.Events {
+			if e.Time.After(latest.Time) {
+				latest = e
+			}
+		}
+		if latest.EventType() == wireformat.EventSuspended {
+			return errors.Errorf("plan is suspended for charm %v since %v by %v", charm.CharmURL, latest.Time.UTC().Format(time.RFC3339), latest.User)
+		}
+		return nil
+	}
+	return errors.Errorf("plan is not attached to charm %v", charmURL)
+}
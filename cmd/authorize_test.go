@@ -0,0 +1,160 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/juju/cmd/cmdtesting"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/macaroon-bakery.v2/httpbakery"
+	"gopkg.in/macaroon.v1"
+
+	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/cmd"
+	plantesting "github.com/juju/plans-client/testing"
+)
+
+const testModelUUID = "9d4ba8a9-1b2c-4f5e-8e3a-2f0c63a1c0de"
+
+type authorizePlanSuite struct {
+	testing.CleanupSuite
+	mockAPI *plantesting.MockPlanClient
+}
+
+var _ = gc.Suite(&authorizePlanSuite{})
+
+func (s *authorizePlanSuite) SetUpTest(c *gc.C) {
+	s.PatchEnvironment("GOCOOKIES", filepath.Join(c.MkDir(), "cookies"))
+	s.mockAPI = plantesting.NewMockPlanClient()
+	m, err := macaroon.New([]byte("root-key"), "plan-auth", "plans")
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.AuthorizationMacaroon = m
+	s.PatchValue(cmd.NewClient, func(string, *httpbakery.Client) (api.PlanClient, error) {
+		return s.mockAPI, nil
+	})
+}
+
+func (s *authorizePlanSuite) TestInvalidArgs(c *gc.C) {
+	tests := []struct {
+		about string
+		args  []string
+		err   string
+	}{{
+		about: "missing arguments",
+		args:  []string{"cs:~testisv/charm1-0"},
+		err:   `missing arguments`,
+	}, {
+		about: "unknown arguments",
+		args:  []string{"--model", testModelUUID, "--application", "app1", "cs:~testisv/charm1-0", "testisv/default", "extra"},
+		err:   `unknown command line arguments: extra`,
+	}, {
+		about: "invalid model",
+		args:  []string{"--model", "model", "--application", "app1", "cs:~testisv/charm1-0", "testisv/default"},
+		err:   `invalid environment UUID: "model"`,
+	}, {
+		about: "missing application",
+		args:  []string{"--model", testModelUUID, "cs:~testisv/charm1-0", "testisv/default"},
+		err:   `undefined service name`,
+	}, {
+		about: "invalid charm",
+		args:  []string{"--model", testModelUUID, "--application", "app1", "cs:~testisv/Charm", "testisv/default"},
+		err:   `invalid charm url: "cs:~testisv/Charm"`,
+	}, {
+		about: "invalid plan",
+		args:  []string{"--model", testModelUUID, "--application", "app1", "cs:~testisv/charm1-0", "default"},
+		err:   `plan id "default" not valid`,
+	}}
+	for i, t := range tests {
+		c.Logf("running test %d: %s", i, t.about)
+		_, err := cmdtesting.RunCommand(c, cmd.NewAuthorizePlanCommand(), t.args...)
+		c.Assert(err, gc.ErrorMatches, t.err)
+	}
+	s.mockAPI.CheckNoCalls(c)
+}
+
+func (s *authorizePlanSuite) TestAuthorize(c *gc.C) {
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewAuthorizePlanCommand(), "--env", testModelUUID, "--service", "app1", "cs:~testisv/charm1-0", "testisv/default")
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.CheckCall(c, 0, "Authorize", testModelUUID, "cs:~testisv/charm1-0", "app1", "testisv/default")
+	var m macaroon.Macaroon
+	err = json.Unmarshal([]byte(cmdtesting.Stdout(ctx)), &m)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(m.Id(), gc.Equals, "plan-auth")
+}
+
+func (s *authorizePlanSuite) TestAuthorizeToFile(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "auth.json")
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewAuthorizePlanCommand(), "--model", testModelUUID, "--application", "app1", "cs:~testisv/charm1-0", "testisv/default", "-o", path)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, "")
+	data, err := ioutil.ReadFile(path)
+	c.Assert(err, jc.ErrorIsNil)
+	var m macaroon.Macaroon
+	err = json.Unmarshal(data, &m)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(m.Id(), gc.Equals, "plan-auth")
+}
+
+func (s *authorizePlanSuite) TestAuthorizeInspect(c *gc.C) {
+	err := s.mockAPI.AuthorizationMacaroon.AddFirstPartyCaveat("declared plan testisv/default")
+	c.Assert(err, jc.ErrorIsNil)
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewAuthorizePlanCommand(), "--model", testModelUUID, "--application", "app1", "cs:~testisv/charm1-0", "testisv/default", "--format", "inspect")
+	c.Assert(err, jc.ErrorIsNil)
+	stdout := cmdtesting.Stdout(ctx)
+	c.Assert(stdout, gc.Matches, `(?s)LOCATION\s+plans\s*\nID\s+plan-auth\s*\n.*`)
+	c.Assert(stdout, gc.Matches, `(?s).*DECLARED\s+plan=testisv/default\s*\n.*`)
+}
+
+func (s *authorizePlanSuite) TestCheck(c *gc.C) {
+	tests := []struct {
+		about  string
+		charm  string
+		plan   string
+		err    string
+		stdout string
+	}{{
+		about:  "authorization would be issued",
+		charm:  "cs:~testisv/charm1-3",
+		plan:   "testisv/default",
+		stdout: "authorization of testisv/default for cs:~testisv/charm1-3 would be issued\n",
+	}, {
+		about:  "released revision",
+		charm:  "cs:~testisv/charm1-0",
+		plan:   "testisv/default/1",
+		stdout: "authorization of testisv/default/1 for cs:~testisv/charm1-0 would be issued\n",
+	}, {
+		about: "unreleased revision",
+		charm: "cs:~testisv/charm1-0",
+		plan:  "testisv/default/2",
+		err:   `authorization of testisv/default/2 for cs:~testisv/charm1-0 would fail: plan revision 2 is not the released revision 1`,
+	}, {
+		about: "suspended",
+		charm: "cs:~testisv/charm2-1",
+		plan:  "testisv/default",
+		err:   `authorization of testisv/default for cs:~testisv/charm2-1 would fail: plan is suspended for charm cs:~testisv/charm2-1 since 2015-01-01T01:02:03Z by eve.jaas`,
+	}, {
+		about: "not attached",
+		charm: "cs:~testisv/charm3-0",
+		plan:  "testisv/default",
+		err:   `authorization of testisv/default for cs:~testisv/charm3-0 would fail: plan is not attached to charm cs:~testisv/charm3-0`,
+	}}
+	for i, t := range tests {
+		c.Logf("running test %d: %s", i, t.about)
+		s.mockAPI.ResetCalls()
+		ctx, err := cmdtesting.RunCommand(c, cmd.NewAuthorizePlanCommand(), "--check", "--model", testModelUUID, "--application", "app1", t.charm, t.plan)
+		s.mockAPI.CheckCallNames(c, "GetPlanDetails")
+		s.mockAPI.CheckCall(c, 0, "GetPlanDetails", "testisv/default")
+		if t.err != "" {
+			c.Assert(err, gc.ErrorMatches, t.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(cmdtesting.Stdout(ctx), gc.Equals, t.stdout)
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/gosuri/uitable"
+	"github.com/juju/charm/v8"
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+	"gopkg.in/macaroon-bakery.v2/httpbakery"
+
+	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/api/wireformat"
+)
+
+const checkBundlePlansDoc = `
+check-bundle-plans checks that every charm of a bundle can be deployed
+with a plan. Applications pinning a plan pass when the plan is attached to
+the charm, released and not suspended for the charm. Other applications
+pass when the charm has a released default plan that is not suspended.
+
+Applications whose charm has no plans attached and local charms are
+reported as warnings. The command exits with an error when any application
+fails the check, or when any application has a warning if --strict is
+specified, so it may be used in continuous integration.
+Examples
+check-bundle-plans bundle.yaml
+	checks the plans of all the applications of the bundle.
+check-bundle-plans bundle.yaml --strict --format json
+	checks the plans of the bundle, failing on warnings, and prints the
+	results as JSON.
+`
+
+const checkBundlePlansPurpose = "check the plans of the charms in a bundle"
+
+// Results of the plan check of a bundle application.
+const (
+	checkPass = "pass"
+	checkWarn = "warn"
+	checkFail = "fail"
+)
+
+// NewCheckBundlePlansCommand returns a new CheckBundlePlansCommand.
+func NewCheckBundlePlansCommand() cmd.Command {
+	return &CheckBundlePlansCommand{
+		CharmResolver: NewCharmStoreResolver(),
+	}
+}
+
+// CheckBundlePlansCommand checks the plans of the charms of a bundle.
+type CheckBundlePlansCommand struct {
+	baseCommand
+
+	CharmResolver charmResolver
+
+	out    cmd.Output
+	Bundle string
+	Strict bool
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *CheckBundlePlansCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.baseCommand.SetFlags(f)
+	c.addOutputFlags(f, &c.out, "tabular", map[string]cmd.Formatter{
+		"json":    cmd.FormatJson,
+		"yaml":    cmd.FormatYaml,
+		"tabular": formatBundlePlanChecksTabular,
+	})
+	f.BoolVar(&c.Strict, "strict", false, "treat warnings as failures")
+}
+
+// Info implements Command.Info.
+func (c *CheckBundlePlansCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "check-bundle-plans",
+		Args:    "<bundle.yaml>",
+		Purpose: checkBundlePlansPurpose,
+		Doc:     checkBundlePlansDoc,
+	}
+}
+
+// Init implements Command.Init.
+func (c *CheckBundlePlansCommand) Init(args []string) error {
+	if len(args) < 1 {
+		return errors.New("missing bundle")
+	}
+	c.Bundle = args[0]
+	if err := cmd.CheckEmpty(args[1:]); err != nil {
+		return errors.Errorf("unknown command line arguments: " + strings.Join(args[1:], ","))
+	}
+	return nil
+}
+
+// Run implements Command.Run.
+func (c *CheckBundlePlansCommand) Run(ctx *cmd.Context) error {
+	data, err := readFile(ctx.AbsPath(c.Bundle))
+	if err != nil {
+		return errors.Annotatef(err, "failed to read bundle %q", c.Bundle)
+	}
+	bundle, err := charm.ReadBundleData(bytes.NewReader(data))
+	if err != nil {
+		return errors.Annotatef(err, "failed to parse bundle %q", c.Bundle)
+	}
+	if len(bundle.Applications) == 0 {
+		return errors.Errorf("bundle %q has no applications", c.Bundle)
+	}
+
+	client, cleanup, err := c.NewClient(ctx)
+	if err != nil {
+		return errors.Annotate(err, "failed to create an http client")
+	}
+	defer cleanup()
+	apiClient, err := newClient(c.ServiceURL, client)
+	if err != nil {
+		return errors.Annotate(err, "failed to create a plan API client")
+	}
+	if r, ok := c.CharmResolver.(*charmStoreResolver); ok {
+		if r.csURL, err = c.charmStoreURL(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	names := make([]string, 0, len(bundle.Applications))
+	for name := range bundle.Applications {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	checks := make([]bundlePlanCheck, len(names))
+	failed := 0
+	for i, name := range names {
+		ctx.Verbosef("checking the plans of application %v", name)
+		checks[i] = c.checkApplication(client, apiClient, name, bundle.Applications[name])
+		if checks[i].Status == checkFail || c.Strict && checks[i].Status == checkWarn {
+			failed++
+		}
+	}
+	if err := c.out.Write(ctx, checks); err != nil {
+		return errors.Trace(err)
+	}
+	if failed > 0 {
+		return errors.Errorf("%d of %d applications failed the plan check", failed, len(checks))
+	}
+	return nil
+}
+
+// checkApplication checks the plan of a bundle application.
+func (c *CheckBundlePlansCommand) checkApplication(client *httpbakery.Client, apiClient api.PlanClient, name string, app *charm.ApplicationSpec) bundlePlanCheck {
+	check := bundlePlanCheck{
+		Application: name,
+		Charm:       app.Charm,
+		Plan:        app.Plan,
+	}
+	result := func(status, format string, args ...interface{}) bundlePlanCheck {
+		check.Status = status
+		check.Message = fmt.Sprintf(format, args...)
+		return check
+	}
+	if app.Charm == "" {
+		return result(checkFail, "no charm specified")
+	}
+	if isLocalCharm(app.Charm) {
+		return result(checkWarn, "local charm not checked")
+	}
+	resolved, err := c.CharmResolver.Resolve(client, app.Charm)
+	if err != nil {
+		return result(checkFail, "cannot resolve charm: %v", err)
+	}
+	check.Charm = resolved
+
+	planURL := app.Plan
+	if planURL != "" {
+		id, err := wireformat.ParsePlanIDWithOptionalRevision(planURL)
+		if err != nil {
+			return result(checkFail, "plan %q not valid", planURL)
+		}
+		plans, err := apiClient.GetPlansForCharm(context.Background(), resolved)
+		if err != nil && !errors.IsNotFound(err) {
+			return result(checkFail, "cannot retrieve plans: %v", err)
+		}
+		if !containsPlan(plans, id.PlanURL.String()) {
+			return result(checkFail, "plan %v is not attached to the charm", id.PlanURL)
+		}
+	} else {
+		plan, err := apiClient.GetDefaultPlan(context.Background(), resolved)
+		if errors.IsNotFound(err) {
+			plans, err := apiClient.GetPlansForCharm(context.Background(), resolved)
+			if err != nil && !errors.IsNotFound(err) {
+				return result(checkFail, "cannot retrieve plans: %v", err)
+			}
+			if len(plans) == 0 {
+				return result(checkWarn, "no plans attached to the charm")
+			}
+			return result(checkFail, "no default plan among %d attached plans", len(plans))
+		} else if err != nil {
+			return result(checkFail, "cannot retrieve default plan: %v", err)
+		}
+		planURL = plan.URL
+		check.Plan = planURL
+	}
+
+	details, err := apiClient.GetPlanDetails(context.Background(), planURL)
+	if errors.IsNotFound(err) {
+		return result(checkFail, "plan %v not found", planURL)
+	} else if err != nil {
+		return result(checkFail, "cannot retrieve plan %v details: %v", planURL, err)
+	}
+	if details.Released == nil {
+		return result(checkFail, "plan %v is not released", planURL)
+	}
+	for _, ch := range details.Charms {
+		if wireformat.CharmBaseURL(ch.CharmURL) == wireformat.CharmBaseURL(resolved) && planSuspended(ch.Events) {
+			return result(checkFail, "plan %v is suspended for the charm", planURL)
+		}
+	}
+	if app.Plan != "" {
+		return result(checkPass, "pinned plan is released")
+	}
+	return result(checkPass, "default plan is released")
+}
+
+// isLocalCharm returns true if the charm of a bundle application is a
+// local charm, which is not known to the plans service.
+func isLocalCharm(charmURL string) bool {
+	return strings.HasPrefix(charmURL, "local:") || strings.HasPrefix(charmURL, ".") || strings.HasPrefix(charmURL, "/")
+}
+
+// containsPlan returns true if one of the plans has the plan url.
+func containsPlan(plans []wireformat.Plan, planURL string) bool {
+	for _, p := range plans {
+		if p.URL == planURL {
+			return true
+		}
+	}
+	return false
+}
+
+// planSuspended returns true if the latest of the suspend and resume
+// events of a charm is a suspension.
+func planSuspended(events []wireformat.Event) bool {
+	var latest *wireformat.Event
+	for i, e := range events {
+		if e.EventType() != wireformat.EventSuspended && e.EventType() != wireformat.EventResumed {
+			continue
+		}
+		if latest == nil || !e.Time.Before(latest.Time) {
+			latest = &events[i]
+		}
+	}
+	return latest != nil && latest.EventType() == wireformat.EventSuspended
+}
+
+// bundlePlanCheck is the output format of the plan check of a bundle
+// application.
+type bundlePlanCheck struct {
+	Application string `json:"application" yaml:"application"`
+	Charm       string `json:"charm" yaml:"charm"`
+	Plan        string `json:"plan,omitempty" yaml:"plan,omitempty"`
+	Status      string `json:"status" yaml:"status"`
+	Message     string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+func formatBundlePlanChecksTabular(w io.Writer, value interface{}) error {
+	checks, ok := value.([]bundlePlanCheck)
+	if !ok {
+		return errors.Errorf("expected value of type %T, got %T", checks, value)
+	}
+	table := uitable.New()
+	table.MaxColWidth = 50
+	table.Wrap = true
+	table.AddRow("APPLICATION", "CHARM", "PLAN", "STATUS", "MESSAGE")
+	for _, check := range checks {
+		table.AddRow(check.Application, check.Charm, check.Plan, check.Status, check.Message)
+	}
+	_, err := w.Write(table.Bytes())
+	if err != nil {
+		return errors.Annotatef(err, "failed to print table")
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd_test
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/juju/cmd/cmdtesting"
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/macaroon-bakery.v2/httpbakery"
+
+	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/api/wireformat"
+	"github.com/juju/plans-client/cmd"
+	plantesting "github.com/juju/plans-client/testing"
+)
+
+const testBundle = `
+applications:
+  db:
+    charm: cs:~testisv/charm1-0
+    num_units: 1
+  web:
+    charm: cs:~testisv/charm2-1
+    plan: testisv/default
+    num_units: 2
+  dev:
+    charm: ./charms/dev
+`
+
+type checkBundlePlansSuite struct {
+	testing.CleanupSuite
+	mockAPI  *plantesting.MockPlanClient
+	resolver *mockCharmResolver
+	bundle   string
+}
+
+var _ = gc.Suite(&checkBundlePlansSuite{})
+
+func (s *checkBundlePlansSuite) SetUpTest(c *gc.C) {
+	s.CleanupSuite.SetUpTest(c)
+	s.PatchEnvironment("GOCOOKIES", filepath.Join(c.MkDir(), "cookies"))
+	s.mockAPI = plantesting.NewMockPlanClient()
+	s.resolver = &mockCharmResolver{Stub: &testing.Stub{}}
+	s.bundle = testBundle
+	s.PatchValue(cmd.NewClient, func(string, *httpbakery.Client) (api.PlanClient, error) {
+		return s.mockAPI, nil
+	})
+	s.PatchValue(cmd.ReadFile, func(string) ([]byte, error) {
+		return []byte(s.bundle), nil
+	})
+}
+
+func (s *checkBundlePlansSuite) run(c *gc.C, args ...string) (string, error) {
+	ctx, err := cmdtesting.RunCommand(c, &cmd.CheckBundlePlansCommand{CharmResolver: s.resolver}, args...)
+	if ctx == nil {
+		return "", err
+	}
+	return cmdtesting.Stdout(ctx), err
+}
+
+func (s *checkBundlePlansSuite) TestInvalidArgs(c *gc.C) {
+	_, err := s.run(c)
+	c.Assert(err, gc.ErrorMatches, "missing bundle")
+	_, err = s.run(c, "bundle.yaml", "extra")
+	c.Assert(err, gc.ErrorMatches, "unknown command line arguments: extra")
+	s.bundle = "applications: [1, 2]"
+	_, err = s.run(c, "bundle.yaml")
+	c.Assert(err, gc.ErrorMatches, `(?s)failed to parse bundle "bundle.yaml": .*`)
+	s.bundle = "series: xenial"
+	_, err = s.run(c, "bundle.yaml")
+	c.Assert(err, gc.ErrorMatches, `bundle "bundle.yaml" has no applications`)
+	s.mockAPI.CheckNoCalls(c)
+}
+
+func (s *checkBundlePlansSuite) TestCheck(c *gc.C) {
+	stdout, err := s.run(c, "bundle.yaml")
+	c.Assert(err, gc.ErrorMatches, "1 of 3 applications failed the plan check")
+	s.resolver.CheckCalls(c, []testing.StubCall{{
+		FuncName: "Resolve",
+		Args:     []interface{}{"cs:~testisv/charm1-0"},
+	}, {
+		FuncName: "Resolve",
+		Args:     []interface{}{"cs:~testisv/charm2-1"},
+	}})
+	s.mockAPI.CheckCallNames(c, "GetDefaultPlan", "GetPlanDetails", "GetPlansForCharm", "GetPlanDetails")
+	s.mockAPI.CheckCall(c, 0, "GetDefaultPlan", "cs:~testisv/charm1-0")
+	s.mockAPI.CheckCall(c, 1, "GetPlanDetails", "testisv/default")
+	s.mockAPI.CheckCall(c, 2, "GetPlansForCharm", "cs:~testisv/charm2-1")
+	s.mockAPI.CheckCall(c, 3, "GetPlanDetails", "testisv/default")
+	c.Assert(stdout, gc.Equals, ""+
+		"APPLICATION\tCHARM               \tPLAN           \tSTATUS\tMESSAGE                                        \n"+
+		"db         \tcs:~testisv/charm1-0\ttestisv/default\tpass  \tdefault plan is released                       \n"+
+		"dev        \t./charms/dev        \t               \twarn  \tlocal charm not checked                        \n"+
+		"web        \tcs:~testisv/charm2-1\ttestisv/default\tfail  \tplan testisv/default is suspended for the charm\n")
+}
+
+func (s *checkBundlePlansSuite) TestFormats(c *gc.C) {
+	s.bundle = `
+services:
+  db:
+    charm: cs:~testisv/charm1-0
+`
+	stdout, err := s.run(c, "bundle.yaml", "--format", "yaml")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(stdout, gc.Equals, `- application: db
+  charm: cs:~testisv/charm1-0
+  plan: testisv/default
+  status: pass
+  message: default plan is released
+`)
+	stdout, err = s.run(c, "bundle.yaml", "--format", "json")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(stdout, gc.Equals, `[{"application":"db","charm":"cs:~testisv/charm1-0","plan":"testisv/default","status":"pass","message":"default plan is released"}]
+`)
+}
+
+func (s *checkBundlePlansSuite) TestApplications(c *gc.C) {
+	unreleased := &wireformat.PlanDetails{
+		Plan:    wireformat.Plan{Id: "testisv/default/1", URL: "testisv/default"},
+		Created: wireformat.Event{User: "jane.jaas", Type: "create", Time: time.Date(2015, 1, 1, 1, 0, 0, 0, time.UTC)},
+	}
+	resumed := &wireformat.PlanDetails{
+		Plan:     wireformat.Plan{Id: "testisv/default/1", URL: "testisv/default"},
+		Created:  wireformat.Event{User: "jane.jaas", Type: "create", Time: time.Date(2015, 1, 1, 1, 0, 0, 0, time.UTC)},
+		Released: &wireformat.Event{User: "jane.jaas", Type: "release", Time: time.Date(2015, 1, 2, 1, 0, 0, 0, time.UTC)},
+		Charms: []wireformat.CharmPlanDetail{{
+			CharmURL: "cs:~testisv/charm1-0",
+			Events: []wireformat.Event{
+				{User: "eve.jaas", Type: "resume", Time: time.Date(2015, 1, 4, 1, 0, 0, 0, time.UTC)},
+				{User: "eve.jaas", Type: "suspend", Time: time.Date(2015, 1, 3, 1, 0, 0, 0, time.UTC)},
+			},
+		}},
+	}
+	suspended := &wireformat.PlanDetails{
+		Plan:     wireformat.Plan{Id: "testisv/default/1", URL: "testisv/default"},
+		Created:  wireformat.Event{User: "jane.jaas", Type: "create", Time: time.Date(2015, 1, 1, 1, 0, 0, 0, time.UTC)},
+		Released: &wireformat.Event{User: "jane.jaas", Type: "release", Time: time.Date(2015, 1, 2, 1, 0, 0, 0, time.UTC)},
+		Charms: []wireformat.CharmPlanDetail{{
+			CharmURL: "cs:~testisv/charm1-5",
+			Events: []wireformat.Event{
+				{User: "eve.jaas", Type: "suspend", Time: time.Date(2015, 1, 3, 1, 0, 0, 0, time.UTC)},
+			},
+		}},
+	}
+	tests := []struct {
+		about       string
+		application string
+		resolveErr  error
+		apiErrors   []error
+		charmPlans  []wireformat.Plan
+		planDetails *wireformat.PlanDetails
+		strict      bool
+		status      string
+		message     string
+	}{{
+		about:       "resumed plan",
+		application: "{charm: cs:~testisv/charm1-0}",
+		planDetails: resumed,
+		status:      "pass",
+		message:     "default plan is released",
+	}, {
+		about:       "plan suspended for another revision of the charm",
+		application: "{charm: cs:~testisv/charm1-0}",
+		planDetails: suspended,
+		status:      "fail",
+		message:     "plan testisv/default is suspended for the charm",
+	}, {
+		about:       "pinned plan",
+		application: "{charm: cs:~testisv/charm1-0, plan: testisv/default/1}",
+		status:      "pass",
+		message:     "pinned plan is released",
+	}, {
+		about:       "unresolved charm",
+		application: "{charm: cs:~testisv/charm1}",
+		resolveErr:  errors.New("charm not found"),
+		status:      "fail",
+		message:     "cannot resolve charm: charm not found",
+	}, {
+		about:       "no charm",
+		application: "{num_units: 1}",
+		status:      "fail",
+		message:     "no charm specified",
+	}, {
+		about:       "unreleased default plan",
+		application: "{charm: cs:~testisv/charm1-0}",
+		planDetails: unreleased,
+		status:      "fail",
+		message:     "plan testisv/default is not released",
+	}, {
+		about:       "no plans",
+		application: "{charm: cs:~testisv/charm1-0}",
+		apiErrors:   []error{errors.NotFoundf("default plan")},
+		charmPlans:  []wireformat.Plan{},
+		status:      "warn",
+		message:     "no plans attached to the charm",
+	}, {
+		about:       "no plans, strict",
+		application: "{charm: cs:~testisv/charm1-0}",
+		apiErrors:   []error{errors.NotFoundf("default plan")},
+		charmPlans:  []wireformat.Plan{},
+		strict:      true,
+		status:      "warn",
+		message:     "no plans attached to the charm",
+	}, {
+		about:       "no default plan",
+		application: "{charm: cs:~testisv/charm1-0}",
+		apiErrors:   []error{errors.NotFoundf("default plan")},
+		status:      "fail",
+		message:     "no default plan among 1 attached plans",
+	}, {
+		about:       "default plan error",
+		application: "{charm: cs:~testisv/charm1-0}",
+		apiErrors:   []error{errors.New("boom")},
+		status:      "fail",
+		message:     "cannot retrieve default plan: boom",
+	}, {
+		about:       "pinned plan not attached",
+		application: "{charm: cs:~testisv/charm1-0, plan: testisv/premium}",
+		status:      "fail",
+		message:     "plan testisv/premium is not attached to the charm",
+	}, {
+		about:       "pinned plan not found",
+		application: "{charm: cs:~testisv/charm1-0, plan: testisv/default}",
+		apiErrors:   []error{nil, errors.NotFoundf("plan")},
+		status:      "fail",
+		message:     "plan testisv/default not found",
+	}, {
+		about:       "invalid pinned plan",
+		application: "{charm: cs:~testisv/charm1-0, plan: default}",
+		status:      "fail",
+		message:     `plan \"default\" not valid`,
+	}}
+	for i, t := range tests {
+		c.Logf("running test %d: %s", i, t.about)
+		s.mockAPI = plantesting.NewMockPlanClient()
+		s.mockAPI.SetErrors(t.apiErrors...)
+		s.mockAPI.CharmPlans = t.charmPlans
+		s.mockAPI.PlanDetails = t.planDetails
+		s.resolver = &mockCharmResolver{Stub: &testing.Stub{}}
+		s.resolver.SetErrors(t.resolveErr)
+		s.bundle = "applications:\n  app: " + t.application + "\n"
+		args := []string{"bundle.yaml", "--format", "json"}
+		if t.strict {
+			args = append(args, "--strict")
+		}
+		stdout, err := s.run(c, args...)
+		if t.status == "fail" || t.strict {
+			c.Assert(err, gc.ErrorMatches, "1 of 1 applications failed the plan check")
+		} else {
+			c.Assert(err, jc.ErrorIsNil)
+		}
+		c.Assert(stdout, jc.Contains, `"status":"`+t.status+`","message":"`+t.message+`"`)
+	}
+}
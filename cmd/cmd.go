@@ -4,22 +4,30 @@
 package cmd
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
+	"time"
 
 	"github.com/canonical/candid/candidclient/ussologin"
 	"github.com/juju/cmd"
+	"github.com/juju/errors"
 	"github.com/juju/gnuflag"
 	"github.com/juju/juju/juju/osenv"
 	cookiejar "github.com/juju/persistent-cookiejar"
+	"github.com/juju/utils"
 	"golang.org/x/net/publicsuffix"
 	"gopkg.in/juju/environschema.v1/form"
 	"gopkg.in/macaroon-bakery.v2/httpbakery"
+
+	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/api/wireformat"
 )
 
 var (
 	defaultURL = "https://api.jujucharms.com/omnibus"
 	readFile   = ioutil.ReadFile
+	now        = time.Now
 )
 
 // defaultServiceURL returns the default public URL for plans clients.
@@ -39,34 +47,58 @@ type baseCommand struct {
 	// NoBrowser specifies that web-browser-based auth should
 	// not be used when authenticating.
 	NoBrowser bool
+
+	// AgentFile holds the path to the agent file used to
+	// authenticate without user interaction.
+	AgentFile string
+
+	// MacaroonFile holds the path to a file of pre-discharged
+	// macaroons sent with the requests to the plans service.
+	MacaroonFile string
+
+	// Profile holds the name of the configuration profile
+	// providing the defaults for the command.
+	Profile string
+
+	// flags holds the flags whose profile defaults are
+	// overridden when they are set on the command line.
+	flags   map[string]*explicitValue
+	profile *profile
+
+	// referenceURL holds the url of the plans service named by a
+	// qualified plan reference, which overrides the service url.
+	referenceURL string
 }
 
 // NewClient returns a new http bakery client for Omnibus commands.
 func (s *baseCommand) NewClient(ctx *cmd.Context) (*httpbakery.Client, func(), error) {
-	jar, err := cookiejar.New(&cookiejar.Options{
-		PublicSuffixList: publicsuffix.List,
-		Filename:         cookiejar.DefaultCookieFile(),
-	})
+	jar, err := s.cookieJar()
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, errors.Trace(err)
 	}
 	bakeryClient := httpbakery.NewClient()
 	bakeryClient.Jar = jar
 
-	if s.NoBrowser {
-		tokenStore := ussologin.NewFileTokenStore(ussoTokenPath())
-		bakeryClient.AddInteractor(ussologin.NewInteractor(ussologin.StoreTokenGetter{
-			Store: tokenStore,
-			TokenGetter: ussologin.FormTokenGetter{
-				Filler: &form.IOFiller{
-					In:  os.Stdin,
-					Out: os.Stdout,
+	nonInteractive, err := s.addNonInteractiveAuth(bakeryClient)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	if !nonInteractive {
+		if s.NoBrowser {
+			tokenStore := ussologin.NewFileTokenStore(ussoTokenPath())
+			bakeryClient.AddInteractor(ussologin.NewInteractor(ussologin.StoreTokenGetter{
+				Store: tokenStore,
+				TokenGetter: ussologin.FormTokenGetter{
+					Filler: &form.IOFiller{
+						In:  os.Stdin,
+						Out: os.Stdout,
+					},
+					Name: "charm",
 				},
-				Name: "charm",
-			},
-		}))
+			}))
+		}
+		bakeryClient.AddInteractor(httpbakery.WebBrowserInteractor{})
 	}
-	bakeryClient.AddInteractor(httpbakery.WebBrowserInteractor{})
 
 	return bakeryClient, func() {
 		err := jar.Save()
@@ -76,6 +108,26 @@ func (s *baseCommand) NewClient(ctx *cmd.Context) (*httpbakery.Client, func(), e
 	}, nil
 }
 
+// cookieJar opens the persistent cookie jar holding the macaroons
+// used to authenticate with the plans service.
+func (s *baseCommand) cookieJar() (*cookiejar.Jar, error) {
+	if err := s.applyProfile(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	cookieFile, err := s.cookieFile()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	jar, err := cookiejar.New(&cookiejar.Options{
+		PublicSuffixList: publicsuffix.List,
+		Filename:         cookieFile,
+	})
+	if err != nil {
+		return nil, errors.Annotate(err, "failed to open cookie jar")
+	}
+	return jar, nil
+}
+
 // Close saves the persistent cookie jar used by the specified httpbakery.Client.
 func (s *baseCommand) Close() error {
 	return nil
@@ -91,14 +143,221 @@ func newBaseCommand() *baseCommand {
 
 // SetFlag implements the Command interface.
 func (c *baseCommand) SetFlags(f *gnuflag.FlagSet) {
-	f.BoolVar(&c.NoBrowser, "B", false, "Do not use web browser for authentication")
-	f.BoolVar(&c.NoBrowser, "no-browser-login", false, "")
 	if c.ServiceURL == "" {
 		c.ServiceURL = defaultServiceURL()
 	}
-	f.StringVar(&c.ServiceURL, "url", c.ServiceURL, "host and port of the plans services")
+	c.trackFlags(f, func(f *gnuflag.FlagSet) {
+		f.BoolVar(&c.NoBrowser, "B", false, "Do not use web browser for authentication")
+		f.BoolVar(&c.NoBrowser, "no-browser-login", false, "")
+		f.StringVar(&c.ServiceURL, "url", c.ServiceURL, "host and port of the plans services")
+		f.StringVar(&c.AgentFile, "agent-file", os.Getenv(agentFileEnvVar), "path to the agent file used for non-interactive authentication")
+	})
+	f.StringVar(&c.MacaroonFile, "macaroon-file", os.Getenv(macaroonFileEnvVar), "path to a file of pre-discharged macaroons")
+	f.StringVar(&c.Profile, "profile", os.Getenv("PLANS_PROFILE"), "name of the configuration profile to use")
+}
+
+// addOutputFlags adds the output flags to the flag set. A format
+// specified on the command line takes precedence over the format
+// set in the configuration profile.
+func (c *baseCommand) addOutputFlags(f *gnuflag.FlagSet, out *cmd.Output, defaultFormatter string, formatters map[string]cmd.Formatter) {
+	c.trackFlags(f, func(f *gnuflag.FlagSet) {
+		out.AddFlags(f, defaultFormatter, formatters)
+	})
+}
+
+// trackFlags adds the flags defined by add to the flag set, recording
+// whether they are set on the command line.
+func (c *baseCommand) trackFlags(f *gnuflag.FlagSet, add func(*gnuflag.FlagSet)) {
+	if c.flags == nil {
+		c.flags = make(map[string]*explicitValue)
+	}
+	own := gnuflag.NewFlagSet("", gnuflag.ContinueOnError)
+	add(own)
+	own.VisitAll(func(flag *gnuflag.Flag) {
+		v := &explicitValue{Value: flag.Value}
+		c.flags[flag.Name] = v
+		f.Var(v, flag.Name, flag.Usage)
+	})
+}
+
+// isSet returns true if any of the named flags was set on the
+// command line.
+func (c *baseCommand) isSet(names ...string) bool {
+	for _, name := range names {
+		if v, ok := c.flags[name]; ok && v.set {
+			return true
+		}
+	}
+	return false
+}
+
+// loadProfile returns the selected configuration profile. If no profile
+// is selected, an empty profile is returned.
+func (c *baseCommand) loadProfile() (*profile, error) {
+	if c.profile != nil {
+		return c.profile, nil
+	}
+	config, err := readClientConfig(configFilePath())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	p, err := config.selectProfile(c.Profile)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	c.profile = p
+	return p, nil
+}
+
+// applyProfile sets the values of the selected profile, unless they
+// have been specified on the command line. Environment variables take
+// precedence over the current profile, but not over a profile selected
+// by name.
+func (c *baseCommand) applyProfile() error {
+	p, err := c.loadProfile()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if p.PlansURL != "" && !c.isSet("url") && !c.fromEnvironment("JUJU_PLANS") {
+		c.ServiceURL = p.PlansURL
+	}
+	if p.Auth == authNoBrowser && !c.isSet("B", "no-browser-login") {
+		c.NoBrowser = true
+	}
+	if p.AgentFile != "" && !c.isSet("agent-file") && !c.fromEnvironment(agentFileEnvVar) {
+		c.AgentFile = p.AgentFile
+	}
+	if format, ok := c.flags["format"]; ok && p.Format != "" && !format.set {
+		// The profile format is validated when the configuration is
+		// read, so an error only means that the command does not
+		// support it: the profile format only applies to the commands
+		// that do.
+		_ = format.Value.Set(p.Format)
+	}
+	if c.referenceURL != "" {
+		c.ServiceURL = c.referenceURL
+	}
+	return nil
+}
+
+// unqualifyPlan returns the plan part of a plan reference. The url of the
+// plans service named by a qualified reference overrides the service url
+// of the command, including one specified with --url.
+func (c *baseCommand) unqualifyPlan(ref string) (string, error) {
+	serviceURL, plan, err := wireformat.SplitPlanReference(ref)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if serviceURL != "" {
+		c.referenceURL = serviceURL
+	}
+	return plan, nil
+}
+
+// fromEnvironment returns true if the value of the environment variable
+// takes precedence over the selected profile: the variable is set and the
+// profile is the current profile rather than one selected by name.
+func (c *baseCommand) fromEnvironment(name string) bool {
+	return c.Profile == "" && os.Getenv(name) != ""
+}
+
+// cookieFile returns the path to the persistent cookie jar.
+func (c *baseCommand) cookieFile() (string, error) {
+	p, err := c.loadProfile()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if p.CookieJar == "" {
+		return cookiejar.DefaultCookieFile(), nil
+	}
+	path, err := utils.NormalizePath(p.CookieJar)
+	if err != nil {
+		return "", errors.Annotate(err, "invalid cookie jar path")
+	}
+	return path, nil
+}
+
+// charmStoreURL returns the charm store URL from the CSURL environment
+// variable, the selected profile or the default, in that order. A profile
+// selected by name takes precedence over the environment variable.
+func (c *baseCommand) charmStoreURL() (string, error) {
+	p, err := c.loadProfile()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if p.CharmStoreURL != "" && !c.fromEnvironment("CSURL") {
+		return p.CharmStoreURL, nil
+	}
+	if csURL := os.Getenv("CSURL"); csURL != "" {
+		return csURL, nil
+	}
+	return defaultCharmStoreURL, nil
+}
+
+// explicitValue wraps a flag value, recording whether it was set.
+type explicitValue struct {
+	gnuflag.Value
+	set bool
+}
+
+// Set implements gnuflag.Value.
+func (v *explicitValue) Set(s string) error {
+	v.set = true
+	return v.Value.Set(s)
+}
+
+// IsBoolFlag reports whether the wrapped flag may be given without a value.
+func (v *explicitValue) IsBoolFlag() bool {
+	b, ok := v.Value.(interface {
+		IsBoolFlag() bool
+	})
+	return ok && b.IsBoolFlag()
+}
+
+// statementPeriodValue is a flag value holding a statement period,
+// specified in the YYYY-MM format or relative to the current month.
+type statementPeriodValue struct {
+	period *string
+}
+
+// statementPeriodUsage describes the accepted statement periods.
+const statementPeriodUsage = "(YYYY-MM, " + wireformat.ThisMonth + " or " + wireformat.LastMonth + ")"
+
+// Set implements gnuflag.Value.
+func (v statementPeriodValue) Set(s string) error {
+	p, err := wireformat.ResolveStatementPeriod(s, now())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	*v.period = p.String()
+	return nil
+}
+
+// String implements gnuflag.Value.
+func (v statementPeriodValue) String() string {
+	if v.period == nil {
+		return ""
+	}
+	return *v.period
+}
+
+// resolvePlanRevision resolves a plan selector that must match a single
+// revision of the plan into the id of the revision. Plan urls and plan ids
+// are returned as specified, without querying the plans service.
+func resolvePlanRevision(client api.PlanClient, sel *wireformat.PlanSelector, arg string) (string, error) {
+	if sel.Concrete() {
+		return arg, nil
+	}
+	ids, err := api.ResolvePlanSelector(context.Background(), client, *sel)
+	if err != nil {
+		return "", errors.Annotatef(err, "failed to resolve plan %v", arg)
+	}
+	if len(ids) != 1 {
+		return "", errors.Errorf("plan %v matches %d revisions, expected one", arg, len(ids))
+	}
+	return ids[0].String(), nil
 }
 
-func ussoTokenPath() string {
+var ussoTokenPath = func() string {
 	return osenv.JujuXDGDataHomePath("store-usso-token")
 }
@@ -4,7 +4,19 @@
 package cmd_test
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+
 	gc "gopkg.in/check.v1"
+	"gopkg.in/macaroon-bakery.v2/bakery"
+	"gopkg.in/macaroon-bakery.v2/httpbakery"
+	"gopkg.in/macaroon-bakery.v2/httpbakery/agent"
+	"gopkg.in/macaroon.v2"
 
 	jujucmd "github.com/juju/cmd"
 	"github.com/juju/cmd/cmdtesting"
@@ -22,6 +34,11 @@ type baseCommandSuite struct {
 
 var _ = gc.Suite(&baseCommandSuite{})
 
+func (s *baseCommandSuite) SetUpTest(c *gc.C) {
+	s.CleanupSuite.SetUpTest(c)
+	s.PatchEnvironment("GOCOOKIES", filepath.Join(c.MkDir(), "cookies"))
+}
+
 func newTestCommand() *testCommand {
 	return &testCommand{cmd.NewBaseCommand()}
 }
@@ -80,3 +97,118 @@ func (s *baseCommandSuite) TestNewClientNoHttps(c *gc.C) {
 	defer cleanup()
 	c.Assert(client.Transport, gc.IsNil)
 }
+
+func (s *baseCommandSuite) TestNewClientAgentFile(c *gc.C) {
+	key, err := bakery.GenerateKey()
+	c.Assert(err, jc.ErrorIsNil)
+	data, err := json.Marshal(agent.AuthInfo{
+		Key: key,
+		Agents: []agent.Agent{{
+			URL:      "https://candid.example.com",
+			Username: "ci@candid",
+		}},
+	})
+	c.Assert(err, jc.ErrorIsNil)
+	agentFile := filepath.Join(c.MkDir(), "agent.json")
+	err = ioutil.WriteFile(agentFile, data, 0600)
+	c.Assert(err, jc.ErrorIsNil)
+	s.PatchEnvironment("BAKERY_AGENT_FILE", agentFile)
+
+	basecmd := newTestCommand()
+	_, err = cmdtesting.RunCommand(c, basecmd, "--url", "https://plans.example.com")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(basecmd.AgentFile, gc.Equals, agentFile)
+
+	client, _, err := basecmd.NewClient(cmdtesting.Context(c))
+	c.Assert(err, jc.ErrorIsNil)
+	kinds := make([]string, len(client.InteractionMethods))
+	for i, m := range client.InteractionMethods {
+		kinds[i] = m.Kind()
+	}
+	c.Assert(kinds, jc.DeepEquals, []string{"agent", "browser-window", "usso_oauth"})
+	_, err = client.InteractionMethods[1].Interact(context.Background(), client, "https://candid.example.com", nil)
+	c.Assert(err, gc.ErrorMatches, `discharge from https://candid.example.com requires interactive login, which is disabled when authenticating as agent ci@candid`)
+}
+
+func (s *baseCommandSuite) TestNewClientInvalidAgentFile(c *gc.C) {
+	dir := c.MkDir()
+	tests := []struct {
+		about string
+		data  string
+		err   string
+	}{{
+		about: "not json",
+		data:  "agent",
+		err:   `failed to parse agent file .*`,
+	}, {
+		about: "no key",
+		data:  `{"agents": [{"url": "https://candid.example.com", "username": "ci"}]}`,
+		err:   `agent file .* with no key not valid`,
+	}, {
+		about: "no agents",
+		data:  `{"key": {"public": "Ba7wAI1M0mK0bAyzlmMI8gEUuCx8lwk8Sb9xHPFGNHQ=", "private": "Y9+8A5LBpmsSswlVbnS0C1LqGv3S/l/aaaN4BuPX5Ss="}}`,
+		err:   `agent file .* with no agents not valid`,
+	}}
+	for i, t := range tests {
+		c.Logf("running test %d: %s", i, t.about)
+		agentFile := filepath.Join(dir, fmt.Sprintf("agent-%d.json", i))
+		err := ioutil.WriteFile(agentFile, []byte(t.data), 0600)
+		c.Assert(err, jc.ErrorIsNil)
+		basecmd := newTestCommand()
+		_, err = cmdtesting.RunCommand(c, basecmd, "--agent-file", agentFile)
+		c.Assert(err, jc.ErrorIsNil)
+		_, _, err = basecmd.NewClient(cmdtesting.Context(c))
+		c.Assert(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *baseCommandSuite) TestNewClientMacaroonFile(c *gc.C) {
+	m, err := macaroon.New([]byte("root-key"), []byte("id"), "plans", macaroon.LatestVersion)
+	c.Assert(err, jc.ErrorIsNil)
+	data, err := json.Marshal(macaroon.Slice{m})
+	c.Assert(err, jc.ErrorIsNil)
+
+	dir := c.MkDir()
+	jsonFile := filepath.Join(dir, "macaroons.json")
+	err = ioutil.WriteFile(jsonFile, data, 0600)
+	c.Assert(err, jc.ErrorIsNil)
+	base64File := filepath.Join(dir, "macaroons.txt")
+	err = ioutil.WriteFile(base64File, []byte(base64.StdEncoding.EncodeToString(data)), 0600)
+	c.Assert(err, jc.ErrorIsNil)
+
+	for _, path := range []string{jsonFile, base64File} {
+		c.Logf("macaroon file %s", path)
+		basecmd := newTestCommand()
+		_, err = cmdtesting.RunCommand(c, basecmd, "--url", "https://plans.example.com/v1", "--macaroon-file", path)
+		c.Assert(err, jc.ErrorIsNil)
+
+		client, _, err := basecmd.NewClient(cmdtesting.Context(c))
+		c.Assert(err, jc.ErrorIsNil)
+		u, err := url.Parse("https://plans.example.com/v1/plan")
+		c.Assert(err, jc.ErrorIsNil)
+		ms := httpbakery.MacaroonsForURL(client.Jar, u)
+		c.Assert(ms, gc.HasLen, 1)
+		c.Assert(ms[0][0].Id(), gc.DeepEquals, []byte("id"))
+		_, err = client.InteractionMethods[0].Interact(context.Background(), client, "https://candid.example.com", nil)
+		c.Assert(err, gc.ErrorMatches, `discharge from https://candid.example.com requires interactive login, which is disabled when using pre-discharged macaroons`)
+	}
+}
+
+func (s *baseCommandSuite) TestNewClientExpiredMacaroon(c *gc.C) {
+	m, err := macaroon.New([]byte("root-key"), []byte("id"), "plans", macaroon.LatestVersion)
+	c.Assert(err, jc.ErrorIsNil)
+	err = m.AddFirstPartyCaveat([]byte("time-before 2017-01-01T00:00:00Z"))
+	c.Assert(err, jc.ErrorIsNil)
+	data, err := json.Marshal([]macaroon.Slice{{m}})
+	c.Assert(err, jc.ErrorIsNil)
+	path := filepath.Join(c.MkDir(), "macaroons.json")
+	err = ioutil.WriteFile(path, data, 0600)
+	c.Assert(err, jc.ErrorIsNil)
+	s.PatchEnvironment("PLANS_MACAROON_FILE", path)
+
+	basecmd := newTestCommand()
+	_, err = cmdtesting.RunCommand(c, basecmd)
+	c.Assert(err, jc.ErrorIsNil)
+	_, _, err = basecmd.NewClient(cmdtesting.Context(c))
+	c.Assert(err, gc.ErrorMatches, `macaroon [0-9a-f]+ expired at 2017-01-01T00:00:00Z`)
+}
@@ -0,0 +1,573 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+	cookiejar "github.com/juju/persistent-cookiejar"
+	"golang.org/x/net/publicsuffix"
+	"gopkg.in/macaroon-bakery.v2/httpbakery"
+
+	"github.com/juju/plans-client/api"
+)
+
+const completionDoc = `
+completion prints a script that enables tab completion of commands, flags,
+plan owners, plan urls and plan revisions in the specified shell.
+
+Plans and revisions are looked up in the plans service and kept in a local
+cache for a few minutes, so that completion remains fast and also works
+when the plans service cannot be reached.
+
+Examples
+completion bash > /etc/bash_completion.d/charm-plans
+	installs completion for bash
+completion zsh > "${fpath[1]}/_charm-plans"
+	installs completion for zsh
+completion fish > ~/.config/fish/completions/charm-plans.fish
+	installs completion for fish
+`
+
+const completionPurpose = "print a shell completion script"
+
+// completeArg is the argument that makes the completion command print
+// completion candidates instead of a script.
+const completeArg = "__complete"
+
+var (
+	completionCacheTTL      = 5 * time.Minute
+	completionLookupTimeout = 3 * time.Second
+	completionCachePath     = func() string {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			dir = os.TempDir()
+		}
+		return filepath.Join(dir, "charm-plans", "completion.json")
+	}
+)
+
+var completionScripts = map[string]*template.Template{
+	"bash": template.Must(template.New("bash").Parse(`# bash completion for {{.}}
+_{{.Func}}() {
+    local IFS=$'\n'
+    COMPREPLY=( $({{.}} completion __complete "${COMP_WORDS[@]:1:$COMP_CWORD}" 2>/dev/null) )
+    if [[ ${#COMPREPLY[@]} -eq 1 && "${COMPREPLY[0]}" == */ ]]; then
+        compopt -o nospace
+    fi
+}
+complete -o default -F _{{.Func}} {{.}}
+`)),
+	"zsh": template.Must(template.New("zsh").Parse(`#compdef {{.}}
+_{{.Func}}() {
+    local -a candidates
+    candidates=(${(f)"$({{.}} completion __complete "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+    if (( ${#candidates} == 1 )) && [[ "${candidates[1]}" == */ ]]; then
+        compadd -Q -S '' -- "${candidates[@]}"
+    elif (( ${#candidates} > 0 )); then
+        compadd -Q -- "${candidates[@]}"
+    else
+        _files
+    fi
+}
+compdef _{{.Func}} {{.}}
+`)),
+	"fish": template.Must(template.New("fish").Parse(`# fish completion for {{.}}
+function __{{.Func}}_complete
+    set -l words (commandline -opc) (commandline -ct)
+    {{.}} completion __complete $words[2..-1] 2>/dev/null
+end
+complete -c {{.}} -f -a '(__{{.Func}}_complete)'
+`)),
+}
+
+// completionName is the name of the command being completed, as used
+// by the completion script templates.
+type completionName string
+
+// Func returns the name in a form usable as a shell function name.
+func (n completionName) Func() string {
+	return strings.Replace(string(n), "-", "_", -1)
+}
+
+// argKind defines the kind of value expected by a positional argument.
+type argKind int
+
+const (
+	argAny argKind = iota
+	argOwner
+	argPlanURL
+	argPlanID
+	argProfile
+)
+
+// completionArgs defines the kinds of the positional arguments of each
+// command. Arguments not listed here are completed by the shell.
+var completionArgs = map[string][]argKind{
+	"attach-plan":           {argAny, argPlanURL},
+	"authorize-plan":        {argAny, argPlanURL},
+	"authorize-reseller":    {argPlanURL},
+	"config":                {argProfile},
+	"list-plans":            {argOwner},
+	"plan-history":          {argPlanURL},
+	"push-plan":             {argAny, argPlanURL},
+	"release-plans":         {argPlanID},
+	"report-authorizations": {argOwner},
+	"resume-plan":           {argPlanURL},
+	"show-plan":             {argPlanID},
+	"show-plan-revisions":   {argPlanURL},
+	"suspend-plan":          {argPlanURL},
+}
+
+// NewCompletionCommand returns a command that prints completion scripts
+// for the named super command.
+func NewCompletionCommand(name string) cmd.Command {
+	return &CompletionCommand{
+		name: name,
+	}
+}
+
+// CompletionCommand prints shell completion scripts and completion
+// candidates.
+type CompletionCommand struct {
+	baseCommand
+
+	name  string
+	Shell string
+	Words []string
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *CompletionCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.baseCommand.ServiceURL = defaultServiceURL()
+	c.baseCommand.SetFlags(f)
+}
+
+// AllowInterspersedFlags implements Command.AllowInterspersedFlags. The
+// words being completed are passed on as arguments, even if they look
+// like flags.
+func (c *CompletionCommand) AllowInterspersedFlags() bool {
+	return false
+}
+
+// Info implements Command.Info.
+func (c *CompletionCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "completion",
+		Args:    "bash|zsh|fish",
+		Purpose: completionPurpose,
+		Doc:     completionDoc,
+	}
+}
+
+// Init implements Command.Init.
+func (c *CompletionCommand) Init(args []string) error {
+	if len(args) < 1 {
+		return errors.New("missing shell")
+	}
+	if args[0] == completeArg {
+		c.Words = args[1:]
+		if len(c.Words) == 0 {
+			c.Words = []string{""}
+		}
+		return nil
+	}
+	if _, ok := completionScripts[args[0]]; !ok {
+		return errors.Errorf("unsupported shell %q", args[0])
+	}
+	c.Shell = args[0]
+	if err := cmd.CheckEmpty(args[1:]); err != nil {
+		return errors.Errorf("unknown command line arguments: " + strings.Join(args[1:], ","))
+	}
+	return nil
+}
+
+// Run implements Command.Run.
+func (c *CompletionCommand) Run(ctx *cmd.Context) error {
+	if c.Shell != "" {
+		return errors.Trace(completionScripts[c.Shell].Execute(ctx.Stdout, completionName(c.name)))
+	}
+	for _, candidate := range c.complete(c.Words) {
+		fmt.Fprintln(ctx.Stdout, candidate)
+	}
+	return nil
+}
+
+// complete returns the completion candidates for the last of the words,
+// which follow the name of the super command.
+func (c *CompletionCommand) complete(words []string) []string {
+	current, words := words[len(words)-1], words[:len(words)-1]
+	global := completionFlags(nil)
+
+	// Skip the global flags preceding the command name.
+	i := c.skipFlags(global, words)
+	if i == len(words) {
+		if strings.HasPrefix(current, "-") {
+			return matchFlags(global, current)
+		}
+		if value, ok := flagValue(global, words); ok {
+			return value
+		}
+		return matchPrefix(commandNames(), current)
+	}
+	name, args := words[i], words[i+1:]
+	if alias, ok := commandAliases[name]; ok {
+		name = alias
+	}
+	command := commandByName(name)
+	if command == nil {
+		return nil
+	}
+	flags := completionFlags(command)
+	if strings.HasPrefix(current, "-") {
+		return matchFlags(flags, current)
+	}
+	if value, ok := flagValue(flags, args); ok {
+		return value
+	}
+
+	var positional int
+	for j := 0; j < len(args); j++ {
+		j += c.skipFlags(flags, args[j:])
+		if j < len(args) {
+			positional++
+		}
+	}
+	kinds := completionArgs[name]
+	if positional >= len(kinds) {
+		return nil
+	}
+	return c.completeValue(kinds[positional], current)
+}
+
+// skipFlags applies the service url flag, if any, and returns the index of
+// the first word that is not a flag or a flag value.
+func (c *CompletionCommand) skipFlags(flags map[string]*gnuflag.Flag, words []string) int {
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		if !strings.HasPrefix(word, "-") {
+			return i
+		}
+		f := lookupFlag(flags, word)
+		if f == nil || isBoolFlag(f) {
+			continue
+		}
+		value := ""
+		if parts := strings.SplitN(word, "=", 2); len(parts) == 2 {
+			value = parts[1]
+		} else if i+1 < len(words) {
+			i++
+			value = words[i]
+		}
+		if f.Name == "url" && value != "" {
+			c.ServiceURL = value
+		}
+	}
+	return len(words)
+}
+
+// completeValue returns the candidates of the specified kind that start
+// with prefix.
+func (c *CompletionCommand) completeValue(kind argKind, prefix string) []string {
+	cache := loadCompletionCache(completionCachePath())
+	defer cache.save()
+
+	config, err := readClientConfig(configFilePath())
+	if err != nil {
+		config = &clientConfig{}
+	}
+	switch kind {
+	case argProfile:
+		names := []string{}
+		for name := range config.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return matchPrefix(names, prefix)
+	case argOwner:
+		return matchPrefix(completionOwners(config, cache), prefix)
+	case argPlanURL, argPlanID:
+		parts := strings.Split(prefix, "/")
+		switch len(parts) {
+		case 1:
+			owners := completionOwners(config, cache)
+			for i, owner := range owners {
+				owners[i] = owner + "/"
+			}
+			return matchPrefix(owners, prefix)
+		case 2:
+			plans := c.lookup(cache, "plans:"+parts[0], func(client api.PlanClient, ctx context.Context) ([]string, error) {
+				plans, err := client.GetPlans(ctx, parts[0])
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+				seen := make(map[string]bool)
+				urls := []string{}
+				for _, plan := range plans {
+					if !seen[plan.URL] {
+						seen[plan.URL] = true
+						urls = append(urls, plan.URL)
+					}
+				}
+				sort.Strings(urls)
+				return urls, nil
+			})
+			return matchPrefix(plans, prefix)
+		case 3:
+			if kind != argPlanID {
+				return nil
+			}
+			planURL := parts[0] + "/" + parts[1]
+			revisions := c.lookup(cache, "revisions:"+planURL, func(client api.PlanClient, ctx context.Context) ([]string, error) {
+				plans, err := client.GetPlanRevisions(ctx, planURL)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+				ids := make([]string, len(plans))
+				for i, plan := range plans {
+					ids[i] = plan.Id
+				}
+				return ids, nil
+			})
+			return matchPrefix(revisions, prefix)
+		}
+	}
+	return nil
+}
+
+// lookup returns the cached values for the key. Stale or missing values are
+// fetched from the plans service; if that fails, stale values are used.
+func (c *CompletionCommand) lookup(cache *completionCache, key string, fetch func(api.PlanClient, context.Context) ([]string, error)) []string {
+	entry, ok := cache.Entries[key]
+	if ok && time.Since(entry.Time) < completionCacheTTL {
+		return entry.Values
+	}
+	values, err := c.fetch(fetch)
+	if err != nil {
+		return entry.Values
+	}
+	cache.set(key, values)
+	return values
+}
+
+func (c *CompletionCommand) fetch(fetch func(api.PlanClient, context.Context) ([]string, error)) ([]string, error) {
+	if err := c.applyProfile(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	cookieFile, err := c.cookieFile()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	jar, err := cookiejar.New(&cookiejar.Options{
+		PublicSuffixList: publicsuffix.List,
+		Filename:         cookieFile,
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// The bakery client has no interactors: completion must never
+	// prompt for or open a browser to log in.
+	bakeryClient := httpbakery.NewClient()
+	bakeryClient.Jar = jar
+	apiClient, err := newClient(c.ServiceURL, bakeryClient)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), completionLookupTimeout)
+	defer cancel()
+	return fetch(apiClient, ctx)
+}
+
+// completionCache stores completion candidates retrieved from the plans
+// service.
+type completionCache struct {
+	path    string
+	changed bool
+
+	Entries map[string]completionCacheEntry `json:"entries"`
+}
+
+type completionCacheEntry struct {
+	Time   time.Time `json:"time"`
+	Values []string  `json:"values"`
+}
+
+// loadCompletionCache reads the cache stored at path. A missing or
+// unreadable cache results in an empty cache.
+func loadCompletionCache(path string) *completionCache {
+	cache := &completionCache{path: path}
+	if data, err := ioutil.ReadFile(path); err == nil {
+		json.Unmarshal(data, cache)
+	}
+	if cache.Entries == nil {
+		cache.Entries = make(map[string]completionCacheEntry)
+	}
+	return cache
+}
+
+func (c *completionCache) set(key string, values []string) {
+	c.Entries[key] = completionCacheEntry{
+		Time:   time.Now().UTC(),
+		Values: values,
+	}
+	c.changed = true
+}
+
+// owners returns the owners of the plans recorded in the cache.
+func (c *completionCache) owners() []string {
+	owners := []string{}
+	for key := range c.Entries {
+		if strings.HasPrefix(key, "plans:") {
+			owners = append(owners, strings.TrimPrefix(key, "plans:"))
+		}
+	}
+	sort.Strings(owners)
+	return owners
+}
+
+// save writes the cache if it has changed. Failures are ignored, as the
+// cache is only an optimization.
+func (c *completionCache) save() {
+	if !c.changed {
+		return
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return
+	}
+	ioutil.WriteFile(c.path, data, 0600)
+}
+
+// completionOwners returns the default owners of the configuration
+// profiles and the owners of plans recorded in the cache.
+func completionOwners(config *clientConfig, cache *completionCache) []string {
+	seen := make(map[string]bool)
+	owners := []string{}
+	for _, owner := range append(config.owners(), cache.owners()...) {
+		if !seen[owner] {
+			seen[owner] = true
+			owners = append(owners, owner)
+		}
+	}
+	sort.Strings(owners)
+	return owners
+}
+
+// commandNames returns the names of all commands and aliases of the
+// charm-plans super command.
+func commandNames() []string {
+	names := []string{"completion", "help"}
+	for _, c := range planCommands() {
+		names = append(names, c.Info().Name)
+	}
+	for alias := range commandAliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func commandByName(name string) cmd.Command {
+	if name == "completion" {
+		return NewCompletionCommand("")
+	}
+	for _, c := range planCommands() {
+		if c.Info().Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// completionFlags returns the flags of the command, including the global
+// flags. If the command is nil, only the global flags are returned.
+func completionFlags(command cmd.Command) map[string]*gnuflag.Flag {
+	f := gnuflag.NewFlagSet("", gnuflag.ContinueOnError)
+	(&cmd.Log{}).AddFlags(f)
+	f.Bool("h", false, "")
+	f.Bool("help", false, "")
+	flags := make(map[string]*gnuflag.Flag)
+	if command != nil {
+		command.SetFlags(f)
+	} else {
+		newGlobalFlags().AddFlags(f)
+	}
+	f.VisitAll(func(flag *gnuflag.Flag) {
+		flags[flag.Name] = flag
+	})
+	return flags
+}
+
+func lookupFlag(flags map[string]*gnuflag.Flag, word string) *gnuflag.Flag {
+	name := strings.TrimLeft(word, "-")
+	if i := strings.Index(name, "="); i >= 0 {
+		name = name[:i]
+	}
+	return flags[name]
+}
+
+func isBoolFlag(flag *gnuflag.Flag) bool {
+	b, ok := flag.Value.(interface {
+		IsBoolFlag() bool
+	})
+	return ok && b.IsBoolFlag()
+}
+
+// flagValue returns the candidate values when the last word is a flag
+// that expects a value.
+func flagValue(flags map[string]*gnuflag.Flag, words []string) ([]string, bool) {
+	if len(words) == 0 || !strings.HasPrefix(words[len(words)-1], "-") {
+		return nil, false
+	}
+	last := words[len(words)-1]
+	f := lookupFlag(flags, last)
+	if f == nil || isBoolFlag(f) || strings.Contains(last, "=") {
+		return nil, false
+	}
+	// Formatter flags list their choices in the usage: "... (json|yaml)".
+	if f.Name == "format" {
+		if start, end := strings.LastIndex(f.Usage, "("), strings.LastIndex(f.Usage, ")"); start >= 0 && end > start {
+			return strings.Split(f.Usage[start+1:end], "|"), true
+		}
+	}
+	return nil, true
+}
+
+func matchFlags(flags map[string]*gnuflag.Flag, prefix string) []string {
+	names := []string{}
+	for name := range flags {
+		if len(name) == 1 {
+			names = append(names, "-"+name)
+		} else {
+			names = append(names, "--"+name)
+		}
+	}
+	sort.Strings(names)
+	return matchPrefix(names, prefix)
+}
+
+func matchPrefix(candidates []string, prefix string) []string {
+	matches := []string{}
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			matches = append(matches, candidate)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,154 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd_test
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/juju/cmd/cmdtesting"
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/macaroon-bakery.v2/httpbakery"
+
+	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/api/wireformat"
+	"github.com/juju/plans-client/cmd"
+	plantesting "github.com/juju/plans-client/testing"
+)
+
+type completionSuite struct {
+	testing.CleanupSuite
+	mockAPI *plantesting.MockPlanClient
+}
+
+var _ = gc.Suite(&completionSuite{})
+
+func (s *completionSuite) SetUpTest(c *gc.C) {
+	s.CleanupSuite.SetUpTest(c)
+	s.PatchEnvironment("GOCOOKIES", filepath.Join(c.MkDir(), "cookies"))
+	s.mockAPI = plantesting.NewMockPlanClient()
+	s.mockAPI.Plans = []wireformat.Plan{{
+		Id:  "canonical/landscape-default/2",
+		URL: "canonical/landscape-default",
+	}, {
+		Id:  "canonical/landscape-default/1",
+		URL: "canonical/landscape-default",
+	}, {
+		Id:  "canonical/kubernetes/1",
+		URL: "canonical/kubernetes",
+	}}
+	s.mockAPI.PlanRevisions = []wireformat.Plan{{
+		Id:  "canonical/landscape-default/1",
+		URL: "canonical/landscape-default",
+	}, {
+		Id:  "canonical/landscape-default/2",
+		URL: "canonical/landscape-default",
+	}}
+	cachePath := filepath.Join(c.MkDir(), "completion.json")
+	s.PatchValue(cmd.CompletionCachePath, func() string { return cachePath })
+	s.PatchValue(cmd.NewClient, func(string, *httpbakery.Client) (api.PlanClient, error) {
+		return s.mockAPI, nil
+	})
+}
+
+func (s *completionSuite) complete(c *gc.C, words ...string) []string {
+	args := append([]string{"__complete"}, words...)
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewCompletionCommand("charm-plans"), args...)
+	c.Assert(err, jc.ErrorIsNil)
+	out := strings.TrimSpace(cmdtesting.Stdout(ctx))
+	if out == "" {
+		return nil
+	}
+	return strings.Split(out, "\n")
+}
+
+func (s *completionSuite) TestScripts(c *gc.C) {
+	tests := []struct {
+		shell    string
+		contains string
+	}{{
+		shell:    "bash",
+		contains: "complete -o default -F _charm_plans charm-plans",
+	}, {
+		shell:    "zsh",
+		contains: "compdef _charm_plans charm-plans",
+	}, {
+		shell:    "fish",
+		contains: "complete -c charm-plans -f -a '(__charm_plans_complete)'",
+	}}
+	for i, t := range tests {
+		c.Logf("Running test %d %s", i, t.shell)
+		ctx, err := cmdtesting.RunCommand(c, cmd.NewCompletionCommand("charm-plans"), t.shell)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(cmdtesting.Stdout(ctx), jc.Contains, t.contains)
+		c.Assert(cmdtesting.Stdout(ctx), jc.Contains, "charm-plans completion __complete")
+	}
+}
+
+func (s *completionSuite) TestUnsupportedShell(c *gc.C) {
+	_, err := cmdtesting.RunCommand(c, cmd.NewCompletionCommand("charm-plans"), "powershell")
+	c.Assert(err, gc.ErrorMatches, `unsupported shell "powershell"`)
+}
+
+func (s *completionSuite) TestCommands(c *gc.C) {
+	c.Assert(s.complete(c, "show-"), jc.DeepEquals, []string{"show-plan", "show-plan-revisions"})
+	c.Assert(s.complete(c, "--url", "https://example.com", "list-p"), jc.DeepEquals, []string{"list-plans"})
+	c.Assert(s.mockAPI.Calls(), gc.HasLen, 0)
+}
+
+func (s *completionSuite) TestFlags(c *gc.C) {
+	c.Assert(s.complete(c, "list-plans", "--fo"), jc.DeepEquals, []string{"--format"})
+	c.Assert(s.complete(c, "list-plans", "--format", ""), jc.DeepEquals, []string{"json", "tabular", "yaml"})
+	c.Assert(s.complete(c, "show-plan", "--c"), jc.DeepEquals, []string{"--content"})
+	c.Assert(s.mockAPI.Calls(), gc.HasLen, 0)
+}
+
+func (s *completionSuite) TestPlanURLs(c *gc.C) {
+	c.Assert(s.complete(c, "show-plan", "canonical/l"), jc.DeepEquals, []string{"canonical/landscape-default"})
+	s.mockAPI.CheckCalls(c, []testing.StubCall{{FuncName: "GetPlans", Args: []interface{}{"canonical"}}})
+
+	// The second lookup is served from the cache.
+	s.mockAPI.ResetCalls()
+	c.Assert(s.complete(c, "suspend-plan", "-B", "canonical/"), jc.DeepEquals, []string{
+		"canonical/kubernetes",
+		"canonical/landscape-default",
+	})
+	c.Assert(s.mockAPI.Calls(), gc.HasLen, 0)
+
+	// Owners are completed from the cache.
+	c.Assert(s.complete(c, "list-plans", "can"), jc.DeepEquals, []string{"canonical"})
+	c.Assert(s.complete(c, "show-plan-revisions", "c"), jc.DeepEquals, []string{"canonical/"})
+
+	// Arguments that are not plans are left to the shell.
+	c.Assert(s.complete(c, "push-plan", "plan"), gc.HasLen, 0)
+	c.Assert(s.mockAPI.Calls(), gc.HasLen, 0)
+}
+
+func (s *completionSuite) TestPlanRevisions(c *gc.C) {
+	c.Assert(s.complete(c, "show-plan", "canonical/landscape-default/"), jc.DeepEquals, []string{
+		"canonical/landscape-default/1",
+		"canonical/landscape-default/2",
+	})
+	s.mockAPI.CheckCalls(c, []testing.StubCall{{FuncName: "GetPlanRevisions", Args: []interface{}{"canonical/landscape-default"}}})
+
+	// Plan urls do not take revisions.
+	s.mockAPI.ResetCalls()
+	c.Assert(s.complete(c, "attach-plan", "cs:foo", "canonical/landscape-default/"), gc.HasLen, 0)
+	c.Assert(s.mockAPI.Calls(), gc.HasLen, 0)
+}
+
+func (s *completionSuite) TestOffline(c *gc.C) {
+	c.Assert(s.complete(c, "show-plan", "canonical/k"), jc.DeepEquals, []string{"canonical/kubernetes"})
+
+	// Stale entries are used when the plans service cannot be reached.
+	s.PatchValue(cmd.CompletionCacheTTL, time.Duration(0))
+	s.mockAPI.ResetCalls()
+	s.mockAPI.SetErrors(errors.New("connection refused"))
+	c.Assert(s.complete(c, "show-plan", "canonical/k"), jc.DeepEquals, []string{"canonical/kubernetes"})
+	s.mockAPI.CheckCallNames(c, "GetPlans")
+}
@@ -0,0 +1,420 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gosuri/uitable"
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+	"gopkg.in/yaml.v2"
+)
+
+const configDoc = `
+config displays and edits the named profiles of the client configuration.
+
+A profile holds the defaults used by the plan commands when it is selected
+with the --profile flag, the PLANS_PROFILE environment variable or as the
+current profile of the configuration. Values specified with command line
+flags take precedence. Environment variables (JUJU_PLANS, CSURL,
+BAKERY_AGENT_FILE) take precedence over the current profile, but not over
+a profile selected with --profile or PLANS_PROFILE.
+
+Profile keys:
+    plans-url        url of the plans service
+    charmstore-url   url of the charm store
+    cookie-jar       path to the persistent cookie jar
+    owner            default plan owner
+    format           default output format
+    auth             authentication mode: browser or no-browser
+    agent-file       path to the agent file used for non-interactive
+                     authentication
+
+Examples
+config
+	lists all profiles
+config staging
+	displays the staging profile
+config staging plans-url=https://plans.staging.example owner=canonical
+	sets values of the staging profile, creating it if needed
+config staging --reset owner,format
+	removes values from the staging profile
+config staging --use
+	makes staging the current profile
+config staging --remove
+	removes the staging profile
+`
+
+const configPurpose = "display and edit client configuration profiles"
+
+const (
+	authBrowser   = "browser"
+	authNoBrowser = "no-browser"
+)
+
+var configFilePath = func() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "charm-plans", "config.yaml")
+}
+
+// profile holds a named set of client defaults.
+type profile struct {
+	PlansURL      string `yaml:"plans-url,omitempty" json:"plans-url,omitempty"`
+	CharmStoreURL string `yaml:"charmstore-url,omitempty" json:"charmstore-url,omitempty"`
+	CookieJar     string `yaml:"cookie-jar,omitempty" json:"cookie-jar,omitempty"`
+	Owner         string `yaml:"owner,omitempty" json:"owner,omitempty"`
+	Format        string `yaml:"format,omitempty" json:"format,omitempty"`
+	Auth          string `yaml:"auth,omitempty" json:"auth,omitempty"`
+	AgentFile     string `yaml:"agent-file,omitempty" json:"agent-file,omitempty"`
+}
+
+// profileKeys maps the configuration keys to the profile fields.
+var profileKeys = map[string]func(*profile) *string{
+	"plans-url":      func(p *profile) *string { return &p.PlansURL },
+	"charmstore-url": func(p *profile) *string { return &p.CharmStoreURL },
+	"cookie-jar":     func(p *profile) *string { return &p.CookieJar },
+	"owner":          func(p *profile) *string { return &p.Owner },
+	"format":         func(p *profile) *string { return &p.Format },
+	"auth":           func(p *profile) *string { return &p.Auth },
+	"agent-file":     func(p *profile) *string { return &p.AgentFile },
+}
+
+// set validates and sets the value of the profile key.
+func (p *profile) set(key, value string) error {
+	field, ok := profileKeys[key]
+	if !ok {
+		return errors.NotValidf("profile key %q", key)
+	}
+	switch key {
+	case "plans-url", "charmstore-url":
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+			return errors.NotValidf("%s %q", key, value)
+		}
+	case "auth":
+		if !validAuthMode(value) {
+			return errors.NotValidf("auth mode %q", value)
+		}
+	case "format":
+		if !profileFormats[value] {
+			return errors.NotValidf("format %q", value)
+		}
+	}
+	*field(p) = value
+	return nil
+}
+
+// profileFormats holds the output formats a profile may set. Each command
+// uses the profile format only if it supports it.
+var profileFormats = map[string]bool{
+	"base64":  true,
+	"csv":     true,
+	"inspect": true,
+	"json":    true,
+	"tabular": true,
+	"yaml":    true,
+}
+
+// validate checks the values of the profile.
+func (p *profile) validate() error {
+	for key, field := range profileKeys {
+		if value := *field(p); value != "" {
+			if err := p.set(key, value); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	return nil
+}
+
+func validAuthMode(mode string) bool {
+	switch mode {
+	case authBrowser, authNoBrowser:
+		return true
+	}
+	return false
+}
+
+// clientConfig defines the format of the client configuration file.
+type clientConfig struct {
+	CurrentProfile string              `yaml:"current-profile,omitempty"`
+	Profiles       map[string]*profile `yaml:"profiles,omitempty"`
+}
+
+// readClientConfig reads the configuration from the specified file. A
+// missing file results in an empty configuration.
+func readClientConfig(path string) (*clientConfig, error) {
+	config := &clientConfig{}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	} else if err != nil {
+		return nil, errors.Annotate(err, "failed to read client configuration")
+	}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, errors.Annotatef(err, "failed to parse client configuration %q", path)
+	}
+	if err := config.validate(); err != nil {
+		return nil, errors.Annotatef(err, "invalid client configuration %q", path)
+	}
+	return config, nil
+}
+
+// validate checks the profiles of the configuration.
+func (c *clientConfig) validate() error {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := c.Profiles[name]
+		if p == nil {
+			return errors.NotValidf("empty profile %q", name)
+		}
+		if err := p.validate(); err != nil {
+			return errors.Annotatef(err, "profile %q", name)
+		}
+	}
+	return nil
+}
+
+// write stores the configuration in the specified file.
+func (c *clientConfig) write(path string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return errors.Annotate(err, "failed to create configuration directory")
+	}
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return errors.Annotate(err, "failed to write client configuration")
+	}
+	return nil
+}
+
+// selectProfile returns the named profile or, if name is empty, the
+// current profile.
+func (c *clientConfig) selectProfile(name string) (*profile, error) {
+	if name == "" {
+		name = c.CurrentProfile
+	}
+	if name == "" {
+		return &profile{}, nil
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		return nil, errors.NotFoundf("profile %q", name)
+	}
+	return p, nil
+}
+
+// owners returns the default owners of all profiles.
+func (c *clientConfig) owners() []string {
+	owners := []string{}
+	for _, p := range c.Profiles {
+		if p.Owner != "" {
+			owners = append(owners, p.Owner)
+		}
+	}
+	return owners
+}
+
+// NewConfigCommand returns a new ConfigCommand.
+func NewConfigCommand() cmd.Command {
+	return &ConfigCommand{}
+}
+
+// ConfigCommand displays and edits configuration profiles.
+type ConfigCommand struct {
+	cmd.CommandBase
+
+	out     cmd.Output
+	Name    string
+	Values  map[string]string
+	Reset   []string
+	Use     bool
+	Remove  bool
+	resetCS string
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *ConfigCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "tabular", map[string]cmd.Formatter{
+		"json":    cmd.FormatJson,
+		"yaml":    cmd.FormatYaml,
+		"tabular": formatConfigTabular,
+	})
+	f.StringVar(&c.resetCS, "reset", "", "comma separated list of keys to remove from the profile")
+	f.BoolVar(&c.Use, "use", false, "make the profile the current profile")
+	f.BoolVar(&c.Remove, "remove", false, "remove the profile")
+}
+
+// Info implements Command.Info.
+func (c *ConfigCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "config",
+		Args:    "[<profile> [<key>=<value> ...]]",
+		Purpose: configPurpose,
+		Doc:     configDoc,
+	}
+}
+
+// Init implements Command.Init.
+func (c *ConfigCommand) Init(args []string) error {
+	if c.resetCS != "" {
+		c.Reset = strings.Split(c.resetCS, ",")
+	}
+	if len(args) == 0 {
+		if len(c.Reset) > 0 || c.Use || c.Remove {
+			return errors.New("missing profile name")
+		}
+		return nil
+	}
+	c.Name, args = args[0], args[1:]
+	if c.Remove && (len(args) > 0 || len(c.Reset) > 0 || c.Use) {
+		return errors.New("cannot combine --remove with other changes")
+	}
+	c.Values = make(map[string]string)
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 {
+			return errors.Errorf("expected <key>=<value>, got %q", arg)
+		}
+		if err := (&profile{}).set(parts[0], parts[1]); err != nil {
+			return errors.Trace(err)
+		}
+		c.Values[parts[0]] = parts[1]
+	}
+	for _, key := range c.Reset {
+		if _, ok := profileKeys[key]; !ok {
+			return errors.NotValidf("profile key %q", key)
+		}
+		if _, ok := c.Values[key]; ok {
+			return errors.Errorf("cannot set and reset key %q", key)
+		}
+	}
+	return nil
+}
+
+// Run implements Command.Run.
+func (c *ConfigCommand) Run(ctx *cmd.Context) error {
+	path := configFilePath()
+	config, err := readClientConfig(path)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if c.Name == "" {
+		return errors.Trace(c.out.Write(ctx, profilesFromConfig(config)))
+	}
+	if !c.Remove && len(c.Values) == 0 && len(c.Reset) == 0 && !c.Use {
+		p, ok := config.Profiles[c.Name]
+		if !ok {
+			return errors.NotFoundf("profile %q", c.Name)
+		}
+		return errors.Trace(c.out.Write(ctx, p))
+	}
+
+	if c.Remove {
+		if _, ok := config.Profiles[c.Name]; !ok {
+			return errors.NotFoundf("profile %q", c.Name)
+		}
+		delete(config.Profiles, c.Name)
+		if config.CurrentProfile == c.Name {
+			config.CurrentProfile = ""
+		}
+		return errors.Trace(config.write(path))
+	}
+
+	if config.Profiles == nil {
+		config.Profiles = make(map[string]*profile)
+	}
+	p, ok := config.Profiles[c.Name]
+	if !ok {
+		if len(c.Values) == 0 {
+			return errors.NotFoundf("profile %q", c.Name)
+		}
+		p = &profile{}
+		config.Profiles[c.Name] = p
+	}
+	for key, value := range c.Values {
+		if err := p.set(key, value); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	for _, key := range c.Reset {
+		*profileKeys[key](p) = ""
+	}
+	if c.Use {
+		config.CurrentProfile = c.Name
+	}
+	return errors.Trace(config.write(path))
+}
+
+// profileDetails is the output format of a profile listing.
+type profileDetails struct {
+	profile `yaml:",inline"`
+	Current bool `yaml:"current,omitempty" json:"current,omitempty"`
+}
+
+func profilesFromConfig(config *clientConfig) map[string]profileDetails {
+	profiles := make(map[string]profileDetails)
+	for name, p := range config.Profiles {
+		profiles[name] = profileDetails{
+			profile: *p,
+			Current: name == config.CurrentProfile,
+		}
+	}
+	return profiles
+}
+
+func formatConfigTabular(w io.Writer, value interface{}) error {
+	table := uitable.New()
+	table.MaxColWidth = 50
+	table.Wrap = true
+	switch v := value.(type) {
+	case map[string]profileDetails:
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		table.AddRow("PROFILE", "PLANS URL", "OWNER", "FORMAT", "AUTH")
+		for _, name := range names {
+			p := v[name]
+			if p.Current {
+				name += "*"
+			}
+			table.AddRow(name, p.PlansURL, p.Owner, p.Format, p.Auth)
+		}
+	case *profile:
+		keys := make([]string, 0, len(profileKeys))
+		for key := range profileKeys {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		table.AddRow("KEY", "VALUE")
+		for _, key := range keys {
+			table.AddRow(key, *profileKeys[key](v))
+		}
+	default:
+		return errors.Errorf("unexpected value of type %T", value)
+	}
+	_, err := w.Write(table.Bytes())
+	if err != nil {
+		return errors.Annotatef(err, "failed to print table")
+	}
+	return nil
+}
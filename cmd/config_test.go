@@ -0,0 +1,272 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/juju/cmd/cmdtesting"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/macaroon-bakery.v2/httpbakery"
+
+	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/api/wireformat"
+	"github.com/juju/plans-client/cmd"
+	plantesting "github.com/juju/plans-client/testing"
+)
+
+const testConfig = `current-profile: production
+profiles:
+  production:
+    plans-url: https://plans.example
+    owner: canonical
+  staging:
+    plans-url: https://plans.staging.example
+    owner: testisv
+    format: json
+    auth: no-browser
+`
+
+type configSuite struct {
+	testing.IsolationSuite
+	configPath string
+	mockAPI    *plantesting.MockPlanClient
+	serviceURL string
+}
+
+var _ = gc.Suite(&configSuite{})
+
+func (s *configSuite) SetUpTest(c *gc.C) {
+	s.IsolationSuite.SetUpTest(c)
+	s.PatchEnvironment("GOCOOKIES", filepath.Join(c.MkDir(), "cookies"))
+	s.configPath = filepath.Join(c.MkDir(), "charm-plans", "config.yaml")
+	s.PatchValue(cmd.ConfigFilePath, func() string { return s.configPath })
+	s.mockAPI = plantesting.NewMockPlanClient()
+	s.mockAPI.Plans = []wireformat.Plan{{
+		Id:         "testisv/test-plan/1",
+		URL:        "testisv/test-plan",
+		Definition: "test definition",
+		CreatedOn:  time.Date(2017, 12, 1, 0, 0, 0, 0, time.UTC).Format(time.RFC3339),
+	}}
+	s.PatchValue(cmd.NewClient, func(url string, _ *httpbakery.Client) (api.PlanClient, error) {
+		s.serviceURL = url
+		return s.mockAPI, nil
+	})
+}
+
+func (s *configSuite) writeConfig(c *gc.C, config string) {
+	c.Assert(os.MkdirAll(filepath.Dir(s.configPath), 0700), jc.ErrorIsNil)
+	c.Assert(ioutil.WriteFile(s.configPath, []byte(config), 0600), jc.ErrorIsNil)
+}
+
+func (s *configSuite) readConfig(c *gc.C) string {
+	data, err := ioutil.ReadFile(s.configPath)
+	c.Assert(err, jc.ErrorIsNil)
+	return string(data)
+}
+
+func (s *configSuite) TestEditProfiles(c *gc.C) {
+	_, err := cmdtesting.RunCommand(c, cmd.NewConfigCommand(), "staging", "plans-url=https://plans.staging.example", "owner=testisv")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.readConfig(c), gc.Equals, `profiles:
+  staging:
+    plans-url: https://plans.staging.example
+    owner: testisv
+`)
+
+	_, err = cmdtesting.RunCommand(c, cmd.NewConfigCommand(), "staging", "auth=no-browser", "--reset", "owner", "--use")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.readConfig(c), gc.Equals, `current-profile: staging
+profiles:
+  staging:
+    plans-url: https://plans.staging.example
+    auth: no-browser
+`)
+
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewConfigCommand())
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, `PROFILE 	PLANS URL                    	OWNER	FORMAT	AUTH      
+staging*	https://plans.staging.example	     	      	no-browser
+`)
+
+	ctx, err = cmdtesting.RunCommand(c, cmd.NewConfigCommand(), "staging", "--format", "yaml")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, `plans-url: https://plans.staging.example
+auth: no-browser
+`)
+
+	_, err = cmdtesting.RunCommand(c, cmd.NewConfigCommand(), "staging", "--remove")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(s.readConfig(c), gc.Equals, "{}\n")
+}
+
+func (s *configSuite) TestInvalidArguments(c *gc.C) {
+	tests := []struct {
+		about string
+		args  []string
+		err   string
+	}{{
+		about: "unknown key",
+		args:  []string{"staging", "colour=blue"},
+		err:   `profile key "colour" not valid`,
+	}, {
+		about: "invalid url",
+		args:  []string{"staging", "plans-url=plans.example"},
+		err:   `plans-url "plans.example" not valid`,
+	}, {
+		about: "invalid auth mode",
+		args:  []string{"staging", "auth=carrier-pigeon"},
+		err:   `auth mode "carrier-pigeon" not valid`,
+	}, {
+		about: "invalid format",
+		args:  []string{"staging", "format=xml"},
+		err:   `format "xml" not valid`,
+	}, {
+		about: "not a key value pair",
+		args:  []string{"staging", "owner"},
+		err:   `expected <key>=<value>, got "owner"`,
+	}, {
+		about: "missing profile name",
+		args:  []string{"--use"},
+		err:   `missing profile name`,
+	}, {
+		about: "remove and set",
+		args:  []string{"staging", "--remove", "owner=testisv"},
+		err:   `cannot combine --remove with other changes`,
+	}, {
+		about: "set and reset",
+		args:  []string{"staging", "owner=testisv", "--reset", "owner"},
+		err:   `cannot set and reset key "owner"`,
+	}, {
+		about: "unknown profile",
+		args:  []string{"production"},
+		err:   `profile "production" not found`,
+	}}
+	for i, t := range tests {
+		c.Logf("Running test %d %s", i, t.about)
+		_, err := cmdtesting.RunCommand(c, cmd.NewConfigCommand(), t.args...)
+		c.Assert(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *configSuite) TestProfileSelection(c *gc.C) {
+	s.writeConfig(c, testConfig)
+
+	tests := []struct {
+		about      string
+		args       []string
+		env        map[string]string
+		err        string
+		serviceURL string
+		owner      string
+		stdout     string
+	}{{
+		about:      "current profile",
+		serviceURL: "https://plans.example",
+		owner:      "canonical",
+		stdout: `PLAN               	          CREATED ON	EFFECTIVE TIME	     DEFINITION
+testisv/test-plan/1	2017-12-01T00:00:00Z	              	test definition
+`,
+	}, {
+		about:      "profile flag",
+		args:       []string{"--profile", "staging"},
+		serviceURL: "https://plans.staging.example",
+		owner:      "testisv",
+		stdout: `[{"id":"testisv/test-plan/1","url":"testisv/test-plan","plan":"test definition","created-on":"2017-12-01T00:00:00Z","description":"","price":"","released":false}]
+`,
+	}, {
+		about:      "profile environment variable",
+		env:        map[string]string{"PLANS_PROFILE": "staging"},
+		serviceURL: "https://plans.staging.example",
+		owner:      "testisv",
+		stdout: `[{"id":"testisv/test-plan/1","url":"testisv/test-plan","plan":"test definition","created-on":"2017-12-01T00:00:00Z","description":"","price":"","released":false}]
+`,
+	}, {
+		about:      "flags override the profile",
+		args:       []string{"someone", "--profile", "staging", "--url", "https://plans.local", "--format", "yaml"},
+		serviceURL: "https://plans.local",
+		owner:      "someone",
+		stdout: `- id: testisv/test-plan/1
+  url: testisv/test-plan
+  plan: test definition
+  created-on: "2017-12-01T00:00:00Z"
+  description: ""
+  price: ""
+  released: false
+`,
+	}, {
+		about:      "environment overrides the profile",
+		env:        map[string]string{"JUJU_PLANS": "https://plans.env"},
+		serviceURL: "https://plans.env",
+		owner:      "canonical",
+		stdout: `PLAN               	          CREATED ON	EFFECTIVE TIME	     DEFINITION
+testisv/test-plan/1	2017-12-01T00:00:00Z	              	test definition
+`,
+	}, {
+		about:      "profile flag overrides the environment",
+		args:       []string{"--profile", "staging"},
+		env:        map[string]string{"JUJU_PLANS": "https://plans.env"},
+		serviceURL: "https://plans.staging.example",
+		owner:      "testisv",
+		stdout: `[{"id":"testisv/test-plan/1","url":"testisv/test-plan","plan":"test definition","created-on":"2017-12-01T00:00:00Z","description":"","price":"","released":false}]
+`,
+	}, {
+		about: "unknown profile",
+		args:  []string{"--profile", "development"},
+		err:   `profile "development" not found`,
+	}}
+	for i, t := range tests {
+		c.Logf("Running test %d %s", i, t.about)
+		for key, value := range t.env {
+			s.PatchEnvironment(key, value)
+		}
+		s.mockAPI.ResetCalls()
+		ctx, err := cmdtesting.RunCommand(c, cmd.NewListPlansCommand(), t.args...)
+		for key := range t.env {
+			s.PatchEnvironment(key, "")
+		}
+		if t.err != "" {
+			c.Assert(err, gc.ErrorMatches, t.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(s.serviceURL, gc.Equals, t.serviceURL)
+		s.mockAPI.CheckCall(c, 0, "GetPlans", t.owner)
+		c.Assert(cmdtesting.Stdout(ctx), gc.Equals, t.stdout)
+	}
+}
+
+func (s *configSuite) TestInvalidConfig(c *gc.C) {
+	tests := []struct {
+		about  string
+		config string
+		err    string
+	}{{
+		about:  "empty profile",
+		config: "profiles:\n  staging:\n",
+		err:    `empty profile "staging" not valid`,
+	}, {
+		about:  "invalid format",
+		config: "profiles:\n  staging:\n    format: xml\n",
+		err:    `profile "staging": format "xml" not valid`,
+	}, {
+		about:  "invalid auth mode",
+		config: "profiles:\n  staging:\n    auth: carrier-pigeon\n",
+		err:    `profile "staging": auth mode "carrier-pigeon" not valid`,
+	}}
+	for i, t := range tests {
+		c.Logf("Running test %d %s", i, t.about)
+		s.writeConfig(c, t.config)
+		_, err := cmdtesting.RunCommand(c, cmd.NewListPlansCommand(), "testisv")
+		c.Assert(err, gc.ErrorMatches, `.*invalid client configuration ".*": `+t.err)
+		_, err = cmdtesting.RunCommand(c, cmd.NewConfigCommand())
+		c.Assert(err, gc.ErrorMatches, `.*invalid client configuration ".*": `+t.err)
+	}
+	s.mockAPI.CheckNoCalls(c)
+}
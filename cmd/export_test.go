@@ -4,9 +4,10 @@
 package cmd
 
 var (
-	ReadFile  = &readFile
-	NewClient = &newClient
-	FromWire  = fromWire
+	ReadFile    = &readFile
+	NewClient   = &newClient
+	FromWire    = fromWire
+	CharmStates = charmStates
 )
 
 // BaseCommand type is exported for test purposes.
@@ -19,3 +20,9 @@ type BaseCommand struct {
 func NewBaseCommand() BaseCommand {
 	return BaseCommand{&baseCommand{}}
 }
+
+var CompletionCachePath = &completionCachePath
+var CompletionCacheTTL = &completionCacheTTL
+var ConfigFilePath = &configFilePath
+var USSOTokenPath = &ussoTokenPath
+var Now = &now
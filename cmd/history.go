@@ -0,0 +1,263 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gosuri/uitable"
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+
+	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/api/wireformat"
+)
+
+const planHistoryDoc = `
+plan-history displays the events of all the revisions of a plan, and of
+the charms attached to it, as a single timeline sorted by time: the
+creation and release of each revision, and the attach, suspend and resume
+events of each charm, along with the user who performed them. Charms are
+attached to the plan rather than to one of its revisions, so the events of
+the charms have no plan id.
+
+Times passed to --since and --until are specified in RFC3339 format or as
+a YYYY-MM-DD date, which is interpreted as midnight UTC. Events at the
+--since time are included, events at the --until time are not. When
+--charm is specified only the events of that charm are displayed; a charm
+url without a revision matches all revisions of the charm.
+Examples
+plan-history canonical/landscape-default
+	displays all the events of the canonical/landscape-default plan.
+plan-history canonical/landscape-default --since 2017-01-01 --user bob --format csv
+	displays the events performed by bob since the start of 2017 as
+	comma separated values.
+plan-history canonical/landscape-default --charm cs:~canonical/landscape
+	displays the events of all revisions of the landscape charm.
+`
+
+const planHistoryPurpose = "show the history of a plan"
+
+// NewPlanHistoryCommand returns a new PlanHistoryCommand.
+func NewPlanHistoryCommand() cmd.Command {
+	return &PlanHistoryCommand{}
+}
+
+// PlanHistoryCommand displays the events of all the revisions of a plan.
+type PlanHistoryCommand struct {
+	baseCommand
+
+	out      cmd.Output
+	PlanURL  string
+	Since    string
+	Until    string
+	User     string
+	CharmURL string
+
+	since, until time.Time
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *PlanHistoryCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.baseCommand.SetFlags(f)
+	c.addOutputFlags(f, &c.out, "tabular", map[string]cmd.Formatter{
+		"json":    cmd.FormatJson,
+		"yaml":    cmd.FormatYaml,
+		"csv":     formatPlanHistoryCSV,
+		"tabular": formatPlanHistoryTabular,
+	})
+	f.StringVar(&c.Since, "since", "", "only display events at or after this time")
+	f.StringVar(&c.Until, "until", "", "only display events before this time")
+	f.StringVar(&c.User, "user", "", "only display events performed by this user")
+	f.StringVar(&c.CharmURL, "charm", "", "only display events of this charm")
+}
+
+// Info implements Command.Info.
+func (c *PlanHistoryCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "plan-history",
+		Args:    "<plan url>",
+		Purpose: planHistoryPurpose,
+		Doc:     planHistoryDoc,
+	}
+}
+
+// Init implements Command.Init.
+func (c *PlanHistoryCommand) Init(args []string) error {
+	if len(args) < 1 {
+		return errors.New("missing plan url")
+	}
+	if err := cmd.CheckEmpty(args[1:]); err != nil {
+		return errors.Errorf("unknown command line arguments: " + strings.Join(args[1:], ","))
+	}
+	planURL, err := c.unqualifyPlan(args[0])
+	if err != nil {
+		return errors.Trace(err)
+	}
+	c.PlanURL = planURL
+	if _, err := wireformat.ParsePlanURL(c.PlanURL); err != nil {
+		return errors.Annotate(err, "invalid plan url")
+	}
+	if c.Since != "" {
+		if c.since, err = wireformat.ParseTimestamp(c.Since); err != nil {
+			return errors.Annotate(err, "invalid --since")
+		}
+	}
+	if c.Until != "" {
+		if c.until, err = wireformat.ParseTimestamp(c.Until); err != nil {
+			return errors.Annotate(err, "invalid --until")
+		}
+	}
+	if c.Since != "" && c.Until != "" && !c.since.Before(c.until) {
+		return errors.Errorf("--since %v is not before --until %v", c.Since, c.Until)
+	}
+	return nil
+}
+
+// Run implements Command.Run.
+func (c *PlanHistoryCommand) Run(ctx *cmd.Context) error {
+	client, cleanup, err := c.NewClient(ctx)
+	if err != nil {
+		return errors.Annotate(err, "failed to create an http client")
+	}
+	defer cleanup()
+	apiClient, err := newClient(c.ServiceURL, client)
+	if err != nil {
+		return errors.Annotate(err, "failed to create a plan API client")
+	}
+	history, err := c.planHistory(apiClient)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	entries := make([]planHistoryEntry, 0, len(history))
+	for _, e := range history {
+		if c.matches(e) {
+			entries = append(entries, e)
+		}
+	}
+	return errors.Trace(c.out.Write(ctx, entries))
+}
+
+// planHistory retrieves the details of every revision of the plan and
+// merges their events into a single timeline.
+func (c *PlanHistoryCommand) planHistory(client api.PlanClient) ([]planHistoryEntry, error) {
+	revisions, err := client.GetPlanRevisions(context.Background(), c.PlanURL)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to retrieve revisions of plan %v", c.PlanURL)
+	}
+	if len(revisions) == 0 {
+		return nil, errors.NotFoundf("revisions of plan %v", c.PlanURL)
+	}
+	var entries []planHistoryEntry
+	// The events of the charms are returned with the details of every
+	// revision, only the first occurrence is kept. They are not tagged
+	// with a revision as they belong to the plan.
+	seen := make(map[planHistoryEntry]bool)
+	add := func(revision, charmURL string, e wireformat.Event) {
+		entry := planHistoryEntry{
+			Time:  e.Time.UTC(),
+			Event: e.Type,
+			User:  e.User,
+			Charm: charmURL,
+		}
+		if charmURL == "" {
+			entry.Revision = revision
+		}
+		if seen[entry] {
+			return
+		}
+		seen[entry] = true
+		entries = append(entries, entry)
+	}
+	for _, revision := range revisions {
+		details, err := client.GetPlanDetails(context.Background(), revision.Id)
+		if err != nil {
+			return nil, errors.Annotatef(err, "failed to retrieve plan %v details", revision.Id)
+		}
+		id := details.Plan.Id
+		if id == "" {
+			id = revision.Id
+		}
+		created := details.Created
+		created.Type = string(wireformat.EventCreated)
+		add(id, "", created)
+		if details.Released != nil {
+			add(id, "", *details.Released)
+		}
+		for _, ch := range details.Charms {
+			attached := ch.Attached
+			attached.Type = string(wireformat.EventAttached)
+			add(id, ch.CharmURL, attached)
+			for _, e := range ch.Events {
+				add(id, ch.CharmURL, e)
+			}
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	return entries, nil
+}
+
+// matches returns true if the entry matches the filters of the command.
+func (c *PlanHistoryCommand) matches(e planHistoryEntry) bool {
+	if c.Since != "" && e.Time.Before(c.since) {
+		return false
+	}
+	if c.Until != "" && !e.Time.Before(c.until) {
+		return false
+	}
+	if c.User != "" && e.User != c.User {
+		return false
+	}
+	if c.CharmURL != "" && e.Charm != c.CharmURL && wireformat.CharmBaseURL(e.Charm) != c.CharmURL {
+		return false
+	}
+	return true
+}
+
+// planHistoryEntry is the output format of an event of the plan history.
+type planHistoryEntry struct {
+	Time     time.Time `json:"time" yaml:"time"`
+	Revision string    `json:"plan-id,omitempty" yaml:"plan-id,omitempty"`
+	Event    string    `json:"event" yaml:"event"`
+	User     string    `json:"user" yaml:"user"`
+	Charm    string    `json:"charm-url,omitempty" yaml:"charm-url,omitempty"`
+}
+
+func formatPlanHistoryTabular(w io.Writer, value interface{}) error {
+	entries, ok := value.([]planHistoryEntry)
+	if !ok {
+		return errors.Errorf("expected value of type %T, got %T", entries, value)
+	}
+	table := uitable.New()
+	table.MaxColWidth = 50
+	table.Wrap = true
+	table.AddRow("TIME", "REVISION", "EVENT", "USER", "CHARM")
+	for _, e := range entries {
+		table.AddRow(e.Time.Format(time.RFC3339), e.Revision, e.Event, e.User, e.Charm)
+	}
+	_, err := w.Write(table.Bytes())
+	if err != nil {
+		return errors.Annotatef(err, "failed to print table")
+	}
+	return nil
+}
+
+func formatPlanHistoryCSV(w io.Writer, value interface{}) error {
+	entries, ok := value.([]planHistoryEntry)
+	if !ok {
+		return errors.Errorf("expected value of type %T, got %T", entries, value)
+	}
+	records := [][]string{{"time", "plan-id", "event", "user", "charm-url"}}
+	for _, e := range entries {
+		records = append(records, []string{e.Time.Format(time.RFC3339), e.Revision, e.Event, e.User, e.Charm})
+	}
+	return errors.Trace(writeCSV(w, records))
+}
@@ -0,0 +1,190 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd_test
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/juju/cmd/cmdtesting"
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/macaroon-bakery.v2/httpbakery"
+
+	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/api/wireformat"
+	"github.com/juju/plans-client/cmd"
+	plantesting "github.com/juju/plans-client/testing"
+)
+
+type planHistorySuite struct {
+	testing.CleanupSuite
+	mockAPI *plantesting.MockPlanClient
+}
+
+var _ = gc.Suite(&planHistorySuite{})
+
+func (s *planHistorySuite) SetUpTest(c *gc.C) {
+	s.CleanupSuite.SetUpTest(c)
+	s.PatchEnvironment("GOCOOKIES", filepath.Join(c.MkDir(), "cookies"))
+	s.mockAPI = plantesting.NewMockPlanClient()
+	s.mockAPI.PlanRevisions = []wireformat.Plan{
+		{Id: "testisv/default/1", URL: "testisv/default", Released: true},
+		{Id: "testisv/default/2", URL: "testisv/default"},
+	}
+	day := func(d int) time.Time {
+		return time.Date(2017, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+	// The events of the charms are returned for every revision.
+	charms := []wireformat.CharmPlanDetail{{
+		CharmURL: "cs:~testisv/charm1-0",
+		Attached: wireformat.Event{User: "jane", Type: "create", Time: day(3)},
+		Events: []wireformat.Event{
+			{User: "eve", Type: "suspend", Time: day(5)},
+			{User: "eve", Type: "resume", Time: day(6)},
+		},
+	}, {
+		CharmURL: "cs:~testisv/charm2-1",
+		Attached: wireformat.Event{User: "bob", Type: "create", Time: day(4)},
+	}}
+	s.mockAPI.RevisionDetails = map[string]*wireformat.PlanDetails{
+		"testisv/default/1": {
+			Plan:     wireformat.Plan{Id: "testisv/default/1", URL: "testisv/default"},
+			Created:  wireformat.Event{User: "jane", Type: "create", Time: day(1)},
+			Released: &wireformat.Event{User: "jane", Type: "release", Time: day(2)},
+			Charms:   charms,
+		},
+		"testisv/default/2": {
+			Plan:    wireformat.Plan{Id: "testisv/default/2", URL: "testisv/default"},
+			Created: wireformat.Event{User: "bob", Type: "create", Time: day(7)},
+			Charms:  charms,
+		},
+	}
+	s.PatchValue(cmd.NewClient, func(string, *httpbakery.Client) (api.PlanClient, error) {
+		return s.mockAPI, nil
+	})
+}
+
+func (s *planHistorySuite) TestInvalidArgs(c *gc.C) {
+	tests := []struct {
+		about string
+		args  []string
+		err   string
+	}{{
+		about: "missing plan",
+		args:  []string{},
+		err:   "missing plan url",
+	}, {
+		about: "unknown arguments",
+		args:  []string{"testisv/default", "extra"},
+		err:   "unknown command line arguments: extra",
+	}, {
+		about: "plan revision",
+		args:  []string{"testisv/default/1"},
+		err:   `invalid plan url: plan url "testisv/default/1" not valid`,
+	}, {
+		about: "invalid since",
+		args:  []string{"testisv/default", "--since", "yesterday"},
+		err:   `invalid --since: timestamp "yesterday" not valid`,
+	}, {
+		about: "invalid until",
+		args:  []string{"testisv/default", "--until", "2017-13-01"},
+		err:   `invalid --until: timestamp "2017-13-01" not valid`,
+	}, {
+		about: "empty range",
+		args:  []string{"testisv/default", "--since", "2017-02-01", "--until", "2017-01-01"},
+		err:   `--since 2017-02-01 is not before --until 2017-01-01`,
+	}}
+	for i, t := range tests {
+		c.Logf("running test %d: %s", i, t.about)
+		_, err := cmdtesting.RunCommand(c, cmd.NewPlanHistoryCommand(), t.args...)
+		c.Assert(err, gc.ErrorMatches, t.err)
+	}
+	s.mockAPI.CheckNoCalls(c)
+}
+
+func (s *planHistorySuite) TestHistory(c *gc.C) {
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewPlanHistoryCommand(), "testisv/default")
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.CheckCallNames(c, "GetPlanRevisions", "GetPlanDetails", "GetPlanDetails")
+	s.mockAPI.CheckCall(c, 0, "GetPlanRevisions", "testisv/default")
+	s.mockAPI.CheckCall(c, 1, "GetPlanDetails", "testisv/default/1")
+	s.mockAPI.CheckCall(c, 2, "GetPlanDetails", "testisv/default/2")
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, ""+
+		"TIME                \tREVISION         \tEVENT  \tUSER\tCHARM               \n"+
+		"2017-01-01T00:00:00Z\ttestisv/default/1\tcreate \tjane\t                    \n"+
+		"2017-01-02T00:00:00Z\ttestisv/default/1\trelease\tjane\t                    \n"+
+		"2017-01-03T00:00:00Z\t                 \tattach \tjane\tcs:~testisv/charm1-0\n"+
+		"2017-01-04T00:00:00Z\t                 \tattach \tbob \tcs:~testisv/charm2-1\n"+
+		"2017-01-05T00:00:00Z\t                 \tsuspend\teve \tcs:~testisv/charm1-0\n"+
+		"2017-01-06T00:00:00Z\t                 \tresume \teve \tcs:~testisv/charm1-0\n"+
+		"2017-01-07T00:00:00Z\ttestisv/default/2\tcreate \tbob \t                    \n")
+}
+
+func (s *planHistorySuite) TestFilters(c *gc.C) {
+	tests := []struct {
+		about  string
+		args   []string
+		stdout string
+	}{{
+		about: "time range",
+		args:  []string{"--since", "2017-01-02", "--until", "2017-01-04"},
+		stdout: "" +
+			"time,plan-id,event,user,charm-url\n" +
+			"2017-01-02T00:00:00Z,testisv/default/1,release,jane,\n" +
+			"2017-01-03T00:00:00Z,,attach,jane,cs:~testisv/charm1-0",
+	}, {
+		about: "user",
+		args:  []string{"--user", "bob"},
+		stdout: "" +
+			"time,plan-id,event,user,charm-url\n" +
+			"2017-01-04T00:00:00Z,,attach,bob,cs:~testisv/charm2-1\n" +
+			"2017-01-07T00:00:00Z,testisv/default/2,create,bob,",
+	}, {
+		about: "charm without revision",
+		args:  []string{"--charm", "cs:~testisv/charm1"},
+		stdout: "" +
+			"time,plan-id,event,user,charm-url\n" +
+			"2017-01-03T00:00:00Z,,attach,jane,cs:~testisv/charm1-0\n" +
+			"2017-01-05T00:00:00Z,,suspend,eve,cs:~testisv/charm1-0\n" +
+			"2017-01-06T00:00:00Z,,resume,eve,cs:~testisv/charm1-0",
+	}, {
+		about: "charm with revision",
+		args:  []string{"--charm", "cs:~testisv/charm2-1", "--user", "jane"},
+		stdout: "" +
+			"time,plan-id,event,user,charm-url",
+	}}
+	for i, t := range tests {
+		c.Logf("running test %d: %s", i, t.about)
+		s.mockAPI.ResetCalls()
+		args := append([]string{"testisv/default", "--format", "csv"}, t.args...)
+		ctx, err := cmdtesting.RunCommand(c, cmd.NewPlanHistoryCommand(), args...)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(cmdtesting.Stdout(ctx), gc.Equals, t.stdout+"\n")
+	}
+}
+
+func (s *planHistorySuite) TestHistoryJSON(c *gc.C) {
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewPlanHistoryCommand(), "testisv/default", "--until", "2017-01-04", "--format", "json")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, `[`+
+		`{"time":"2017-01-01T00:00:00Z","plan-id":"testisv/default/1","event":"create","user":"jane"},`+
+		`{"time":"2017-01-02T00:00:00Z","plan-id":"testisv/default/1","event":"release","user":"jane"},`+
+		`{"time":"2017-01-03T00:00:00Z","event":"attach","user":"jane","charm-url":"cs:~testisv/charm1-0"}`+
+		"]\n")
+}
+
+func (s *planHistorySuite) TestNoRevisions(c *gc.C) {
+	s.mockAPI.PlanRevisions = nil
+	_, err := cmdtesting.RunCommand(c, cmd.NewPlanHistoryCommand(), "testisv/default")
+	c.Assert(err, gc.ErrorMatches, "revisions of plan testisv/default not found")
+}
+
+func (s *planHistorySuite) TestDetailsError(c *gc.C) {
+	s.mockAPI.SetErrors(errors.New("boom"))
+	_, err := cmdtesting.RunCommand(c, cmd.NewPlanHistoryCommand(), "testisv/default")
+	c.Assert(err, gc.ErrorMatches, "failed to retrieve plan testisv/default/1 details: boom")
+}
@@ -0,0 +1,273 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gosuri/uitable"
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+	macaroonv1 "gopkg.in/macaroon.v1"
+
+	"github.com/juju/plans-client/macaroons"
+)
+
+const inspectMacaroonDoc = `
+inspect-macaroon decodes an authorization macaroon, as obtained with
+authorize-plan or authorize-reseller, and displays its location, id,
+caveats and expiry time. The macaroon is specified as JSON or base64 on
+the command line, as the name of a file holding it, or read from standard
+input when "-" is specified.
+
+When any of --model, --charm, --application or --plan is specified, the
+attributes declared by the macaroon are verified against the expected
+values and the command fails if they do not match, if the macaroon has
+expired or if a third party caveat is not discharged. The verification
+is done offline: the macaroon signature is not checked.
+Examples
+inspect-macaroon auth.macaroon
+	displays the contents of the macaroon held in auth.macaroon.
+authorize-plan --model 9d4ba8a9-1b2c-4f5e-8e3a-2f0c63a1c0de --application landscape cs:~canonical/landscape-1 canonical/landscape-default | inspect-macaroon - --application landscape --plan canonical/landscape-default
+	verifies that the authorization is issued for the landscape application
+	and the canonical/landscape-default plan.
+`
+
+const inspectMacaroonPurpose = "inspect an authorization macaroon"
+
+// NewInspectMacaroonCommand returns a new InspectMacaroonCommand.
+func NewInspectMacaroonCommand() cmd.Command {
+	return &InspectMacaroonCommand{}
+}
+
+// InspectMacaroonCommand displays the contents of a macaroon and
+// verifies its declared caveats.
+type InspectMacaroonCommand struct {
+	cmd.CommandBase
+
+	out      cmd.Output
+	Macaroon string
+	Expected macaroons.Expected
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *InspectMacaroonCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "tabular", map[string]cmd.Formatter{
+		"json":    cmd.FormatJson,
+		"yaml":    cmd.FormatYaml,
+		"tabular": formatMacaroonDetailsTabular,
+	})
+	f.StringVar(&c.Expected.ModelUUID, "model", "", "expected model uuid")
+	f.StringVar(&c.Expected.ModelUUID, "env", "", "")
+	f.StringVar(&c.Expected.CharmURL, "charm", "", "expected charm url")
+	f.StringVar(&c.Expected.Application, "application", "", "expected application name")
+	f.StringVar(&c.Expected.Application, "service", "", "")
+	f.StringVar(&c.Expected.Plan, "plan", "", "expected plan url")
+}
+
+// Info implements Command.Info.
+func (c *InspectMacaroonCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "inspect-macaroon",
+		Args:    "<macaroon|file|->",
+		Purpose: inspectMacaroonPurpose,
+		Doc:     inspectMacaroonDoc,
+	}
+}
+
+// Init implements Command.Init.
+func (c *InspectMacaroonCommand) Init(args []string) error {
+	if len(args) < 1 {
+		return errors.New("missing macaroon")
+	}
+	c.Macaroon = args[0]
+	if err := cmd.CheckEmpty(args[1:]); err != nil {
+		return errors.Errorf("unknown command line arguments: " + strings.Join(args[1:], ","))
+	}
+	return nil
+}
+
+// Run implements Command.Run.
+func (c *InspectMacaroonCommand) Run(ctx *cmd.Context) error {
+	data, err := c.readMacaroon(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ms, err := macaroons.Decode(data)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	info, err := macaroons.Inspect(ms)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	t := now()
+	details := macaroonDetails{Info: *info}
+	if info.Expires != nil {
+		details.Expiry = humanExpiry(*info.Expires, t)
+	}
+	var verr error
+	if c.Expected != (macaroons.Expected{}) {
+		verr = info.Verify(c.Expected, t)
+		details.Verification = &verificationDetails{OK: verr == nil}
+		if e, ok := verr.(*macaroons.VerificationError); ok {
+			details.Verification.Problems = e.Problems
+		}
+	}
+	if err := c.out.Write(ctx, details); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(verr)
+}
+
+// readMacaroon returns the macaroon specified on the command line,
+// reading it from standard input or from a file if needed. An argument
+// that cannot be opened as a file, for instance because a base64-encoded
+// macaroon is longer than the maximum length of a file name, is the
+// macaroon itself.
+func (c *InspectMacaroonCommand) readMacaroon(ctx *cmd.Context) ([]byte, error) {
+	if c.Macaroon == "-" {
+		data, err := ioutil.ReadAll(ctx.Stdin)
+		return data, errors.Annotate(err, "failed to read macaroon from standard input")
+	}
+	data, err := readFile(ctx.AbsPath(c.Macaroon))
+	if err != nil && !os.IsPermission(errors.Cause(err)) {
+		return []byte(c.Macaroon), nil
+	}
+	return data, errors.Annotatef(err, "failed to read macaroon file %q", c.Macaroon)
+}
+
+// macaroonDetails is the output format of inspect-macaroon.
+type macaroonDetails struct {
+	macaroons.Info `yaml:",inline"`
+	Expiry         string               `json:"expiry,omitempty" yaml:"expiry,omitempty"`
+	Verification   *verificationDetails `json:"verification,omitempty" yaml:"verification,omitempty"`
+}
+
+// verificationDetails holds the result of the verification of a
+// macaroon against the expected deployment.
+type verificationDetails struct {
+	OK       bool     `json:"ok" yaml:"ok"`
+	Problems []string `json:"problems,omitempty" yaml:"problems,omitempty"`
+}
+
+// humanExpiry describes the expiry time relative to now.
+func humanExpiry(expires, now time.Time) string {
+	if expires.After(now) {
+		return "expires in " + humanDuration(expires.Sub(now))
+	}
+	return "expired " + humanDuration(now.Sub(expires)) + " ago"
+}
+
+func humanDuration(d time.Duration) string {
+	plural := func(n int64, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s", unit)
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+	switch {
+	case d < time.Minute:
+		return "less than a minute"
+	case d < time.Hour:
+		return plural(int64(d/time.Minute), "minute")
+	case d < 48*time.Hour:
+		return plural(int64(d/time.Hour), "hour")
+	default:
+		return plural(int64(d/(24*time.Hour)), "day")
+	}
+}
+
+// formatMacaroonInspect writes the contents of an authorization macaroon
+// as displayed by inspect-macaroon.
+func formatMacaroonInspect(w io.Writer, value interface{}) error {
+	m, ok := value.(*macaroonv1.Macaroon)
+	if !ok {
+		return errors.Errorf("expected value of type %T, got %T", m, value)
+	}
+	ms, err := macaroons.FromV1(m)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	info, err := macaroons.Inspect(ms)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	details := macaroonDetails{Info: *info}
+	if info.Expires != nil {
+		details.Expiry = humanExpiry(*info.Expires, now())
+	}
+	return formatMacaroonDetailsTabular(w, details)
+}
+
+func formatMacaroonDetailsTabular(w io.Writer, value interface{}) error {
+	m, ok := value.(macaroonDetails)
+	if !ok {
+		return errors.Errorf("expected value of type %T, got %T", m, value)
+	}
+	table := uitable.New()
+	table.MaxColWidth = 80
+	table.Wrap = true
+	table.AddRow("LOCATION", m.Location)
+	table.AddRow("ID", m.ID)
+	table.AddRow("SIGNATURE", m.Signature)
+	if m.Expires != nil {
+		table.AddRow("EXPIRES", fmt.Sprintf("%s (%s)", m.Expires.Format(time.RFC3339), m.Expiry))
+	}
+	addRows := func(label string, values []string) {
+		for i, v := range values {
+			if i > 0 {
+				label = ""
+			}
+			table.AddRow(label, v)
+		}
+	}
+	keys := make([]string, 0, len(m.Declared))
+	for k := range m.Declared {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	declared := make([]string, len(keys))
+	for i, k := range keys {
+		declared[i] = k + "=" + m.Declared[k]
+	}
+	addRows("DECLARED", declared)
+	addRows("CAVEATS", m.FirstPartyCaveats)
+	thirdParty := make([]string, len(m.ThirdPartyCaveats))
+	for i, cav := range m.ThirdPartyCaveats {
+		status := "not discharged"
+		if cav.Discharged {
+			status = "discharged"
+		}
+		thirdParty[i] = fmt.Sprintf("%s (%s)", cav.Location, status)
+	}
+	addRows("THIRD PARTY", thirdParty)
+	discharges := make([]string, len(m.Discharges))
+	for i, d := range m.Discharges {
+		discharges[i] = d.Location
+		if len(d.FirstPartyCaveats) > 0 {
+			discharges[i] += ": " + strings.Join(d.FirstPartyCaveats, ", ")
+		}
+	}
+	addRows("DISCHARGES", discharges)
+	if m.Verification != nil {
+		if m.Verification.OK {
+			table.AddRow("VERIFICATION", "ok")
+		} else {
+			addRows("VERIFICATION", m.Verification.Problems)
+		}
+	}
+	_, err := w.Write(table.Bytes())
+	if err != nil {
+		return errors.Annotatef(err, "failed to print table")
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd_test
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/juju/cmd/cmdtesting"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/macaroon.v2"
+
+	"github.com/juju/plans-client/cmd"
+)
+
+type inspectMacaroonSuite struct {
+	testing.CleanupSuite
+	macaroons macaroon.Slice
+}
+
+var _ = gc.Suite(&inspectMacaroonSuite{})
+
+func (s *inspectMacaroonSuite) SetUpTest(c *gc.C) {
+	s.CleanupSuite.SetUpTest(c)
+	s.PatchValue(cmd.Now, func() time.Time {
+		return time.Date(2017, 1, 2, 0, 4, 5, 0, time.UTC)
+	})
+	m, err := macaroon.New([]byte("root-key"), []byte("plan-auth"), "plans", macaroon.LatestVersion)
+	c.Assert(err, jc.ErrorIsNil)
+	for _, cav := range []string{
+		"declared environment " + testModelUUID,
+		"declared charm cs:~testisv/charm1-0",
+		"declared service app1",
+		"declared plan testisv/default",
+		"time-before 2017-01-02T03:04:05Z",
+	} {
+		err := m.AddFirstPartyCaveat([]byte(cav))
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	s.macaroons = macaroon.Slice{m}
+}
+
+func (s *inspectMacaroonSuite) TestInvalidArgs(c *gc.C) {
+	_, err := cmdtesting.RunCommand(c, cmd.NewInspectMacaroonCommand())
+	c.Assert(err, gc.ErrorMatches, "missing macaroon")
+	_, err = cmdtesting.RunCommand(c, cmd.NewInspectMacaroonCommand(), "a", "b")
+	c.Assert(err, gc.ErrorMatches, "unknown command line arguments: b")
+	_, err = cmdtesting.RunCommand(c, cmd.NewInspectMacaroonCommand(), "not a macaroon!")
+	c.Assert(err, gc.ErrorMatches, "expected JSON, base64-encoded JSON or base64-encoded binary macaroon")
+}
+
+func (s *inspectMacaroonSuite) TestInspect(c *gc.C) {
+	data, err := json.Marshal(s.macaroons[0])
+	c.Assert(err, jc.ErrorIsNil)
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewInspectMacaroonCommand(), string(data))
+	c.Assert(err, jc.ErrorIsNil)
+	// The table pads the values to the width of the signature.
+	row := func(label, value string) string {
+		return fmt.Sprintf("%-9s\t%-64s\n", label, value)
+	}
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, row("LOCATION", "plans")+
+		row("ID", "plan-auth")+
+		row("SIGNATURE", hex.EncodeToString(s.macaroons[0].Signature()))+
+		row("EXPIRES", "2017-01-02T03:04:05Z (expires in 3 hours)")+
+		row("DECLARED", "charm=cs:~testisv/charm1-0")+
+		row("", "environment="+testModelUUID)+
+		row("", "plan=testisv/default")+
+		row("", "service=app1")+
+		row("CAVEATS", "declared environment "+testModelUUID)+
+		row("", "declared charm cs:~testisv/charm1-0")+
+		row("", "declared service app1")+
+		row("", "declared plan testisv/default")+
+		row("", "time-before 2017-01-02T03:04:05Z"))
+
+}
+
+func (s *inspectMacaroonSuite) TestInspectFile(c *gc.C) {
+	data, err := s.macaroons.MarshalBinary()
+	c.Assert(err, jc.ErrorIsNil)
+	path := filepath.Join(c.MkDir(), "auth.macaroon")
+	err = ioutil.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(data)+"\n"), 0600)
+	c.Assert(err, jc.ErrorIsNil)
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewInspectMacaroonCommand(), path, "--format", "json")
+	c.Assert(err, jc.ErrorIsNil)
+	var out map[string]interface{}
+	err = json.Unmarshal([]byte(cmdtesting.Stdout(ctx)), &out)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(out["id"], gc.Equals, "plan-auth")
+	c.Assert(out["expires"], gc.Equals, "2017-01-02T03:04:05Z")
+	c.Assert(out["expiry"], gc.Equals, "expires in 3 hours")
+	c.Assert(out["verification"], gc.IsNil)
+}
+
+func (s *inspectMacaroonSuite) TestInspectBase64(c *gc.C) {
+	data, err := s.macaroons.MarshalBinary()
+	c.Assert(err, jc.ErrorIsNil)
+	// The encoded macaroon is too long to be a file name.
+	encoded := base64.StdEncoding.EncodeToString(data)
+	c.Assert(len(encoded) > 255, jc.IsTrue)
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewInspectMacaroonCommand(), encoded, "--format", "json")
+	c.Assert(err, jc.ErrorIsNil)
+	var out map[string]interface{}
+	err = json.Unmarshal([]byte(cmdtesting.Stdout(ctx)), &out)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(out["id"], gc.Equals, "plan-auth")
+	c.Assert(out["signature"], gc.Equals, hex.EncodeToString(s.macaroons[0].Signature()))
+}
+
+func (s *inspectMacaroonSuite) TestVerify(c *gc.C) {
+	data, err := json.Marshal(s.macaroons)
+	c.Assert(err, jc.ErrorIsNil)
+	ctx := cmdtesting.Context(c)
+	ctx.Stdin = strings.NewReader(string(data))
+	command := cmd.NewInspectMacaroonCommand()
+	err = cmdtesting.InitCommand(command, []string{"-", "--format", "yaml", "--model", testModelUUID, "--application", "app1", "--plan", "testisv/default"})
+	c.Assert(err, jc.ErrorIsNil)
+	err = command.Run(ctx)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), jc.Contains, "verification:\n  ok: true\n")
+}
+
+func (s *inspectMacaroonSuite) TestVerifyFails(c *gc.C) {
+	s.PatchValue(cmd.Now, func() time.Time {
+		return time.Date(2017, 1, 5, 3, 4, 5, 0, time.UTC)
+	})
+	data, err := json.Marshal(s.macaroons)
+	c.Assert(err, jc.ErrorIsNil)
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewInspectMacaroonCommand(), string(data), "--charm", "cs:~testisv/charm1-0", "--service", "app2")
+	c.Assert(err, gc.ErrorMatches, `macaroon verification failed: macaroon expired at 2017-01-02T03:04:05Z; application mismatch: macaroon declares "app1", expected "app2"`)
+	c.Assert(cmdtesting.Stdout(ctx), jc.Contains, "2017-01-02T03:04:05Z (expired 3 days ago)")
+	c.Assert(cmdtesting.Stdout(ctx), jc.Contains, `VERIFICATION	macaroon expired at 2017-01-02T03:04:05Z`)
+}
@@ -6,17 +6,30 @@ package cmd
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/juju/cmd"
 	"github.com/juju/errors"
 	"github.com/juju/gnuflag"
+
+	"github.com/juju/plans-client/api/wireformat"
 )
 
 const listPlansDoc = `
-list-plans is to list plans owned by a user or group
+list-plans is to list plans owned by a user or group.
+
+When --since or --until is specified, only the plans created in that time
+range are listed, in the order they were created. Times are specified in
+RFC3339 format or as a YYYY-MM-DD date, which is interpreted as midnight
+UTC. Plans created at the --since time are listed, plans created at the
+--until time are not. Plans without a valid creation time are skipped.
 Examples
 list-plans canonical
 	lists all plans owned by canonical
+list-plans
+	lists all plans owned by the owner set in the configuration profile
+list-plans canonical --since 2017-01-01
+	lists the plans owned by canonical created since the start of 2017
 `
 const listPlansPurpose = "list plans"
 
@@ -30,17 +43,23 @@ type ListPlansCommand struct {
 	baseCommand
 	out   cmd.Output
 	Owner string
+	Since string
+	Until string
+
+	since, until time.Time
 }
 
 // SetFlags implements Command.SetFlags.
 func (c *ListPlansCommand) SetFlags(f *gnuflag.FlagSet) {
 	c.baseCommand.ServiceURL = defaultServiceURL()
 	c.baseCommand.SetFlags(f)
-	c.out.AddFlags(f, "tabular", map[string]cmd.Formatter{
+	c.addOutputFlags(f, &c.out, "tabular", map[string]cmd.Formatter{
 		"json":    cmd.FormatJson,
 		"yaml":    cmd.FormatYaml,
 		"tabular": formatPlansTabular,
 	})
+	f.StringVar(&c.Since, "since", "", "only list plans created at or after this time")
+	f.StringVar(&c.Until, "until", "", "only list plans created before this time")
 }
 
 // Description returns a one-line description of the command.
@@ -52,7 +71,7 @@ func (c *ListPlansCommand) Description() string {
 func (c *ListPlansCommand) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:    "list-plans",
-		Args:    "<owner>",
+		Args:    "[<owner>]",
 		Purpose: listPlansPurpose,
 		Doc:     listPlansDoc,
 	}
@@ -61,7 +80,14 @@ func (c *ListPlansCommand) Info() *cmd.Info {
 // Init reads and verifies the cli arguments for the PlanAddCommang
 func (c *ListPlansCommand) Init(args []string) error {
 	if len(args) < 1 {
-		return errors.New("missing arguments")
+		p, err := c.loadProfile()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if p.Owner == "" {
+			return errors.New("missing arguments")
+		}
+		args = []string{p.Owner}
 	}
 	owner, args := args[0], args[1:]
 
@@ -70,6 +96,20 @@ func (c *ListPlansCommand) Init(args []string) error {
 	}
 
 	c.Owner = owner
+	var err error
+	if c.Since != "" {
+		if c.since, err = wireformat.ParseTimestamp(c.Since); err != nil {
+			return errors.Annotate(err, "invalid --since")
+		}
+	}
+	if c.Until != "" {
+		if c.until, err = wireformat.ParseTimestamp(c.Until); err != nil {
+			return errors.Annotate(err, "invalid --until")
+		}
+	}
+	if c.Since != "" && c.Until != "" && !c.since.Before(c.until) {
+		return errors.Errorf("--since %v is not before --until %v", c.Since, c.Until)
+	}
 	return nil
 }
 
@@ -90,7 +130,33 @@ func (c *ListPlansCommand) Run(ctx *cmd.Context) error {
 	if err != nil {
 		return errors.Annotate(err, "failed to retrieve plans")
 	}
+	if c.Since != "" || c.Until != "" {
+		wireformat.SortPlansByCreation(plans)
+		plans = c.filter(ctx, plans)
+	}
 
 	c.out.Write(ctx, plans)
 	return nil
 }
+
+// filter returns the plans created in the time range specified by
+// --since and --until. Plans without a valid creation time are skipped
+// with a warning.
+func (c *ListPlansCommand) filter(ctx *cmd.Context, plans []wireformat.Plan) []wireformat.Plan {
+	filtered := []wireformat.Plan{}
+	for _, plan := range plans {
+		created, err := plan.CreatedTime()
+		if err != nil {
+			ctx.Warningf("skipping plan: %v", err)
+			continue
+		}
+		if c.Since != "" && created.Before(c.since) {
+			continue
+		}
+		if c.Until != "" && !created.Before(c.until) {
+			continue
+		}
+		filtered = append(filtered, plan)
+	}
+	return filtered
+}
@@ -4,6 +4,8 @@
 package cmd_test
 
 import (
+	"encoding/json"
+	"path/filepath"
 	"time"
 
 	"github.com/juju/cmd/cmdtesting"
@@ -27,6 +29,7 @@ type listPlansSuite struct {
 var _ = gc.Suite(&listPlansSuite{})
 
 func (s *listPlansSuite) SetUpTest(c *gc.C) {
+	s.PatchEnvironment("GOCOOKIES", filepath.Join(c.MkDir(), "cookies"))
 	s.stub = &testing.Stub{}
 
 	s.mockAPI = plantesting.NewMockPlanClient()
@@ -124,3 +127,77 @@ canonical/test-plan/1	2017-12-01T00:00:00Z	              	test definition
 		}
 	}
 }
+
+func (s *listPlansSuite) TestCreationTime(c *gc.C) {
+	s.mockAPI.Plans = []wireformat.Plan{
+		{Id: "canonical/b/1", URL: "canonical/b", CreatedOn: "2017-12-01T10:00:00+02:00"},
+		{Id: "canonical/a/1", URL: "canonical/a", CreatedOn: "2017-12-01 09:00:00"},
+		{Id: "canonical/c/1", URL: "canonical/c", CreatedOn: "2017-11-30T00:00:00Z"},
+	}
+	tests := []struct {
+		about string
+		args  []string
+		ids   []string
+		err   string
+	}{{
+		about: "order of the plans service",
+		ids:   []string{"canonical/b/1", "canonical/a/1", "canonical/c/1"},
+	}, {
+		about: "sorted by creation time in a time range",
+		args:  []string{"--since", "2017-01-01"},
+		ids:   []string{"canonical/c/1", "canonical/b/1", "canonical/a/1"},
+	}, {
+		about: "since",
+		args:  []string{"--since", "2017-12-01T08:30:00Z"},
+		ids:   []string{"canonical/a/1"},
+	}, {
+		about: "until",
+		args:  []string{"--until", "2017-12-01T09:00:00Z"},
+		ids:   []string{"canonical/c/1", "canonical/b/1"},
+	}, {
+		about: "nothing in range",
+		args:  []string{"--since", "2018-01-01"},
+		ids:   []string{},
+	}, {
+		about: "invalid since",
+		args:  []string{"--since", "tomorrow"},
+		err:   `invalid --since: timestamp "tomorrow" not valid`,
+	}, {
+		about: "empty range",
+		args:  []string{"--since", "2017-12-01", "--until", "2017-12-01"},
+		err:   `--since 2017-12-01 is not before --until 2017-12-01`,
+	}}
+	for i, t := range tests {
+		c.Logf("test %d: %s", i, t.about)
+		args := append([]string{"canonical", "--url", "localhost:0", "--format", "json"}, t.args...)
+		ctx, err := cmdtesting.RunCommand(c, cmd.NewListPlansCommand(), args...)
+		if t.err != "" {
+			c.Assert(err, gc.ErrorMatches, t.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		var plans []wireformat.Plan
+		err = json.Unmarshal([]byte(cmdtesting.Stdout(ctx)), &plans)
+		c.Assert(err, jc.ErrorIsNil)
+		ids := []string{}
+		for _, p := range plans {
+			ids = append(ids, p.Id)
+		}
+		c.Check(ids, jc.DeepEquals, t.ids)
+	}
+}
+
+func (s *listPlansSuite) TestInvalidCreationTime(c *gc.C) {
+	s.mockAPI.Plans = []wireformat.Plan{
+		{Id: "canonical/a/1", URL: "canonical/a", CreatedOn: "soon"},
+		{Id: "canonical/b/1", URL: "canonical/b"},
+		{Id: "canonical/c/1", URL: "canonical/c", CreatedOn: "2017-02-01T00:00:00Z"},
+	}
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewListPlansCommand(), "canonical", "--url", "localhost:0", "--since", "2017-01-01", "--format", "json")
+	c.Assert(err, jc.ErrorIsNil)
+	var plans []wireformat.Plan
+	err = json.Unmarshal([]byte(cmdtesting.Stdout(ctx)), &plans)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(plans, gc.HasLen, 1)
+	c.Assert(plans[0].Id, gc.Equals, "canonical/c/1")
+}
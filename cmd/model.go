@@ -0,0 +1,334 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/gosuri/uitable"
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+
+	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/api/wireformat"
+)
+
+const reportModelPlansDoc = `
+report-model-plans reports the plans of the applications of a model, as
+described by the output of "juju status --format json", read from a file
+or from standard input when "-" is specified. No connection to the model
+is needed.
+
+For each application the latest authorization issued for the model and
+the application is looked up to find the plan revision in use. The report
+shows whether a newer revision of the plan has been released and whether
+the plan is suspended for the charm of the application.
+
+The model uuid is read from the status when present. Otherwise it must be
+specified with --model, as reported by "juju show-model".
+Examples
+juju status --format json | report-model-plans - --model 9d4ba8a9-1b2c-4f5e-8e3a-2f0c63a1c0de
+	reports the plans of the applications of the current model.
+report-model-plans status.json --format yaml
+	reports the plans of the applications of the model described in
+	status.json as yaml.
+`
+
+const reportModelPlansPurpose = "report the plans of the applications of a model"
+
+// NewReportModelPlansCommand returns a new ReportModelPlansCommand.
+func NewReportModelPlansCommand() cmd.Command {
+	return &ReportModelPlansCommand{}
+}
+
+// ReportModelPlansCommand reports the plans of the applications of a
+// model from its juju status.
+type ReportModelPlansCommand struct {
+	baseCommand
+
+	out       cmd.Output
+	Status    string
+	ModelUUID string
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *ReportModelPlansCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.baseCommand.SetFlags(f)
+	c.addOutputFlags(f, &c.out, "tabular", map[string]cmd.Formatter{
+		"json":    cmd.FormatJson,
+		"yaml":    cmd.FormatYaml,
+		"tabular": formatModelPlansTabular,
+	})
+	f.StringVar(&c.ModelUUID, "model", "", "model uuid, when not included in the status")
+}
+
+// Info implements Command.Info.
+func (c *ReportModelPlansCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "report-model-plans",
+		Args:    "<status.json|->",
+		Purpose: reportModelPlansPurpose,
+		Doc:     reportModelPlansDoc,
+	}
+}
+
+// Init implements Command.Init.
+func (c *ReportModelPlansCommand) Init(args []string) error {
+	if len(args) < 1 {
+		return errors.New("missing status file")
+	}
+	c.Status = args[0]
+	if err := cmd.CheckEmpty(args[1:]); err != nil {
+		return errors.Errorf("unknown command line arguments: " + strings.Join(args[1:], ","))
+	}
+	return nil
+}
+
+// modelStatus holds the parts of the output of juju status used by
+// report-model-plans.
+type modelStatus struct {
+	Model struct {
+		Name string `json:"name"`
+		UUID string `json:"model-uuid"`
+	} `json:"model"`
+	Applications map[string]applicationStatus `json:"applications"`
+	// LegacyServices holds the applications in the output of older
+	// versions of juju.
+	LegacyServices map[string]applicationStatus `json:"services"`
+}
+
+type applicationStatus struct {
+	Charm string `json:"charm"`
+}
+
+// readStatus reads the juju status from the file or standard input.
+func (c *ReportModelPlansCommand) readStatus(ctx *cmd.Context) (*modelStatus, error) {
+	var data []byte
+	var err error
+	if c.Status == "-" {
+		data, err = ioutil.ReadAll(ctx.Stdin)
+	} else {
+		data, err = readFile(ctx.AbsPath(c.Status))
+	}
+	if err != nil {
+		return nil, errors.Annotate(err, "failed to read status")
+	}
+	var status modelStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, errors.Annotate(err, "failed to parse status")
+	}
+	if len(status.Applications) == 0 {
+		status.Applications = status.LegacyServices
+	}
+	return &status, nil
+}
+
+// Run implements Command.Run.
+func (c *ReportModelPlansCommand) Run(ctx *cmd.Context) error {
+	status, err := c.readStatus(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	modelUUID := c.ModelUUID
+	if modelUUID == "" {
+		modelUUID = status.Model.UUID
+	}
+	if modelUUID == "" {
+		return errors.New("model uuid not found in the status, specify it with --model")
+	}
+
+	client, cleanup, err := c.NewClient(ctx)
+	if err != nil {
+		return errors.Annotate(err, "failed to create an http client")
+	}
+	defer cleanup()
+	apiClient, err := newClient(c.ServiceURL, client)
+	if err != nil {
+		return errors.Annotate(err, "failed to create a plan API client")
+	}
+
+	names := make([]string, 0, len(status.Applications))
+	for name := range status.Applications {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	r := &modelPlanReporter{
+		client:    apiClient,
+		modelUUID: modelUUID,
+		revisions: make(map[string]int),
+		details:   make(map[string]*wireformat.PlanDetails),
+	}
+	apps := make([]modelPlanApplication, len(names))
+	for i, name := range names {
+		ctx.Verbosef("retrieving the plan of application %v", name)
+		apps[i], err = r.report(name, status.Applications[name].Charm)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(c.out.Write(ctx, apps))
+}
+
+// modelPlanReporter looks up the plans of the applications of a model,
+// retrieving the revisions and details of each plan once.
+type modelPlanReporter struct {
+	client    api.PlanClient
+	modelUUID string
+	revisions map[string]int
+	details   map[string]*wireformat.PlanDetails
+}
+
+// report returns the plan report of the application.
+func (r *modelPlanReporter) report(name, charmURL string) (modelPlanApplication, error) {
+	app := modelPlanApplication{
+		Application: name,
+		Charm:       charmURL,
+	}
+	auths, err := r.client.GetAuthorizations(context.Background(), wireformat.AuthorizationQuery{
+		EnvironmentUUID: r.modelUUID,
+		ServiceName:     name,
+		IncludePlan:     true,
+	})
+	if err != nil {
+		return app, errors.Annotatef(err, "failed to retrieve authorizations of application %v", name)
+	}
+	if len(auths) == 0 {
+		plans, err := r.client.GetPlansForCharm(context.Background(), charmURL)
+		if errors.IsNotFound(err) {
+			plans = nil
+		} else if err != nil {
+			return app, errors.Annotatef(err, "failed to retrieve plans of charm %v", charmURL)
+		}
+		if len(plans) == 0 {
+			app.Message = "no plans"
+		} else {
+			app.Message = "not authorized"
+		}
+		return app, nil
+	}
+	auth := auths[0]
+	for _, a := range auths[1:] {
+		if a.CreatedOn.After(auth.CreatedOn) {
+			auth = a
+		}
+	}
+	app.Plan = planRevision(auth.PlanURL, auth.PlanID)
+	if auth.PlanID != "" {
+		id, err := wireformat.ParsePlanIDWithOptionalRevision(auth.PlanID)
+		if err != nil {
+			return app, errors.Annotatef(err, "failed to parse plan id of application %v", name)
+		}
+		app.Revision = id.Revision
+	}
+
+	latest, err := r.latestRevision(auth.PlanURL)
+	if err != nil {
+		return app, errors.Trace(err)
+	}
+	app.LatestRevision = latest
+	if app.Revision != 0 && latest > app.Revision {
+		app.UpdateAvailable = true
+		app.Message = fmt.Sprintf("revision %d released", latest)
+	}
+
+	details, err := r.planDetails(auth.PlanURL)
+	if err != nil {
+		return app, errors.Trace(err)
+	}
+	for _, ch := range details.Charms {
+		if wireformat.CharmBaseURL(ch.CharmURL) == wireformat.CharmBaseURL(charmURL) && planSuspended(ch.Events) {
+			app.Suspended = true
+		}
+	}
+	return app, nil
+}
+
+// latestRevision returns the latest released revision of the plan, or 0
+// if no revision has been released.
+func (r *modelPlanReporter) latestRevision(planURL string) (int, error) {
+	if latest, ok := r.revisions[planURL]; ok {
+		return latest, nil
+	}
+	plans, err := r.client.GetPlanRevisions(context.Background(), planURL)
+	if err != nil {
+		return 0, errors.Annotatef(err, "failed to retrieve revisions of plan %v", planURL)
+	}
+	latest := 0
+	for _, p := range plans {
+		if !p.Released {
+			continue
+		}
+		id, err := wireformat.ParsePlanIDWithOptionalRevision(p.Id)
+		if err != nil {
+			return 0, errors.Annotatef(err, "failed to parse plan id %q", p.Id)
+		}
+		if id.Revision > latest {
+			latest = id.Revision
+		}
+	}
+	r.revisions[planURL] = latest
+	return latest, nil
+}
+
+// planDetails returns the details of the plan.
+func (r *modelPlanReporter) planDetails(planURL string) (*wireformat.PlanDetails, error) {
+	if details, ok := r.details[planURL]; ok {
+		return details, nil
+	}
+	details, err := r.client.GetPlanDetails(context.Background(), planURL)
+	if err != nil {
+		return nil, errors.Annotatef(err, "failed to retrieve plan %v details", planURL)
+	}
+	r.details[planURL] = details
+	return details, nil
+}
+
+// modelPlanApplication is the output format of the plan report of an
+// application.
+type modelPlanApplication struct {
+	Application     string `json:"application" yaml:"application"`
+	Charm           string `json:"charm" yaml:"charm"`
+	Plan            string `json:"plan,omitempty" yaml:"plan,omitempty"`
+	Revision        int    `json:"revision,omitempty" yaml:"revision,omitempty"`
+	LatestRevision  int    `json:"latest-revision,omitempty" yaml:"latest-revision,omitempty"`
+	UpdateAvailable bool   `json:"update-available" yaml:"update-available"`
+	Suspended       bool   `json:"suspended" yaml:"suspended"`
+	Message         string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+func formatModelPlansTabular(w io.Writer, value interface{}) error {
+	apps, ok := value.([]modelPlanApplication)
+	if !ok {
+		return errors.Errorf("expected value of type %T, got %T", apps, value)
+	}
+	revision := func(rev int) string {
+		if rev == 0 {
+			return ""
+		}
+		return fmt.Sprint(rev)
+	}
+	table := uitable.New()
+	table.MaxColWidth = 50
+	table.Wrap = true
+	table.AddRow("APPLICATION", "CHARM", "PLAN", "REVISION", "LATEST", "SUSPENDED", "MESSAGE")
+	for _, app := range apps {
+		suspended := ""
+		if app.Plan != "" {
+			suspended = fmt.Sprint(app.Suspended)
+		}
+		table.AddRow(app.Application, app.Charm, app.Plan, revision(app.Revision), revision(app.LatestRevision), suspended, app.Message)
+	}
+	_, err := w.Write(table.Bytes())
+	if err != nil {
+		return errors.Annotatef(err, "failed to print table")
+	}
+	return nil
+}
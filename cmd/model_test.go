@@ -0,0 +1,161 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd_test
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/juju/cmd/cmdtesting"
+	"github.com/juju/errors"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/macaroon-bakery.v2/httpbakery"
+
+	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/api/wireformat"
+	"github.com/juju/plans-client/cmd"
+	plantesting "github.com/juju/plans-client/testing"
+)
+
+const testStatus = `{
+  "model": {"name": "default", "type": "iaas", "controller": "ctrl"},
+  "machines": {},
+  "applications": {
+    "web": {"charm": "cs:~testisv/charm2-1", "charm-name": "charm2", "charm-rev": 1},
+    "db": {"charm": "cs:~testisv/charm1-0", "charm-name": "charm1", "charm-rev": 0}
+  }
+}`
+
+type reportModelPlansSuite struct {
+	testing.CleanupSuite
+	mockAPI *plantesting.MockPlanClient
+	status  string
+}
+
+var _ = gc.Suite(&reportModelPlansSuite{})
+
+func (s *reportModelPlansSuite) SetUpTest(c *gc.C) {
+	s.CleanupSuite.SetUpTest(c)
+	s.PatchEnvironment("GOCOOKIES", filepath.Join(c.MkDir(), "cookies"))
+	s.mockAPI = plantesting.NewMockPlanClient()
+	s.mockAPI.Authorizations = []wireformat.Authorization{{
+		AuthorizationID: "auth-1",
+		PlanURL:         "testisv/default",
+		PlanID:          "testisv/default/1",
+		CreatedOn:       time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, {
+		AuthorizationID: "auth-2",
+		PlanURL:         "testisv/default",
+		PlanID:          "testisv/default/2",
+		CreatedOn:       time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+	}}
+	s.mockAPI.PlanRevisions = []wireformat.Plan{
+		{Id: "testisv/default/1", URL: "testisv/default", Released: true},
+		{Id: "testisv/default/2", URL: "testisv/default", Released: true},
+		{Id: "testisv/default/3", URL: "testisv/default", Released: true},
+		{Id: "testisv/default/4", URL: "testisv/default"},
+	}
+	s.status = testStatus
+	s.PatchValue(cmd.NewClient, func(string, *httpbakery.Client) (api.PlanClient, error) {
+		return s.mockAPI, nil
+	})
+	s.PatchValue(cmd.ReadFile, func(string) ([]byte, error) {
+		return []byte(s.status), nil
+	})
+}
+
+func (s *reportModelPlansSuite) TestInvalidArgs(c *gc.C) {
+	_, err := cmdtesting.RunCommand(c, cmd.NewReportModelPlansCommand())
+	c.Assert(err, gc.ErrorMatches, "missing status file")
+	_, err = cmdtesting.RunCommand(c, cmd.NewReportModelPlansCommand(), "status.json", "extra")
+	c.Assert(err, gc.ErrorMatches, "unknown command line arguments: extra")
+	_, err = cmdtesting.RunCommand(c, cmd.NewReportModelPlansCommand(), "status.json")
+	c.Assert(err, gc.ErrorMatches, "model uuid not found in the status, specify it with --model")
+	s.status = "model: default"
+	_, err = cmdtesting.RunCommand(c, cmd.NewReportModelPlansCommand(), "status.json", "--model", "model-uuid")
+	c.Assert(err, gc.ErrorMatches, "failed to parse status: .*")
+	s.mockAPI.CheckNoCalls(c)
+}
+
+func (s *reportModelPlansSuite) TestReport(c *gc.C) {
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewReportModelPlansCommand(), "status.json", "--model", "model-uuid")
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.CheckCallNames(c, "GetAuthorizations", "GetPlanRevisions", "GetPlanDetails", "GetAuthorizations")
+	s.mockAPI.CheckCall(c, 0, "GetAuthorizations", wireformat.AuthorizationQuery{
+		EnvironmentUUID: "model-uuid",
+		ServiceName:     "db",
+		IncludePlan:     true,
+	})
+	s.mockAPI.CheckCall(c, 1, "GetPlanRevisions", "testisv/default")
+	s.mockAPI.CheckCall(c, 2, "GetPlanDetails", "testisv/default")
+	s.mockAPI.CheckCall(c, 3, "GetAuthorizations", wireformat.AuthorizationQuery{
+		EnvironmentUUID: "model-uuid",
+		ServiceName:     "web",
+		IncludePlan:     true,
+	})
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, ""+
+		"APPLICATION\tCHARM               \tPLAN             \tREVISION\tLATEST\tSUSPENDED\tMESSAGE            \n"+
+		"db         \tcs:~testisv/charm1-0\ttestisv/default/2\t2       \t3     \tfalse    \trevision 3 released\n"+
+		"web        \tcs:~testisv/charm2-1\ttestisv/default/2\t2       \t3     \ttrue     \trevision 3 released\n")
+}
+
+func (s *reportModelPlansSuite) TestReportFromStdin(c *gc.C) {
+	s.PatchValue(cmd.ReadFile, func(string) ([]byte, error) {
+		return nil, errors.New("unexpected read")
+	})
+	s.mockAPI.Authorizations = s.mockAPI.Authorizations[1:]
+	s.mockAPI.PlanRevisions = s.mockAPI.PlanRevisions[:2]
+	status := `{"model": {"name": "default", "model-uuid": "model-uuid"}, "services": {"db": {"charm": "cs:~testisv/charm1-0"}}}`
+	command := cmd.NewReportModelPlansCommand()
+	ctx := cmdtesting.Context(c)
+	ctx.Stdin = strings.NewReader(status)
+	err := cmdtesting.InitCommand(command, []string{"-", "--format", "json"})
+	c.Assert(err, jc.ErrorIsNil)
+	err = command.Run(ctx)
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.CheckCall(c, 0, "GetAuthorizations", wireformat.AuthorizationQuery{
+		EnvironmentUUID: "model-uuid",
+		ServiceName:     "db",
+		IncludePlan:     true,
+	})
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, `[{"application":"db","charm":"cs:~testisv/charm1-0","plan":"testisv/default/2","revision":2,"latest-revision":2,"update-available":false,"suspended":false}]
+`)
+}
+
+func (s *reportModelPlansSuite) TestSuspendedForAnotherRevision(c *gc.C) {
+	s.mockAPI.Authorizations = s.mockAPI.Authorizations[1:]
+	s.status = `{"model": {"name": "default", "model-uuid": "model-uuid"}, "services": {"web": {"charm": "cs:~testisv/charm2-7"}}}`
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewReportModelPlansCommand(), "status.json", "--format", "json")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, `[{"application":"web","charm":"cs:~testisv/charm2-7","plan":"testisv/default/2","revision":2,"latest-revision":3,"update-available":true,"suspended":true,"message":"revision 3 released"}]
+`)
+}
+
+func (s *reportModelPlansSuite) TestNotAuthorized(c *gc.C) {
+	s.mockAPI.Authorizations = nil
+	s.mockAPI.SetErrors(nil, errors.NotFoundf("plans"))
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewReportModelPlansCommand(), "status.json", "--model", "model-uuid", "--format", "yaml")
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.CheckCallNames(c, "GetAuthorizations", "GetPlansForCharm", "GetAuthorizations", "GetPlansForCharm")
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, `- application: db
+  charm: cs:~testisv/charm1-0
+  update-available: false
+  suspended: false
+  message: no plans
+- application: web
+  charm: cs:~testisv/charm2-1
+  update-available: false
+  suspended: false
+  message: not authorized
+`)
+}
+
+func (s *reportModelPlansSuite) TestAPIError(c *gc.C) {
+	s.mockAPI.SetErrors(errors.New("boom"))
+	_, err := cmdtesting.RunCommand(c, cmd.NewReportModelPlansCommand(), "status.json", "--model", "model-uuid")
+	c.Assert(err, gc.ErrorMatches, "failed to retrieve authorizations of application db: boom")
+}
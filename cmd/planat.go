@@ -0,0 +1,165 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gosuri/uitable"
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+
+	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/api/wireformat"
+)
+
+const planAtDoc = `
+plan-at reports which revision of a plan was effective for a charm at a
+point in time, and whether the plan was suspended for the charm at that
+time, by replaying the release, attach, suspend and resume events of the
+plan.
+
+The time is specified in RFC3339 format or as a YYYY-MM-DD date, which
+is interpreted as midnight UTC. All the plans attached to the charm are
+reported, unless --plan is specified.
+Examples
+plan-at cs:~canonical/landscape-1 2017-03-01T12:00:00Z
+	reports the revisions of the plans of the charm effective at noon UTC
+	on March 1st 2017.
+plan-at cs:~canonical/landscape-1 2017-03-01 --plan canonical/landscape-default
+	reports the revision of the canonical/landscape-default plan effective
+	for the charm at the start of March 1st 2017.
+`
+
+const planAtPurpose = "show the plan revision effective for a charm at a point in time"
+
+// NewPlanAtCommand returns a new PlanAtCommand.
+func NewPlanAtCommand() cmd.Command {
+	return &PlanAtCommand{}
+}
+
+// PlanAtCommand reports the plan revisions effective for a charm at a
+// point in time.
+type PlanAtCommand struct {
+	baseCommand
+
+	out      cmd.Output
+	CharmURL string
+	Time     time.Time
+	PlanURL  string
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *PlanAtCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.baseCommand.SetFlags(f)
+	c.addOutputFlags(f, &c.out, "tabular", map[string]cmd.Formatter{
+		"json":    cmd.FormatJson,
+		"yaml":    cmd.FormatYaml,
+		"tabular": formatPlanStatesTabular,
+	})
+	f.StringVar(&c.PlanURL, "plan", "", "only report the specified plan")
+}
+
+// Info implements Command.Info.
+func (c *PlanAtCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "plan-at",
+		Args:    "<charm url> <time>",
+		Purpose: planAtPurpose,
+		Doc:     planAtDoc,
+	}
+}
+
+// Init implements Command.Init.
+func (c *PlanAtCommand) Init(args []string) error {
+	if len(args) < 2 {
+		return errors.New("missing charm url and time")
+	}
+	c.CharmURL = args[0]
+	t, err := wireformat.ParseTimestamp(args[1])
+	if err != nil {
+		return errors.Trace(err)
+	}
+	c.Time = t
+	if err := cmd.CheckEmpty(args[2:]); err != nil {
+		return errors.Errorf("unknown command line arguments: " + strings.Join(args[2:], ","))
+	}
+	if c.PlanURL != "" {
+		if c.PlanURL, err = c.unqualifyPlan(c.PlanURL); err != nil {
+			return errors.Trace(err)
+		}
+		if _, err := wireformat.ParsePlanURL(c.PlanURL); err != nil {
+			return errors.Annotate(err, "invalid plan url")
+		}
+	}
+	return nil
+}
+
+// Run implements Command.Run.
+func (c *PlanAtCommand) Run(ctx *cmd.Context) error {
+	client, cleanup, err := c.NewClient(ctx)
+	if err != nil {
+		return errors.Annotate(err, "failed to create an http client")
+	}
+	defer cleanup()
+	apiClient, err := newClient(c.ServiceURL, client)
+	if err != nil {
+		return errors.Annotate(err, "failed to create a plan API client")
+	}
+
+	planURLs := []string{c.PlanURL}
+	if c.PlanURL == "" {
+		plans, err := apiClient.GetPlansForCharm(context.Background(), c.CharmURL)
+		if err != nil {
+			return errors.Annotatef(err, "failed to retrieve plans of charm %v", c.CharmURL)
+		}
+		planURLs = ownerPlanURLs(plans)
+		if len(planURLs) == 0 {
+			return errors.Errorf("no plans attached to charm %v", c.CharmURL)
+		}
+	}
+	states := make([]wireformat.PlanState, len(planURLs))
+	for i, planURL := range planURLs {
+		ctx.Verbosef("replaying the events of plan %v", planURL)
+		state, err := api.PlanAt(context.Background(), apiClient, planURL, c.CharmURL, c.Time)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		states[i] = *state
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].PlanURL < states[j].PlanURL
+	})
+	return errors.Trace(c.out.Write(ctx, states))
+}
+
+func formatPlanStatesTabular(w io.Writer, value interface{}) error {
+	states, ok := value.([]wireformat.PlanState)
+	if !ok {
+		return errors.Errorf("expected value of type %T, got %T", states, value)
+	}
+	since := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+	table := uitable.New()
+	table.MaxColWidth = 50
+	table.Wrap = true
+	table.AddRow("PLAN", "EFFECTIVE REVISION", "EFFECTIVE SINCE", "ATTACHED", "SUSPENDED", "SUSPENDED SINCE")
+	for _, s := range states {
+		table.AddRow(s.PlanURL, s.PlanID, since(s.EffectiveSince), s.Attached, s.Suspended, since(s.SuspendedSince))
+	}
+	_, err := w.Write(table.Bytes())
+	if err != nil {
+		return errors.Annotatef(err, "failed to print table")
+	}
+	return nil
+}
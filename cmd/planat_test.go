@@ -0,0 +1,122 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd_test
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/juju/cmd/cmdtesting"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/macaroon-bakery.v2/httpbakery"
+
+	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/api/wireformat"
+	"github.com/juju/plans-client/cmd"
+	plantesting "github.com/juju/plans-client/testing"
+)
+
+type planAtSuite struct {
+	testing.CleanupSuite
+	mockAPI *plantesting.MockPlanClient
+}
+
+var _ = gc.Suite(&planAtSuite{})
+
+func (s *planAtSuite) SetUpTest(c *gc.C) {
+	s.CleanupSuite.SetUpTest(c)
+	s.PatchEnvironment("GOCOOKIES", filepath.Join(c.MkDir(), "cookies"))
+	s.mockAPI = plantesting.NewMockPlanClient()
+	s.mockAPI.PlanRevisions = []wireformat.Plan{
+		{Id: "testisv/default/1", URL: "testisv/default", Released: true},
+	}
+	s.PatchValue(cmd.NewClient, func(string, *httpbakery.Client) (api.PlanClient, error) {
+		return s.mockAPI, nil
+	})
+}
+
+func (s *planAtSuite) TestInvalidArgs(c *gc.C) {
+	tests := []struct {
+		about string
+		args  []string
+		err   string
+	}{{
+		about: "missing args",
+		args:  []string{"cs:~testisv/charm2-1"},
+		err:   "missing charm url and time",
+	}, {
+		about: "invalid time",
+		args:  []string{"cs:~testisv/charm2-1", "yesterday"},
+		err:   `timestamp "yesterday" not valid`,
+	}, {
+		about: "unknown arguments",
+		args:  []string{"cs:~testisv/charm2-1", "2017-01-01", "extra"},
+		err:   "unknown command line arguments: extra",
+	}, {
+		about: "invalid plan",
+		args:  []string{"cs:~testisv/charm2-1", "2017-01-01", "--plan", "testisv/default/1"},
+		err:   `invalid plan url: plan url "testisv/default/1" not valid`,
+	}}
+	for i, t := range tests {
+		c.Logf("running test %d: %s", i, t.about)
+		_, err := cmdtesting.RunCommand(c, cmd.NewPlanAtCommand(), t.args...)
+		c.Assert(err, gc.ErrorMatches, t.err)
+	}
+	s.mockAPI.CheckNoCalls(c)
+}
+
+func (s *planAtSuite) TestPlanAt(c *gc.C) {
+	effective := time.Date(2015, 1, 1, 1, 0, 0, 0, time.UTC)
+	s.mockAPI.RevisionDetails = map[string]*wireformat.PlanDetails{
+		"testisv/default/1": {
+			Plan:     wireformat.Plan{Id: "testisv/default/1", URL: "testisv/default", EffectiveTime: &effective},
+			Released: &wireformat.Event{Type: "release", Time: time.Date(2016, 1, 1, 1, 0, 0, 0, time.UTC)},
+			Charms: []wireformat.CharmPlanDetail{{
+				CharmURL: "cs:~testisv/charm2-1",
+				Attached: wireformat.Event{Type: "create", Time: time.Date(2015, 1, 1, 1, 0, 0, 0, time.UTC)},
+				Events: []wireformat.Event{
+					{Type: "suspend", Time: time.Date(2015, 1, 1, 1, 2, 3, 0, time.UTC)},
+				},
+			}},
+		},
+	}
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewPlanAtCommand(), "cs:~testisv/charm2-1", "2016-06-01T00:00:00+02:00")
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.CheckCallNames(c, "GetPlansForCharm", "GetPlanRevisions", "GetPlanDetails")
+	s.mockAPI.CheckCall(c, 0, "GetPlansForCharm", "cs:~testisv/charm2-1")
+	s.mockAPI.CheckCall(c, 2, "GetPlanDetails", "testisv/default/1")
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, ""+
+		"PLAN           \tEFFECTIVE REVISION\tEFFECTIVE SINCE     \tATTACHED\tSUSPENDED\tSUSPENDED SINCE     \n"+
+		"testisv/default\ttestisv/default/1 \t2015-01-01T01:00:00Z\ttrue    \ttrue     \t2015-01-01T01:02:03Z\n")
+}
+
+func (s *planAtSuite) TestPlanAtDate(c *gc.C) {
+	s.mockAPI.RevisionDetails = map[string]*wireformat.PlanDetails{
+		"testisv/premium/1": {
+			Plan:     wireformat.Plan{Id: "testisv/premium/1", URL: "testisv/premium"},
+			Released: &wireformat.Event{Type: "release", Time: time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)},
+			Charms: []wireformat.CharmPlanDetail{{
+				CharmURL: "cs:~testisv/charm2-0",
+				Attached: wireformat.Event{Type: "create", Time: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)},
+			}},
+		},
+	}
+	s.mockAPI.PlanRevisions = []wireformat.Plan{
+		{Id: "testisv/premium/1", URL: "testisv/premium", Released: true},
+	}
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewPlanAtCommand(), "cs:~testisv/charm2-1", "2017-03-01", "--plan", "testisv/premium", "--format", "json")
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.CheckCallNames(c, "GetPlanRevisions", "GetPlanDetails")
+	s.mockAPI.CheckCall(c, 0, "GetPlanRevisions", "testisv/premium")
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, `[{"plan-url":"testisv/premium","charm-url":"cs:~testisv/charm2-1","time":"2017-03-01T00:00:00Z","plan-id":"testisv/premium/1","effective-since":"2017-03-01T00:00:00Z","attached":true,"attached-since":"2017-01-01T00:00:00Z","suspended":false}]
+`)
+}
+
+func (s *planAtSuite) TestNoPlans(c *gc.C) {
+	s.mockAPI.CharmPlans = []wireformat.Plan{}
+	_, err := cmdtesting.RunCommand(c, cmd.NewPlanAtCommand(), "cs:~testisv/charm2-1", "2017-03-01")
+	c.Assert(err, gc.ErrorMatches, `no plans attached to charm cs:~testisv/charm2-1`)
+}
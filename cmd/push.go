@@ -4,6 +4,7 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"strings"
@@ -12,16 +13,36 @@ import (
 	"github.com/juju/errors"
 	"github.com/juju/gnuflag"
 	"gopkg.in/macaroon-bakery.v2/httpbakery"
+	"gopkg.in/yaml.v2"
 
 	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/api/wireformat"
 )
 
 const pushDoc = `
 push-plan is used to upload a new plan
+
+The description, price and release notes of the new revision may be set
+with flags, or in a YAML front-matter block at the top of the plan file:
+
+	---
+	description: Landscape monitoring
+	price: 1.00 USD per unit
+	release-notes: Lower the price of monitored units.
+	---
+	metrics:
+	  ...
+
+The front-matter block is not part of the plan definition. A block holding
+any other key is taken to be part of the plan definition. Flags take
+precedence over the front-matter.
 Examples
 push-plan plan.yaml canonical/default
 	uploads a new plan owned by canonical under the name default with the
 	definition contained in the file plan.yaml
+push-plan plan.yaml canonical/default --release-notes "Lower the price."
+	uploads a new revision of the canonical/default plan with release
+	notes describing the changes made in the revision
 `
 const pushPlanPurpose = "push new plan"
 
@@ -42,12 +63,19 @@ type PushCommand struct {
 	out      cmd.Output
 	Filename string
 	PlanURL  string
+
+	PlanDescription string
+	PlanPrice       string
+	ReleaseNotes    string
 }
 
 // SetFlags implements Command.SetFlags.
 func (c *PushCommand) SetFlags(f *gnuflag.FlagSet) {
 	c.baseCommand.ServiceURL = defaultServiceURL()
 	c.baseCommand.SetFlags(f)
+	f.StringVar(&c.PlanDescription, "description", "", "description of the plan")
+	f.StringVar(&c.PlanPrice, "price", "", "price of the plan")
+	f.StringVar(&c.ReleaseNotes, "release-notes", "", "notes on the changes made in the new revision")
 }
 
 // Description returns a one-line description of the command.
@@ -76,7 +104,11 @@ func (c *PushCommand) Init(args []string) error {
 		return errors.Errorf("unknown command line arguments: " + strings.Join(args, ","))
 	}
 
-	c.PlanURL = pn
+	planURL, err := c.unqualifyPlan(pn)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	c.PlanURL = planURL
 	c.Filename = fn
 	return nil
 }
@@ -88,6 +120,19 @@ func (c *PushCommand) Run(ctx *cmd.Context) error {
 	if err != nil {
 		return errors.Annotatef(err, "could not read the rating plan from file %q", c.Filename)
 	}
+	metadata, definition, err := splitFrontMatter(data)
+	if err != nil {
+		return errors.Annotatef(err, "could not read the rating plan from file %q", c.Filename)
+	}
+	if c.PlanDescription != "" {
+		metadata.Description = c.PlanDescription
+	}
+	if c.PlanPrice != "" {
+		metadata.Price = c.PlanPrice
+	}
+	if c.ReleaseNotes != "" {
+		metadata.ReleaseNotes = c.ReleaseNotes
+	}
 
 	client, cleanup, err := c.NewClient(ctx)
 	if err != nil {
@@ -99,7 +144,7 @@ func (c *PushCommand) Run(ctx *cmd.Context) error {
 	if err != nil {
 		return errors.Annotate(err, "failed to create a plan API client")
 	}
-	plan, err := apiClient.Save(context.Background(), c.PlanURL, string(data))
+	plan, err := api.SaveWithMetadata(context.Background(), apiClient, c.PlanURL, string(definition), metadata)
 	if err != nil {
 		return errors.Annotate(err, "failed to save the plan")
 	}
@@ -107,3 +152,69 @@ func (c *PushCommand) Run(ctx *cmd.Context) error {
 	fmt.Fprintf(ctx.Stdout, "%v\n", plan.Id)
 	return nil
 }
+
+// frontMatterDelimiter delimits the front-matter block of a plan file.
+const frontMatterDelimiter = "---"
+
+// frontMatterKeys are the keys a front-matter block may hold.
+var frontMatterKeys = map[string]bool{
+	"description":   true,
+	"price":         true,
+	"release-notes": true,
+}
+
+// splitFrontMatter splits the plan file into the metadata held in its
+// front-matter block and the plan definition. A file that does not start
+// with a front-matter block is a plan definition. So that plan files
+// written as YAML documents are not mistaken for front-matter, a block is
+// only front-matter if it holds nothing but metadata keys.
+func splitFrontMatter(data []byte) (wireformat.PlanMetadata, []byte, error) {
+	var metadata wireformat.PlanMetadata
+	first, rest := splitLine(data)
+	if string(bytes.TrimRight(first, " \t\r")) != frontMatterDelimiter {
+		return metadata, data, nil
+	}
+	var block []byte
+	for len(rest) > 0 {
+		var line []byte
+		line, rest = splitLine(rest)
+		if string(bytes.TrimRight(line, " \t\r")) == frontMatterDelimiter {
+			if !isFrontMatter(block) {
+				return metadata, data, nil
+			}
+			if err := yaml.UnmarshalStrict(block, &metadata); err != nil {
+				return metadata, nil, errors.Annotate(err, "invalid front-matter")
+			}
+			return metadata, rest, nil
+		}
+		block = append(block, line...)
+		block = append(block, '\n')
+	}
+	// A single delimiter starts a YAML document rather than a
+	// front-matter block.
+	return metadata, data, nil
+}
+
+// isFrontMatter returns true if the block is a YAML mapping of
+// front-matter keys.
+func isFrontMatter(block []byte) bool {
+	var fields map[string]interface{}
+	if err := yaml.Unmarshal(block, &fields); err != nil {
+		return false
+	}
+	for key := range fields {
+		if !frontMatterKeys[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// splitLine returns the first line of data, without its line ending, and
+// the data that follows it.
+func splitLine(data []byte) (line, rest []byte) {
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return data[:i], data[i+1:]
+	}
+	return data, nil
+}
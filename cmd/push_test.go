@@ -4,6 +4,8 @@
 package cmd_test
 
 import (
+	"path/filepath"
+
 	"github.com/juju/cmd/cmdtesting"
 	"github.com/juju/testing"
 	jc "github.com/juju/testing/checkers"
@@ -11,6 +13,7 @@ import (
 	"gopkg.in/macaroon-bakery.v2/httpbakery"
 
 	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/api/wireformat"
 	"github.com/juju/plans-client/cmd"
 	plantesting "github.com/juju/plans-client/testing"
 )
@@ -24,6 +27,7 @@ type pushSuite struct {
 var _ = gc.Suite(&pushSuite{})
 
 func (s *pushSuite) SetUpTest(c *gc.C) {
+	s.PatchEnvironment("GOCOOKIES", filepath.Join(c.MkDir(), "cookies"))
 	s.stub = &testing.Stub{}
 
 	s.mockAPI = plantesting.NewMockPlanClient()
@@ -51,12 +55,22 @@ func (s *pushSuite) TestCommand(c *gc.C) {
 		about:   "everything works",
 		args:    []string{"example.yaml", "testisv/default", "--url", "localhost:0"},
 		stdout:  "testisv/default/17\n",
-		apiCall: []interface{}{"testisv/default", plantesting.TestPlan},
+		apiCall: []interface{}{"testisv/default", plantesting.TestPlan, wireformat.PlanMetadata{}},
+	}, {
+		about:  "metadata flags",
+		args:   []string{"example.yaml", "testisv/default", "--description", "a test plan", "--price", "1.00 USD", "--release-notes", "lower the price"},
+		stdout: "testisv/default/17\n",
+		apiCall: []interface{}{"testisv/default", plantesting.TestPlan, wireformat.PlanMetadata{
+			Description:  "a test plan",
+			Price:        "1.00 USD",
+			ReleaseNotes: "lower the price",
+		}},
 	},
 	}
 
 	for i, t := range tests {
 		c.Logf("Running test %d %s", i, t.about)
+		s.mockAPI.ResetCalls()
 		ctx, err := cmdtesting.RunCommand(c, cmd.NewPushCommand(), t.args...)
 		if t.err != "" {
 			c.Assert(err, gc.ErrorMatches, t.err)
@@ -64,10 +78,53 @@ func (s *pushSuite) TestCommand(c *gc.C) {
 		} else {
 			c.Assert(err, jc.ErrorIsNil)
 			c.Assert(s.mockAPI.Calls(), gc.HasLen, 1)
-			s.mockAPI.CheckCall(c, 0, "Save", t.apiCall...)
+			s.mockAPI.CheckCall(c, 0, "SaveWithMetadata", t.apiCall...)
 		}
 		if ctx != nil {
 			c.Assert(cmdtesting.Stdout(ctx), gc.Equals, t.stdout)
 		}
 	}
 }
+
+func (s *pushSuite) TestFrontMatter(c *gc.C) {
+	s.PatchValue(cmd.ReadFile, func(string) ([]byte, error) {
+		return []byte("---\ndescription: a test plan\nprice: 1.00 USD\nrelease-notes: |\n  lower the price\n---\n" + plantesting.TestPlan), nil
+	})
+	_, err := cmdtesting.RunCommand(c, cmd.NewPushCommand(), "example.yaml", "testisv/default", "--price", "2.00 USD")
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.CheckCall(c, 0, "SaveWithMetadata", "testisv/default", plantesting.TestPlan, wireformat.PlanMetadata{
+		Description:  "a test plan",
+		Price:        "2.00 USD",
+		ReleaseNotes: "lower the price\n",
+	})
+}
+
+func (s *pushSuite) TestDocumentStart(c *gc.C) {
+	definition := "---\n" + plantesting.TestPlan
+	s.PatchValue(cmd.ReadFile, func(string) ([]byte, error) {
+		return []byte(definition), nil
+	})
+	_, err := cmdtesting.RunCommand(c, cmd.NewPushCommand(), "example.yaml", "testisv/default")
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.CheckCall(c, 0, "SaveWithMetadata", "testisv/default", definition, wireformat.PlanMetadata{})
+}
+
+func (s *pushSuite) TestDocuments(c *gc.C) {
+	// A plan file holding several YAML documents has no front-matter.
+	definition := "---\n" + plantesting.TestPlan + "---\n" + plantesting.TestPlan
+	s.PatchValue(cmd.ReadFile, func(string) ([]byte, error) {
+		return []byte(definition), nil
+	})
+	_, err := cmdtesting.RunCommand(c, cmd.NewPushCommand(), "example.yaml", "testisv/default")
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.CheckCall(c, 0, "SaveWithMetadata", "testisv/default", definition, wireformat.PlanMetadata{})
+}
+
+func (s *pushSuite) TestInvalidFrontMatter(c *gc.C) {
+	s.PatchValue(cmd.ReadFile, func(string) ([]byte, error) {
+		return []byte("---\nprice:\n  amount: 1.00\n---\n" + plantesting.TestPlan), nil
+	})
+	_, err := cmdtesting.RunCommand(c, cmd.NewPushCommand(), "example.yaml", "testisv/default")
+	c.Assert(err, gc.ErrorMatches, `(?s)could not read the rating plan from file "example.yaml": invalid front-matter: .*`)
+	s.mockAPI.CheckNoCalls(c)
+}
@@ -12,13 +12,19 @@ import (
 	"github.com/juju/cmd"
 	"github.com/juju/errors"
 	"github.com/juju/gnuflag"
+
+	"github.com/juju/plans-client/api/wireformat"
 )
 
 const releaseDoc = `
 release-plan is used to release the specified plan revision
-Example
+Examples
 release-plan canonical/foobar/1
 	release revision 1 of the canonical/foobar plan
+release-plan canonical/foobar/latest
+	release the latest revision of the canonical/foobar plan
+
+See "help plan-urls" for all the revision selectors.
 `
 const releasePlanPurpose = "release the plan"
 
@@ -26,6 +32,8 @@ const releasePlanPurpose = "release the plan"
 type ReleaseCommand struct {
 	baseCommand
 	Plan string
+
+	selector *wireformat.PlanSelector
 }
 
 // NewReleaseCommand creates a new ReleaseCommand.
@@ -59,11 +67,20 @@ func (c *ReleaseCommand) Init(args []string) error {
 	if len(args) < 1 {
 		return errors.New("missing plan")
 	}
-	c.Plan = args[0]
+	plan, err := c.unqualifyPlan(args[0])
+	if err != nil {
+		return errors.Trace(err)
+	}
+	c.Plan = plan
 
 	if err := cmd.CheckEmpty(args[1:]); err != nil {
 		return errors.Errorf("unknown command line arguments: " + strings.Join(args[1:], ","))
 	}
+	sel, err := wireformat.ParsePlanSelector(c.Plan)
+	if err != nil {
+		return errors.Annotate(err, "invalid plan")
+	}
+	c.selector = sel
 	return nil
 }
 
@@ -78,7 +95,11 @@ func (c *ReleaseCommand) Run(ctx *cmd.Context) error {
 	if err != nil {
 		return errors.Annotate(err, "failed to create a plan API client")
 	}
-	plan, err := apiClient.Release(context.Background(), c.Plan)
+	planID, err := resolvePlanRevision(apiClient, c.selector, c.Plan)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	plan, err := apiClient.Release(context.Background(), planID)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -4,6 +4,8 @@
 package cmd_test
 
 import (
+	"path/filepath"
+
 	"github.com/juju/cmd/cmdtesting"
 	"github.com/juju/testing"
 	jc "github.com/juju/testing/checkers"
@@ -11,6 +13,7 @@ import (
 	"gopkg.in/macaroon-bakery.v2/httpbakery"
 
 	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/api/wireformat"
 	"github.com/juju/plans-client/cmd"
 	plantesting "github.com/juju/plans-client/testing"
 )
@@ -24,6 +27,7 @@ type releaseSuite struct {
 var _ = gc.Suite(&releaseSuite{})
 
 func (s *releaseSuite) SetUpTest(c *gc.C) {
+	s.PatchEnvironment("GOCOOKIES", filepath.Join(c.MkDir(), "cookies"))
 	s.stub = &testing.Stub{}
 
 	s.mockAPI = plantesting.NewMockPlanClient()
@@ -72,3 +76,62 @@ effective from 01 Jan 16 01:00 UTC
 		}
 	}
 }
+
+func (s *releaseSuite) TestReleaseSelector(c *gc.C) {
+	s.mockAPI.PlanRevisions = []wireformat.Plan{
+		{Id: "testisv/default/1", URL: "testisv/default", Released: true},
+		{Id: "testisv/default/2", URL: "testisv/default"},
+	}
+	_, err := cmdtesting.RunCommand(c, cmd.NewReleaseCommand(), "testisv/default/latest")
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.CheckCallNames(c, "GetPlanRevisions", "Release")
+	s.mockAPI.CheckCall(c, 0, "GetPlanRevisions", "testisv/default")
+	s.mockAPI.CheckCall(c, 1, "Release", "testisv/default/2")
+}
+
+func (s *releaseSuite) TestReleaseSelectorErrors(c *gc.C) {
+	s.mockAPI.PlanRevisions = []wireformat.Plan{
+		{Id: "testisv/default/1", URL: "testisv/default", Released: true},
+		{Id: "testisv/default/2", URL: "testisv/default"},
+	}
+	_, err := cmdtesting.RunCommand(c, cmd.NewReleaseCommand(), "testisv/default/1..2")
+	c.Assert(err, gc.ErrorMatches, "plan testisv/default/1..2 matches 2 revisions, expected one")
+	_, err = cmdtesting.RunCommand(c, cmd.NewReleaseCommand(), "testisv/default/-2")
+	c.Assert(err, gc.ErrorMatches, `failed to resolve plan testisv/default/-2: revisions of plan testisv/default matching "testisv/default/-2" not found`)
+	s.mockAPI.CheckCallNames(c, "GetPlanRevisions", "GetPlanRevisions")
+
+	_, err = cmdtesting.RunCommand(c, cmd.NewReleaseCommand(), "testisv/default/newest")
+	c.Assert(err, gc.ErrorMatches, `invalid plan: invalid revision format: .*`)
+}
+
+func (s *releaseSuite) TestReleaseQualifiedReference(c *gc.C) {
+	var serviceURL string
+	s.PatchValue(cmd.NewClient, func(url string, _ *httpbakery.Client) (api.PlanClient, error) {
+		serviceURL = url
+		return s.mockAPI, nil
+	})
+	tests := []struct {
+		about   string
+		args    []string
+		service string
+	}{{
+		about:   "service url overrides --url",
+		args:    []string{"https://plans.staging.example/testisv/default/1", "--url", "localhost:0"},
+		service: "https://plans.staging.example",
+	}, {
+		about:   "plan scheme keeps --url",
+		args:    []string{"plan:testisv/default/1", "--url", "localhost:0"},
+		service: "localhost:0",
+	}}
+	for i, t := range tests {
+		s.mockAPI.ResetCalls()
+		c.Logf("test %d: %s", i, t.about)
+		_, err := cmdtesting.RunCommand(c, cmd.NewReleaseCommand(), t.args...)
+		c.Assert(err, jc.ErrorIsNil)
+		c.Check(serviceURL, gc.Equals, t.service)
+		s.mockAPI.CheckCall(c, 0, "Release", "testisv/default/1")
+	}
+
+	_, err := cmdtesting.RunCommand(c, cmd.NewReleaseCommand(), "https://plans.staging.example/testisv/default/1?x=1")
+	c.Assert(err, gc.ErrorMatches, `plan reference ".*" not valid`)
+}
@@ -0,0 +1,362 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gosuri/uitable"
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+
+	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/api/wireformat"
+)
+
+const reportAuthorizationsDoc = `
+report-authorizations reports the number of authorizations created for
+the plans of an owner in each statement period from --from to --to, both
+defaulting to the current month, aggregated by plan revision, charm and
+user.
+
+Each authorization is counted once, in the month it was created in: an
+application deployed in January and still running in March is counted in
+January only. The first-seen and last-seen times are the creation times
+of the earliest and latest authorizations counted in the row. Statement
+periods are specified as YYYY-MM, this-month or last-month. When
+--reseller is specified, the reseller authorizations of the owner's plans
+issued to that reseller are included.
+Examples
+report-authorizations canonical
+	reports the authorizations of the plans owned by canonical for the
+	current month.
+report-authorizations canonical --from 2017-01 --to last-month --format csv
+	reports the authorizations from January 2017 to the previous month as
+	comma separated values.
+report-authorizations --reseller acme
+	reports the authorizations of the plans owned by the owner set in the
+	configuration profile, including those issued to the acme reseller.
+`
+
+const reportAuthorizationsPurpose = "report authorizations by plan, charm and statement period"
+
+// maxReportPeriods is the maximum number of statement periods of a
+// report.
+const maxReportPeriods = 36
+
+// NewReportAuthorizationsCommand returns a new ReportAuthorizationsCommand.
+func NewReportAuthorizationsCommand() cmd.Command {
+	return &ReportAuthorizationsCommand{}
+}
+
+// ReportAuthorizationsCommand reports the authorizations of an owner's
+// plans.
+type ReportAuthorizationsCommand struct {
+	baseCommand
+
+	out      cmd.Output
+	Owner    string
+	Reseller string
+	From     string
+	To       string
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *ReportAuthorizationsCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.baseCommand.SetFlags(f)
+	c.addOutputFlags(f, &c.out, "tabular", map[string]cmd.Formatter{
+		"json":    cmd.FormatJson,
+		"csv":     formatAuthorizationReportCSV,
+		"tabular": formatAuthorizationReportTabular,
+	})
+	f.Var(statementPeriodValue{&c.From}, "from", "first statement period of the report "+statementPeriodUsage)
+	f.Var(statementPeriodValue{&c.To}, "to", "last statement period of the report "+statementPeriodUsage)
+	f.StringVar(&c.Reseller, "reseller", "", "include the reseller authorizations issued to the reseller")
+}
+
+// Info implements Command.Info.
+func (c *ReportAuthorizationsCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "report-authorizations",
+		Args:    "[<owner>]",
+		Purpose: reportAuthorizationsPurpose,
+		Doc:     reportAuthorizationsDoc,
+	}
+}
+
+// Init implements Command.Init.
+func (c *ReportAuthorizationsCommand) Init(args []string) error {
+	if len(args) < 1 {
+		p, err := c.loadProfile()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if p.Owner == "" {
+			return errors.New("missing arguments")
+		}
+		args = []string{p.Owner}
+	}
+	c.Owner, args = args[0], args[1:]
+	if err := cmd.CheckEmpty(args); err != nil {
+		return errors.Errorf("unknown command line arguments: " + strings.Join(args, ","))
+	}
+	if c.To == "" {
+		c.To = wireformat.StatementPeriodOf(now()).String()
+	}
+	if c.From == "" {
+		c.From = c.To
+	}
+	periods, err := c.periods()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(periods) == 0 {
+		return errors.Errorf("--from %s is after --to %s", c.From, c.To)
+	}
+	if len(periods) > maxReportPeriods {
+		return errors.Errorf("cannot report on more than %d statement periods", maxReportPeriods)
+	}
+	return nil
+}
+
+// periods returns the statement periods of the report.
+func (c *ReportAuthorizationsCommand) periods() ([]wireformat.StatementPeriod, error) {
+	from, err := wireformat.ParseStatementPeriod(c.From)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	to, err := wireformat.ParseStatementPeriod(c.To)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return wireformat.StatementPeriodRange(from, to), nil
+}
+
+// Run implements Command.Run.
+func (c *ReportAuthorizationsCommand) Run(ctx *cmd.Context) error {
+	client, cleanup, err := c.NewClient(ctx)
+	if err != nil {
+		return errors.Annotate(err, "failed to create an http client")
+	}
+	defer cleanup()
+	apiClient, err := newClient(c.ServiceURL, client)
+	if err != nil {
+		return errors.Annotate(err, "failed to create a plan API client")
+	}
+	periods, err := c.periods()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	plans, err := apiClient.GetPlans(context.Background(), c.Owner)
+	if err != nil {
+		return errors.Annotate(err, "failed to retrieve plans")
+	}
+	planURLs := ownerPlanURLs(plans)
+	report := newAuthorizationReport(periods)
+	for _, planURL := range planURLs {
+		ctx.Verbosef("retrieving authorizations of %v", planURL)
+		auths, err := apiClient.GetAuthorizations(context.Background(), wireformat.AuthorizationQuery{
+			PlanURL:     planURL,
+			IncludePlan: true,
+		})
+		if err != nil {
+			return errors.Annotatef(err, "failed to retrieve authorizations of %v", planURL)
+		}
+		for _, a := range auths {
+			if a.PlanURL != planURL {
+				continue
+			}
+			report.add("authorization/"+a.AuthorizationID, planRevision(a.PlanURL, a.PlanID), a.CharmURL, a.User, a.CreatedOn)
+		}
+	}
+	if c.Reseller != "" {
+		if err := c.addResellerAuthorizations(ctx, apiClient, report, planURLs); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(c.out.Write(ctx, report.rows()))
+}
+
+// addResellerAuthorizations adds the reseller authorizations of the
+// owner's plans to the report.
+func (c *ReportAuthorizationsCommand) addResellerAuthorizations(ctx *cmd.Context, apiClient api.PlanClient, report *authorizationReport, planURLs []string) error {
+	ctx.Verbosef("retrieving reseller authorizations of %v", c.Reseller)
+	auths, err := apiClient.GetResellerAuthorizations(context.Background(), wireformat.ResellerAuthorizationQuery{
+		Reseller:    c.Reseller,
+		IncludePlan: true,
+	})
+	if err != nil {
+		return errors.Annotatef(err, "failed to retrieve reseller authorizations of %v", c.Reseller)
+	}
+	owned := make(map[string]bool)
+	for _, planURL := range planURLs {
+		owned[planURL] = true
+	}
+	for _, a := range auths {
+		id, err := wireformat.ParsePlanIDWithOptionalRevision(a.Plan)
+		if err != nil || !owned[id.PlanURL.String()] {
+			continue
+		}
+		report.add("reseller-authorization/"+a.AuthUUID, planRevision(a.Plan, a.PlanID), a.CharmURL, a.ApplicationUser, a.CreatedOn)
+	}
+	return nil
+}
+
+// ownerPlanURLs returns the sorted urls of the plans, without revisions.
+func ownerPlanURLs(plans []wireformat.Plan) []string {
+	seen := make(map[string]bool)
+	var urls []string
+	for _, p := range plans {
+		if p.URL == "" || seen[p.URL] {
+			continue
+		}
+		seen[p.URL] = true
+		urls = append(urls, p.URL)
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+// planRevision returns the plan id of the revision when known, and the
+// plan url otherwise.
+func planRevision(planURL, planID string) string {
+	if planID != "" {
+		return planID
+	}
+	return planURL
+}
+
+// authorizationReportKey identifies a row of the authorization report.
+type authorizationReportKey struct {
+	period wireformat.StatementPeriod
+	plan   string
+	charm  string
+	user   string
+}
+
+// authorizationReportRow is the output format of a row of the
+// authorization report.
+type authorizationReportRow struct {
+	Period    wireformat.StatementPeriod `json:"period"`
+	Plan      string                     `json:"plan"`
+	Charm     string                     `json:"charm-url"`
+	User      string                     `json:"user"`
+	Count     int                        `json:"count"`
+	FirstSeen time.Time                  `json:"first-seen"`
+	LastSeen  time.Time                  `json:"last-seen"`
+}
+
+// authorizationReport aggregates authorizations by the statement
+// period they were created in.
+type authorizationReport struct {
+	entries map[authorizationReportKey]*authorizationReportRow
+	// periods holds the statement periods of the report.
+	periods map[wireformat.StatementPeriod]bool
+	// seen holds the ids of the authorizations counted.
+	seen map[string]bool
+}
+
+func newAuthorizationReport(periods []wireformat.StatementPeriod) *authorizationReport {
+	r := &authorizationReport{
+		entries: make(map[authorizationReportKey]*authorizationReportRow),
+		periods: make(map[wireformat.StatementPeriod]bool),
+		seen:    make(map[string]bool),
+	}
+	for _, p := range periods {
+		r.periods[p] = true
+	}
+	return r
+}
+
+// add counts the authorization with the given id in the statement period
+// it was created in, unless it has been counted already or it was created
+// outside the periods of the report.
+func (r *authorizationReport) add(id string, plan, charm, user string, createdOn time.Time) {
+	createdOn = createdOn.UTC()
+	period := wireformat.StatementPeriodOf(createdOn)
+	if r.seen[id] || !r.periods[period] {
+		return
+	}
+	r.seen[id] = true
+	key := authorizationReportKey{period: period, plan: plan, charm: charm, user: user}
+	row, ok := r.entries[key]
+	if !ok {
+		row = &authorizationReportRow{
+			Period:    period,
+			Plan:      plan,
+			Charm:     charm,
+			User:      user,
+			FirstSeen: createdOn,
+			LastSeen:  createdOn,
+		}
+		r.entries[key] = row
+	}
+	row.Count++
+	if createdOn.Before(row.FirstSeen) {
+		row.FirstSeen = createdOn
+	}
+	if createdOn.After(row.LastSeen) {
+		row.LastSeen = createdOn
+	}
+}
+
+// rows returns the rows of the report ordered by period, plan, charm
+// and user.
+func (r *authorizationReport) rows() []authorizationReportRow {
+	rows := make([]authorizationReportRow, 0, len(r.entries))
+	for _, row := range r.entries {
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.Period != b.Period {
+			return a.Period.Before(b.Period)
+		}
+		if a.Plan != b.Plan {
+			return a.Plan < b.Plan
+		}
+		if a.Charm != b.Charm {
+			return a.Charm < b.Charm
+		}
+		return a.User < b.User
+	})
+	return rows
+}
+
+func formatAuthorizationReportTabular(w io.Writer, value interface{}) error {
+	rows, ok := value.([]authorizationReportRow)
+	if !ok {
+		return errors.Errorf("expected value of type %T, got %T", rows, value)
+	}
+	table := uitable.New()
+	table.MaxColWidth = 50
+	table.Wrap = true
+	table.AddRow("PERIOD", "PLAN", "CHARM", "USER", "COUNT", "FIRST SEEN", "LAST SEEN")
+	for _, r := range rows {
+		table.AddRow(r.Period.String(), r.Plan, r.Charm, r.User, r.Count, r.FirstSeen.Format(time.RFC3339), r.LastSeen.Format(time.RFC3339))
+	}
+	_, err := w.Write(table.Bytes())
+	if err != nil {
+		return errors.Annotatef(err, "failed to print table")
+	}
+	return nil
+}
+
+func formatAuthorizationReportCSV(w io.Writer, value interface{}) error {
+	rows, ok := value.([]authorizationReportRow)
+	if !ok {
+		return errors.Errorf("expected value of type %T, got %T", rows, value)
+	}
+	records := [][]string{{"period", "plan", "charm-url", "user", "count", "first-seen", "last-seen"}}
+	for _, r := range rows {
+		records = append(records, []string{r.Period.String(), r.Plan, r.Charm, r.User, strconv.Itoa(r.Count), r.FirstSeen.Format(time.RFC3339), r.LastSeen.Format(time.RFC3339)})
+	}
+	return errors.Trace(writeCSV(w, records))
+}
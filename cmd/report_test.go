@@ -0,0 +1,202 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd_test
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/juju/cmd/cmdtesting"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/macaroon-bakery.v2/httpbakery"
+
+	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/api/wireformat"
+	"github.com/juju/plans-client/cmd"
+	plantesting "github.com/juju/plans-client/testing"
+)
+
+type reportAuthorizationsSuite struct {
+	testing.CleanupSuite
+	mockAPI *plantesting.MockPlanClient
+}
+
+var _ = gc.Suite(&reportAuthorizationsSuite{})
+
+// authorizationService answers authorization queries like the plans
+// service: only the authorizations of the queried plan are returned.
+type authorizationService struct {
+	*plantesting.MockPlanClient
+}
+
+// GetAuthorizations implements api.PlanClient.
+func (s authorizationService) GetAuthorizations(ctx context.Context, query wireformat.AuthorizationQuery) ([]wireformat.Authorization, error) {
+	auths, err := s.MockPlanClient.GetAuthorizations(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	var matched []wireformat.Authorization
+	for _, a := range auths {
+		if query.PlanURL == "" || a.PlanURL == query.PlanURL {
+			matched = append(matched, a)
+		}
+	}
+	return matched, nil
+}
+
+func (s *reportAuthorizationsSuite) SetUpTest(c *gc.C) {
+	s.CleanupSuite.SetUpTest(c)
+	s.PatchEnvironment("GOCOOKIES", filepath.Join(c.MkDir(), "cookies"))
+	s.mockAPI = plantesting.NewMockPlanClient()
+	s.mockAPI.Plans = []wireformat.Plan{
+		{Id: "testisv/default/1", URL: "testisv/default"},
+		{Id: "testisv/default/2", URL: "testisv/default"},
+		{Id: "testisv/premium/1", URL: "testisv/premium"},
+	}
+	auth1 := wireformat.Authorization{
+		AuthorizationID: "auth-1",
+		User:            "bob",
+		PlanURL:         "testisv/default",
+		PlanID:          "testisv/default/2",
+		CharmURL:        "cs:~testisv/charm1-0",
+		ServiceName:     "app1",
+		CreatedOn:       time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	s.mockAPI.Authorizations = []wireformat.Authorization{auth1, {
+		AuthorizationID: "auth-2",
+		User:            "bob",
+		PlanURL:         "testisv/default",
+		PlanID:          "testisv/default/2",
+		CharmURL:        "cs:~testisv/charm1-0",
+		ServiceName:     "app2",
+		CreatedOn:       time.Date(2017, 1, 25, 3, 4, 5, 0, time.UTC),
+	}, {
+		AuthorizationID: "auth-3",
+		User:            "alice",
+		PlanURL:         "testisv/default",
+		CharmURL:        "cs:~testisv/charm1-0",
+		ServiceName:     "app3",
+		CreatedOn:       time.Date(2017, 2, 3, 0, 0, 0, 0, time.UTC),
+	}, {
+		AuthorizationID: "auth-6",
+		User:            "dave",
+		PlanURL:         "testisv/default",
+		PlanID:          "testisv/default/1",
+		CharmURL:        "cs:~testisv/charm1-0",
+		ServiceName:     "app6",
+		CreatedOn:       time.Date(2016, 12, 2, 3, 4, 5, 0, time.UTC),
+	}, {
+		AuthorizationID: "auth-7",
+		User:            "erin",
+		PlanURL:         "testisv/premium",
+		PlanID:          "testisv/premium/1",
+		CharmURL:        "cs:~testisv/charm2-0",
+		ServiceName:     "app7",
+		CreatedOn:       time.Date(2017, 2, 10, 0, 0, 0, 0, time.UTC),
+	},
+		// Authorizations may be returned more than once.
+		auth1,
+	}
+	s.mockAPI.ResellerAuthorizations = []wireformat.ResellerAuthorization{{
+		AuthUUID:        "auth-4",
+		Plan:            "testisv/premium",
+		PlanID:          "testisv/premium/1",
+		CharmURL:        "cs:~testisv/charm2-0",
+		ApplicationUser: "carol",
+		CreatedOn:       time.Date(2017, 1, 5, 0, 0, 0, 0, time.UTC),
+	}, {
+		AuthUUID:        "auth-5",
+		Plan:            "other/plan",
+		CharmURL:        "cs:~other/charm-0",
+		ApplicationUser: "carol",
+		CreatedOn:       time.Date(2017, 1, 5, 0, 0, 0, 0, time.UTC),
+	}}
+	s.PatchValue(cmd.NewClient, func(string, *httpbakery.Client) (api.PlanClient, error) {
+		return authorizationService{s.mockAPI}, nil
+	})
+	s.PatchValue(cmd.Now, func() time.Time {
+		return time.Date(2017, 2, 15, 0, 0, 0, 0, time.UTC)
+	})
+}
+
+func (s *reportAuthorizationsSuite) TestInvalidArgs(c *gc.C) {
+	tests := []struct {
+		about string
+		args  []string
+		err   string
+	}{{
+		about: "unknown arguments",
+		args:  []string{"testisv", "extra"},
+		err:   `unknown command line arguments: extra`,
+	}, {
+		about: "invalid period",
+		args:  []string{"testisv", "--from", "2017"},
+		err:   `invalid value "2017" for flag --from: statement period "2017" not valid`,
+	}, {
+		about: "reversed range",
+		args:  []string{"testisv", "--from", "2017-03"},
+		err:   `--from 2017-03 is after --to 2017-02`,
+	}, {
+		about: "too many periods",
+		args:  []string{"testisv", "--from", "2010-01"},
+		err:   `cannot report on more than 36 statement periods`,
+	}}
+	for i, t := range tests {
+		c.Logf("running test %d: %s", i, t.about)
+		_, err := cmdtesting.RunCommand(c, cmd.NewReportAuthorizationsCommand(), t.args...)
+		c.Assert(err, gc.ErrorMatches, t.err)
+	}
+	s.mockAPI.CheckNoCalls(c)
+}
+
+func (s *reportAuthorizationsSuite) TestReport(c *gc.C) {
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewReportAuthorizationsCommand(), "testisv", "--from", "last-month", "--reseller", "acme")
+	c.Assert(err, jc.ErrorIsNil)
+	// Each plan is queried once, whatever the number of periods.
+	s.mockAPI.CheckCallNames(c, "GetPlans", "GetAuthorizations", "GetAuthorizations", "GetResellerAuthorizations")
+	s.mockAPI.CheckCall(c, 0, "GetPlans", "testisv")
+	s.mockAPI.CheckCall(c, 1, "GetAuthorizations", wireformat.AuthorizationQuery{PlanURL: "testisv/default", IncludePlan: true})
+	s.mockAPI.CheckCall(c, 2, "GetAuthorizations", wireformat.AuthorizationQuery{PlanURL: "testisv/premium", IncludePlan: true})
+	s.mockAPI.CheckCall(c, 3, "GetResellerAuthorizations", wireformat.ResellerAuthorizationQuery{Reseller: "acme", IncludePlan: true})
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, `PERIOD 	PLAN             	CHARM               	USER 	COUNT	FIRST SEEN          	LAST SEEN           
+2017-01	testisv/default/2	cs:~testisv/charm1-0	bob  	2    	2017-01-02T03:04:05Z	2017-01-25T03:04:05Z
+2017-01	testisv/premium/1	cs:~testisv/charm2-0	carol	1    	2017-01-05T00:00:00Z	2017-01-05T00:00:00Z
+2017-02	testisv/default  	cs:~testisv/charm1-0	alice	1    	2017-02-03T00:00:00Z	2017-02-03T00:00:00Z
+2017-02	testisv/premium/1	cs:~testisv/charm2-0	erin 	1    	2017-02-10T00:00:00Z	2017-02-10T00:00:00Z
+`)
+}
+
+func (s *reportAuthorizationsSuite) TestReportOtherPlans(c *gc.C) {
+	// The mock returns all the authorizations whatever the plan
+	// queried: those of other plans are not counted.
+	s.PatchValue(cmd.NewClient, func(string, *httpbakery.Client) (api.PlanClient, error) {
+		return s.mockAPI, nil
+	})
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewReportAuthorizationsCommand(), "testisv", "--from", "last-month", "--format", "csv")
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.CheckCallNames(c, "GetPlans", "GetAuthorizations", "GetAuthorizations")
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, `period,plan,charm-url,user,count,first-seen,last-seen
+2017-01,testisv/default/2,cs:~testisv/charm1-0,bob,2,2017-01-02T03:04:05Z,2017-01-25T03:04:05Z
+2017-02,testisv/default,cs:~testisv/charm1-0,alice,1,2017-02-03T00:00:00Z,2017-02-03T00:00:00Z
+2017-02,testisv/premium/1,cs:~testisv/charm2-0,erin,1,2017-02-10T00:00:00Z,2017-02-10T00:00:00Z
+`)
+}
+
+func (s *reportAuthorizationsSuite) TestReportFormats(c *gc.C) {
+	s.mockAPI.Plans = s.mockAPI.Plans[:1]
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewReportAuthorizationsCommand(), "testisv", "--format", "csv")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, `period,plan,charm-url,user,count,first-seen,last-seen
+2017-02,testisv/default,cs:~testisv/charm1-0,alice,1,2017-02-03T00:00:00Z,2017-02-03T00:00:00Z
+`)
+	s.mockAPI.CheckCallNames(c, "GetPlans", "GetAuthorizations")
+
+	ctx, err = cmdtesting.RunCommand(c, cmd.NewReportAuthorizationsCommand(), "testisv", "--to", "2017-01", "--format", "json")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, `[{"period":"2017-01","plan":"testisv/default/2","charm-url":"cs:~testisv/charm1-0","user":"bob","count":2,"first-seen":"2017-01-02T03:04:05Z","last-seen":"2017-01-25T03:04:05Z"}]
+`)
+}
@@ -0,0 +1,315 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gosuri/uitable"
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+	"gopkg.in/macaroon.v1"
+
+	"github.com/juju/plans-client/api/wireformat"
+)
+
+const authorizeResellerDoc = `
+authorize-reseller obtains a reseller authorization for the application
+deployed from the charm, owned and used by the specified users. The
+resulting macaroon is written to standard output or to the file specified
+with --output.
+Examples
+authorize-reseller canonical/landscape-default cs:~canonical/landscape-1 landscape --owner acme --user bob
+	writes the reseller authorization macaroon, as JSON, to standard output.
+authorize-reseller canonical/landscape-default cs:~canonical/landscape-1 landscape --owner acme --user bob --format base64 -o auth.macaroon
+	writes the base64-encoded macaroon to auth.macaroon.
+authorize-reseller canonical/landscape-default cs:~canonical/landscape-1 landscape --owner acme --user bob --format inspect
+	displays the contents of the macaroon, as inspect-macaroon does.
+`
+
+const authorizeResellerPurpose = "authorize a reseller plan"
+
+const listResellerAuthorizationsDoc = `
+list-reseller-authorizations lists the reseller authorizations matching the
+specified criteria. Either the reseller or the authorization uuid must be
+specified.
+Examples
+list-reseller-authorizations --reseller acme
+	lists all authorizations issued to the acme reseller.
+list-reseller-authorizations --reseller acme --application landscape --include-plan --format yaml
+	lists the authorizations of the landscape application, including the
+	plan definitions.
+list-reseller-authorizations --reseller acme --include-plan --statement-period this-month
+	lists the authorizations, including the plan definitions in effect for
+	the current month. Statement periods are specified as YYYY-MM,
+	this-month or last-month.
+`
+
+const listResellerAuthorizationsPurpose = "list reseller authorizations"
+
+// macaroonFormatters holds the output formats of authorization macaroons.
+var macaroonFormatters = map[string]cmd.Formatter{
+	"json":    formatMacaroonJSON,
+	"base64":  formatMacaroonBase64,
+	"inspect": formatMacaroonInspect,
+}
+
+func formatMacaroonJSON(w io.Writer, value interface{}) error {
+	m, ok := value.(*macaroon.Macaroon)
+	if !ok {
+		return errors.Errorf("expected value of type %T, got %T", m, value)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return errors.Annotate(err, "failed to marshal macaroon")
+	}
+	_, err = w.Write(data)
+	return errors.Trace(err)
+}
+
+func formatMacaroonBase64(w io.Writer, value interface{}) error {
+	m, ok := value.(*macaroon.Macaroon)
+	if !ok {
+		return errors.Errorf("expected value of type %T, got %T", m, value)
+	}
+	data, err := m.MarshalBinary()
+	if err != nil {
+		return errors.Annotate(err, "failed to marshal macaroon")
+	}
+	_, err = fmt.Fprint(w, base64.StdEncoding.EncodeToString(data))
+	return errors.Trace(err)
+}
+
+// NewAuthorizeResellerCommand returns a new AuthorizeResellerCommand.
+func NewAuthorizeResellerCommand() cmd.Command {
+	return &AuthorizeResellerCommand{}
+}
+
+// AuthorizeResellerCommand obtains a reseller authorization macaroon.
+type AuthorizeResellerCommand struct {
+	baseCommand
+
+	out     cmd.Output
+	Request wireformat.ResellerAuthorizationRequest
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *AuthorizeResellerCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.baseCommand.SetFlags(f)
+	c.addOutputFlags(f, &c.out, "json", macaroonFormatters)
+	f.StringVar(&c.Request.ApplicationOwner, "owner", "", "owner of the application")
+	f.StringVar(&c.Request.ApplicationUser, "user", "", "user of the application")
+}
+
+// Info implements Command.Info.
+func (c *AuthorizeResellerCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "authorize-reseller",
+		Args:    "<plan> <charm url> <application>",
+		Purpose: authorizeResellerPurpose,
+		Doc:     authorizeResellerDoc,
+	}
+}
+
+// Init implements Command.Init.
+func (c *AuthorizeResellerCommand) Init(args []string) error {
+	if len(args) < 3 {
+		return errors.New("missing arguments")
+	}
+	c.Request.CharmURL, c.Request.Application = args[1], args[2]
+	if err := cmd.CheckEmpty(args[3:]); err != nil {
+		return errors.Errorf("unknown command line arguments: " + strings.Join(args[3:], ","))
+	}
+	plan, err := c.unqualifyPlan(args[0])
+	if err != nil {
+		return errors.Trace(err)
+	}
+	c.Request.Plan = plan
+	if _, err := wireformat.ParsePlanIDWithOptionalRevision(c.Request.Plan); err != nil {
+		return errors.Trace(err)
+	}
+	if c.Request.ApplicationOwner == "" {
+		return errors.New("missing application owner: use --owner")
+	}
+	if c.Request.ApplicationUser == "" {
+		return errors.New("missing application user: use --user")
+	}
+	return errors.Trace(c.Request.Validate())
+}
+
+// Run implements Command.Run.
+func (c *AuthorizeResellerCommand) Run(ctx *cmd.Context) error {
+	client, cleanup, err := c.NewClient(ctx)
+	if err != nil {
+		return errors.Annotate(err, "failed to create an http client")
+	}
+	defer cleanup()
+	apiClient, err := newClient(c.ServiceURL, client)
+	if err != nil {
+		return errors.Annotate(err, "failed to create a plan API client")
+	}
+	r := c.Request
+	m, err := apiClient.AuthorizeReseller(context.Background(), r.Plan, r.CharmURL, r.Application, r.ApplicationOwner, r.ApplicationUser)
+	if err != nil {
+		return errors.Annotate(err, "failed to authorize reseller plan")
+	}
+	return errors.Trace(c.out.Write(ctx, m))
+}
+
+// NewListResellerAuthorizationsCommand returns a new
+// ListResellerAuthorizationsCommand.
+func NewListResellerAuthorizationsCommand() cmd.Command {
+	return &ListResellerAuthorizationsCommand{}
+}
+
+// ListResellerAuthorizationsCommand lists reseller authorizations.
+type ListResellerAuthorizationsCommand struct {
+	baseCommand
+
+	out   cmd.Output
+	Query wireformat.ResellerAuthorizationQuery
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *ListResellerAuthorizationsCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.baseCommand.SetFlags(f)
+	c.addOutputFlags(f, &c.out, "tabular", map[string]cmd.Formatter{
+		"json":    cmd.FormatJson,
+		"yaml":    cmd.FormatYaml,
+		"csv":     formatResellerAuthorizationsCSV,
+		"tabular": formatResellerAuthorizationsTabular,
+	})
+	f.StringVar(&c.Query.AuthUUID, "auth-uuid", "", "authorization uuid")
+	f.StringVar(&c.Query.Application, "application", "", "application name")
+	f.StringVar(&c.Query.Reseller, "reseller", "", "reseller name")
+	f.StringVar(&c.Query.User, "user", "", "user of the application")
+	f.BoolVar(&c.Query.IncludePlan, "include-plan", false, "include the plan definition")
+	f.Var(statementPeriodValue{&c.Query.StatementPeriod}, "statement-period", "statement period of the included plan "+statementPeriodUsage)
+}
+
+// Info implements Command.Info.
+func (c *ListResellerAuthorizationsCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "list-reseller-authorizations",
+		Purpose: listResellerAuthorizationsPurpose,
+		Doc:     listResellerAuthorizationsDoc,
+	}
+}
+
+// Init implements Command.Init.
+func (c *ListResellerAuthorizationsCommand) Init(args []string) error {
+	if err := cmd.CheckEmpty(args); err != nil {
+		return errors.Errorf("unknown command line arguments: " + strings.Join(args, ","))
+	}
+	if c.Query.StatementPeriod != "" && !c.Query.IncludePlan {
+		return errors.New("--statement-period requires --include-plan")
+	}
+	return errors.Trace(c.Query.Validate())
+}
+
+// Run implements Command.Run.
+func (c *ListResellerAuthorizationsCommand) Run(ctx *cmd.Context) error {
+	client, cleanup, err := c.NewClient(ctx)
+	if err != nil {
+		return errors.Annotate(err, "failed to create an http client")
+	}
+	defer cleanup()
+	apiClient, err := newClient(c.ServiceURL, client)
+	if err != nil {
+		return errors.Annotate(err, "failed to create a plan API client")
+	}
+	auths, err := apiClient.GetResellerAuthorizations(context.Background(), c.Query)
+	if err != nil {
+		return errors.Annotate(err, "failed to retrieve reseller authorizations")
+	}
+	details := make([]resellerAuthorizationDetails, len(auths))
+	for i, a := range auths {
+		details[i] = resellerAuthorizationFromWire(a)
+	}
+	return errors.Trace(c.out.Write(ctx, details))
+}
+
+// resellerAuthorizationDetails is the output format of a reseller
+// authorization.
+type resellerAuthorizationDetails struct {
+	ID             string    `json:"auth-uuid" yaml:"auth-uuid"`
+	Plan           string    `json:"plan" yaml:"plan"`
+	PlanID         string    `json:"plan-id,omitempty" yaml:"plan-id,omitempty"`
+	Charm          string    `json:"charm-url" yaml:"charm-url"`
+	Application    string    `json:"application" yaml:"application"`
+	Owner          string    `json:"owner" yaml:"owner"`
+	User           string    `json:"user" yaml:"user"`
+	CreatedOn      time.Time `json:"created-on" yaml:"created-on"`
+	PlanDefinition string    `json:"plan-definition,omitempty" yaml:"plan-definition,omitempty"`
+}
+
+func resellerAuthorizationFromWire(a wireformat.ResellerAuthorization) resellerAuthorizationDetails {
+	return resellerAuthorizationDetails{
+		ID:             a.AuthUUID,
+		Plan:           a.Plan,
+		PlanID:         a.PlanID,
+		Charm:          a.CharmURL,
+		Application:    a.Application,
+		Owner:          a.ApplicationOwner,
+		User:           a.ApplicationUser,
+		CreatedOn:      a.CreatedOn.UTC(),
+		PlanDefinition: a.PlanDefinition,
+	}
+}
+
+func formatResellerAuthorizationsTabular(w io.Writer, value interface{}) error {
+	auths, ok := value.([]resellerAuthorizationDetails)
+	if !ok {
+		return errors.Errorf("expected value of type %T, got %T", auths, value)
+	}
+	withDefinition := false
+	for _, a := range auths {
+		if a.PlanDefinition != "" {
+			withDefinition = true
+		}
+	}
+	table := uitable.New()
+	table.MaxColWidth = 50
+	table.Wrap = true
+	header := []interface{}{"ID", "PLAN", "CHARM", "APPLICATION", "OWNER", "USER", "CREATED ON"}
+	if withDefinition {
+		header = append(header, "DEFINITION")
+	}
+	table.AddRow(header...)
+	for _, a := range auths {
+		plan := a.Plan
+		if a.PlanID != "" {
+			plan = a.PlanID
+		}
+		row := []interface{}{a.ID, plan, a.Charm, a.Application, a.Owner, a.User, a.CreatedOn.Format(time.RFC3339)}
+		if withDefinition {
+			row = append(row, a.PlanDefinition)
+		}
+		table.AddRow(row...)
+	}
+	_, err := w.Write(table.Bytes())
+	if err != nil {
+		return errors.Annotatef(err, "failed to print table")
+	}
+	return nil
+}
+
+func formatResellerAuthorizationsCSV(w io.Writer, value interface{}) error {
+	auths, ok := value.([]resellerAuthorizationDetails)
+	if !ok {
+		return errors.Errorf("expected value of type %T, got %T", auths, value)
+	}
+	rows := [][]string{{"auth-uuid", "plan", "plan-id", "charm-url", "application", "owner", "user", "created-on", "plan-definition"}}
+	for _, a := range auths {
+		rows = append(rows, []string{a.ID, a.Plan, a.PlanID, a.Charm, a.Application, a.Owner, a.User, a.CreatedOn.Format(time.RFC3339), a.PlanDefinition})
+	}
+	return errors.Trace(writeCSV(w, rows))
+}
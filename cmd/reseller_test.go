@@ -0,0 +1,195 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/juju/cmd/cmdtesting"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/macaroon-bakery.v2/httpbakery"
+	"gopkg.in/macaroon.v1"
+
+	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/api/wireformat"
+	"github.com/juju/plans-client/cmd"
+	plantesting "github.com/juju/plans-client/testing"
+)
+
+type resellerSuite struct {
+	testing.CleanupSuite
+	mockAPI *plantesting.MockPlanClient
+}
+
+var _ = gc.Suite(&resellerSuite{})
+
+func (s *resellerSuite) SetUpTest(c *gc.C) {
+	s.PatchEnvironment("GOCOOKIES", filepath.Join(c.MkDir(), "cookies"))
+	s.mockAPI = plantesting.NewMockPlanClient()
+	m, err := macaroon.New([]byte("root-key"), "reseller-auth", "plans")
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.AuthorizationMacaroon = m
+	s.mockAPI.ResellerAuthorizations = []wireformat.ResellerAuthorization{{
+		AuthUUID:         "auth-1",
+		Plan:             "testisv/default",
+		CharmURL:         "cs:~testisv/charm1-0",
+		Application:      "app1",
+		ApplicationOwner: "acme",
+		ApplicationUser:  "bob",
+		CreatedOn:        time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC),
+	}}
+	s.PatchValue(cmd.NewClient, func(string, *httpbakery.Client) (api.PlanClient, error) {
+		return s.mockAPI, nil
+	})
+}
+
+func (s *resellerSuite) TestAuthorizeResellerInvalidArgs(c *gc.C) {
+	tests := []struct {
+		about string
+		args  []string
+		err   string
+	}{{
+		about: "missing arguments",
+		args:  []string{"testisv/default", "cs:~testisv/charm1-0"},
+		err:   `missing arguments`,
+	}, {
+		about: "unknown arguments",
+		args:  []string{"testisv/default", "cs:~testisv/charm1-0", "app1", "extra", "--owner", "acme", "--user", "bob"},
+		err:   `unknown command line arguments: extra`,
+	}, {
+		about: "invalid plan",
+		args:  []string{"default", "cs:~testisv/charm1-0", "app1", "--owner", "acme", "--user", "bob"},
+		err:   `plan id "default" not valid`,
+	}, {
+		about: "missing owner",
+		args:  []string{"testisv/default", "cs:~testisv/charm1-0", "app1", "--user", "bob"},
+		err:   `missing application owner: use --owner`,
+	}, {
+		about: "missing user",
+		args:  []string{"testisv/default", "cs:~testisv/charm1-0", "app1", "--owner", "acme"},
+		err:   `missing application user: use --user`,
+	}}
+	for i, t := range tests {
+		c.Logf("running test %d: %s", i, t.about)
+		_, err := cmdtesting.RunCommand(c, cmd.NewAuthorizeResellerCommand(), t.args...)
+		c.Assert(err, gc.ErrorMatches, t.err)
+	}
+	s.mockAPI.CheckNoCalls(c)
+}
+
+func (s *resellerSuite) TestAuthorizeReseller(c *gc.C) {
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewAuthorizeResellerCommand(), "testisv/default", "cs:~testisv/charm1-0", "app1", "--owner", "acme", "--user", "bob")
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.CheckCall(c, 0, "AuthorizeReseller", "testisv/default", "cs:~testisv/charm1-0", "app1", "acme", "bob")
+	var m macaroon.Macaroon
+	err = json.Unmarshal([]byte(cmdtesting.Stdout(ctx)), &m)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(m.Id(), gc.Equals, "reseller-auth")
+}
+
+func (s *resellerSuite) TestAuthorizeResellerBase64File(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "auth.macaroon")
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewAuthorizeResellerCommand(), "testisv/default", "cs:~testisv/charm1-0", "app1", "--owner", "acme", "--user", "bob", "--format", "base64", "-o", path)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, "")
+	data, err := ioutil.ReadFile(path)
+	c.Assert(err, jc.ErrorIsNil)
+	decoded, err := base64.StdEncoding.DecodeString(string(data[:len(data)-1]))
+	c.Assert(err, jc.ErrorIsNil)
+	var m macaroon.Macaroon
+	err = m.UnmarshalBinary(decoded)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(m.Id(), gc.Equals, "reseller-auth")
+}
+
+func (s *resellerSuite) TestListResellerAuthorizations(c *gc.C) {
+	tests := []struct {
+		about  string
+		args   []string
+		auths  []wireformat.ResellerAuthorization
+		err    string
+		stdout string
+		query  wireformat.ResellerAuthorizationQuery
+	}{{
+		about: "missing reseller",
+		args:  []string{"--user", "bob"},
+		err:   `must specify the reseller name`,
+	}, {
+		about: "statement period without plan",
+		args:  []string{"--reseller", "acme", "--statement-period", "2017-01"},
+		err:   `--statement-period requires --include-plan`,
+	}, {
+		about: "invalid statement period",
+		args:  []string{"--reseller", "acme", "--include-plan", "--statement-period", "january"},
+		err:   `invalid value "january" for flag --statement-period: statement period "january" not valid`,
+	}, {
+		about: "tabular output",
+		args:  []string{"--reseller", "acme", "--application", "app1", "--user", "bob"},
+		stdout: `ID    	PLAN           	CHARM               	APPLICATION	OWNER	USER	CREATED ON          
+auth-1	testisv/default	cs:~testisv/charm1-0	app1       	acme 	bob 	2017-01-02T03:04:05Z
+`,
+		query: wireformat.ResellerAuthorizationQuery{Reseller: "acme", Application: "app1", User: "bob"},
+	}, {
+		about: "authorization uuid",
+		args:  []string{"--auth-uuid", "auth-1", "--format", "json"},
+		stdout: `[{"auth-uuid":"auth-1","plan":"testisv/default","charm-url":"cs:~testisv/charm1-0","application":"app1","owner":"acme","user":"bob","created-on":"2017-01-02T03:04:05Z"}]
+`,
+		query: wireformat.ResellerAuthorizationQuery{AuthUUID: "auth-1"},
+	}, {
+		about: "include plan",
+		args:  []string{"--reseller", "acme", "--include-plan", "--statement-period", "2017-01", "--format", "yaml"},
+		auths: []wireformat.ResellerAuthorization{{
+			AuthUUID:         "auth-1",
+			Plan:             "testisv/default",
+			PlanID:           "testisv/default/3",
+			CharmURL:         "cs:~testisv/charm1-0",
+			Application:      "app1",
+			ApplicationOwner: "acme",
+			ApplicationUser:  "bob",
+			CreatedOn:        time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC),
+			PlanDefinition:   "metrics: {}",
+		}},
+		stdout: `- auth-uuid: auth-1
+  plan: testisv/default
+  plan-id: testisv/default/3
+  charm-url: cs:~testisv/charm1-0
+  application: app1
+  owner: acme
+  user: bob
+  created-on: 2017-01-02T03:04:05Z
+  plan-definition: 'metrics: {}'
+`,
+		query: wireformat.ResellerAuthorizationQuery{Reseller: "acme", IncludePlan: true, StatementPeriod: "2017-01"},
+	}, {
+		about: "csv output",
+		args:  []string{"--reseller", "acme", "--format", "csv"},
+		stdout: `auth-uuid,plan,plan-id,charm-url,application,owner,user,created-on,plan-definition
+auth-1,testisv/default,,cs:~testisv/charm1-0,app1,acme,bob,2017-01-02T03:04:05Z,
+`,
+		query: wireformat.ResellerAuthorizationQuery{Reseller: "acme"},
+	}}
+	defaultAuths := s.mockAPI.ResellerAuthorizations
+	for i, t := range tests {
+		c.Logf("running test %d: %s", i, t.about)
+		s.mockAPI.ResetCalls()
+		s.mockAPI.ResellerAuthorizations = defaultAuths
+		if t.auths != nil {
+			s.mockAPI.ResellerAuthorizations = t.auths
+		}
+		ctx, err := cmdtesting.RunCommand(c, cmd.NewListResellerAuthorizationsCommand(), t.args...)
+		if t.err != "" {
+			c.Assert(err, gc.ErrorMatches, t.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(cmdtesting.Stdout(ctx), gc.Equals, t.stdout)
+		s.mockAPI.CheckCall(c, 0, "GetResellerAuthorizations", t.query)
+	}
+}
@@ -14,12 +14,6 @@ import (
 
 var defaultCharmStoreURL = "http://api.jujucharms.com/charmstore"
 
-func init() {
-	if csURL := os.Getenv("CSURL"); csURL != "" {
-		defaultCharmStoreURL = csURL
-	}
-}
-
 // charmResolver interface defines the functionality to resolve a charm URL.
 type charmResolver interface {
 	// Resolve resolves the charm URL.
@@ -34,10 +28,16 @@ type charmStoreResolver struct {
 	csURL string
 }
 
-// NewCharmStoreResolver creates a new charm store resolver.
+// NewCharmStoreResolver creates a new charm store resolver using the
+// charm store specified in the CSURL environment variable or the
+// default charm store.
 func NewCharmStoreResolver() *charmStoreResolver {
+	csURL := os.Getenv("CSURL")
+	if csURL == "" {
+		csURL = defaultCharmStoreURL
+	}
 	return &charmStoreResolver{
-		csURL: defaultCharmStoreURL,
+		csURL: csURL,
 	}
 }
 
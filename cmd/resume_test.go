@@ -4,6 +4,8 @@
 package cmd_test
 
 import (
+	"path/filepath"
+
 	"github.com/juju/cmd/cmdtesting"
 	"github.com/juju/testing"
 	jc "github.com/juju/testing/checkers"
@@ -24,6 +26,7 @@ type resumeSuite struct {
 var _ = gc.Suite(&resumeSuite{})
 
 func (s *resumeSuite) SetUpTest(c *gc.C) {
+	s.PatchEnvironment("GOCOOKIES", filepath.Join(c.MkDir(), "cookies"))
 	s.stub = &testing.Stub{}
 
 	s.mockAPI = plantesting.NewMockPlanClient()
@@ -13,14 +13,20 @@ import (
 	"github.com/juju/errors"
 	"github.com/juju/gnuflag"
 
+	"github.com/juju/plans-client/api"
 	"github.com/juju/plans-client/api/wireformat"
 )
 
 const showPlanRevisionsDoc = `
-show-plan-revisions displays all plan revisions
-Example
+show-plan-revisions displays all plan revisions, or the revisions matched
+by a revision selector.
+Examples
 show-plan-revisions canonical/landscape-default
 	returns all revisions of the canonical/landscape-default plan.
+show-plan-revisions canonical/landscape-default/3..7
+	returns revisions 3 to 7 of the canonical/landscape-default plan.
+
+See "help plan-urls" for all the revision selectors.
 `
 
 const showPlanRevisionsPurpose = "show all revision of a plan"
@@ -33,6 +39,8 @@ type ShowRevisionsCommand struct {
 
 	out     cmd.Output
 	PlanURL string
+
+	selector *wireformat.PlanSelector
 }
 
 // NewShowRevisionsCommand creates a new ShowRevisionsCommand.
@@ -44,7 +52,7 @@ func NewShowRevisionsCommand() cmd.Command {
 func (c *ShowRevisionsCommand) SetFlags(f *gnuflag.FlagSet) {
 	c.baseCommand.ServiceURL = defaultServiceURL()
 	c.baseCommand.SetFlags(f)
-	c.out.AddFlags(f, "tabular", map[string]cmd.Formatter{
+	c.addOutputFlags(f, &c.out, "tabular", map[string]cmd.Formatter{
 		"json":    cmd.FormatJson,
 		"yaml":    cmd.FormatYaml,
 		"tabular": formatPlansTabular,
@@ -72,11 +80,23 @@ func (c *ShowRevisionsCommand) Init(args []string) error {
 		return errors.New("missing plan url")
 	}
 	planURL, args := args[0], args[1:]
+	planURL, err := c.unqualifyPlan(planURL)
+	if err != nil {
+		return errors.Trace(err)
+	}
 	c.PlanURL = planURL
 
 	if err := cmd.CheckEmpty(args); err != nil {
 		return errors.Errorf("unknown command line arguments: " + strings.Join(args, ","))
 	}
+	sel, err := wireformat.ParsePlanSelector(c.PlanURL)
+	if err != nil {
+		return errors.Annotate(err, "invalid plan")
+	}
+	if sel.Kind == wireformat.SelectRevision {
+		return errors.New("plan revision specified where none was expected")
+	}
+	c.selector = sel
 
 	return nil
 }
@@ -94,9 +114,17 @@ func (c *ShowRevisionsCommand) Run(ctx *cmd.Context) error {
 		return errors.Annotate(err, "failed to create a plan API client")
 	}
 
-	plans, err := apiClient.GetPlanRevisions(context.Background(), c.PlanURL)
-	if err != nil {
-		return errors.Annotatef(err, "failed to retrieve plan %v revisions", c.PlanURL)
+	var plans []wireformat.Plan
+	if c.selector.Kind == wireformat.SelectPlan {
+		plans, err = apiClient.GetPlanRevisions(context.Background(), c.PlanURL)
+		if err != nil {
+			return errors.Annotatef(err, "failed to retrieve plan %v revisions", c.PlanURL)
+		}
+	} else {
+		plans, err = api.SelectPlanRevisions(context.Background(), apiClient, *c.selector)
+		if err != nil {
+			return errors.Annotatef(err, "failed to retrieve plan %v revisions", c.PlanURL)
+		}
 	}
 
 	err = c.out.Write(ctx, plans)
@@ -118,13 +146,25 @@ func formatPlansTabular(w io.Writer, value interface{}) error {
 	for _, col := range []int{1, 2, 3, 4} {
 		table.RightAlign(col)
 	}
-	table.AddRow("PLAN", "CREATED ON", "EFFECTIVE TIME", "DEFINITION")
+	// Release notes are only shown when a revision has them.
+	withNotes := false
 	for _, plan := range plans {
+		withNotes = withNotes || plan.ReleaseNotes != ""
+	}
+	header := []interface{}{"PLAN", "CREATED ON", "EFFECTIVE TIME", "DEFINITION"}
+	if withNotes {
+		header = append(header, "RELEASE NOTES")
+	}
+	table.AddRow(header...)
+	for _, plan := range plans {
+		row := []interface{}{plan.Id, plan.CreatedOn, "", plan.Definition}
 		if plan.EffectiveTime != nil {
-			table.AddRow(plan.Id, plan.CreatedOn, plan.EffectiveTime, plan.Definition)
-		} else {
-			table.AddRow(plan.Id, plan.CreatedOn, "", plan.Definition)
+			row[2] = plan.EffectiveTime
+		}
+		if withNotes {
+			row = append(row, plan.ReleaseNotes)
 		}
+		table.AddRow(row...)
 	}
 
 	_, err := w.Write(table.Bytes())
@@ -4,6 +4,7 @@
 package cmd_test
 
 import (
+	"path/filepath"
 	"time"
 
 	"github.com/juju/cmd/cmdtesting"
@@ -27,6 +28,7 @@ type showRevisionsSuite struct {
 var _ = gc.Suite(&showRevisionsSuite{})
 
 func (s *showRevisionsSuite) SetUpTest(c *gc.C) {
+	s.PatchEnvironment("GOCOOKIES", filepath.Join(c.MkDir(), "cookies"))
 	s.stub = &testing.Stub{}
 
 	s.mockAPI = plantesting.NewMockPlanClient()
@@ -177,3 +179,32 @@ testisv/default/2	2015-02-01T01:00:00Z	2015-03-01 01:00:00 +0000 UTC
 		}
 	}
 }
+
+func (s *showRevisionsSuite) TestSelector(c *gc.C) {
+	s.mockAPI.PlanRevisions = []wireformat.Plan{
+		{Id: "testisv/default/1", URL: "testisv/default", Released: true},
+		{Id: "testisv/default/2", URL: "testisv/default", Released: true},
+		{Id: "testisv/default/3", URL: "testisv/default"},
+	}
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewShowRevisionsCommand(), "testisv/default/2..3", "--format", "json")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), jc.JSONEquals, s.mockAPI.PlanRevisions[1:])
+	s.mockAPI.CheckCallNames(c, "GetPlanRevisions")
+	s.mockAPI.CheckCall(c, 0, "GetPlanRevisions", "testisv/default")
+
+	_, err = cmdtesting.RunCommand(c, cmd.NewShowRevisionsCommand(), "testisv/default/5..7")
+	c.Assert(err, gc.ErrorMatches, `failed to retrieve plan testisv/default/5..7 revisions: revisions of plan testisv/default matching "testisv/default/5..7" not found`)
+}
+
+func (s *showRevisionsSuite) TestReleaseNotes(c *gc.C) {
+	s.mockAPI.PlanRevisions = []wireformat.Plan{
+		{Id: "testisv/default/1", URL: "testisv/default", CreatedOn: "2015-01-01T01:00:00Z"},
+		{Id: "testisv/default/2", URL: "testisv/default", CreatedOn: "2015-02-01T01:00:00Z", ReleaseNotes: "lower the price"},
+	}
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewShowRevisionsCommand(), "testisv/default")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, ""+
+		"PLAN             \t          CREATED ON\tEFFECTIVE TIME\tDEFINITION\t  RELEASE NOTES\n"+
+		"testisv/default/1\t2015-01-01T01:00:00Z\t              \t          \t               \n"+
+		"testisv/default/2\t2015-02-01T01:00:00Z\t              \t          \tlower the price\n")
+}
@@ -0,0 +1,111 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+
+	"github.com/juju/plans-client/api/wireformat/schema"
+)
+
+const schemaDoc = `
+schema displays the JSON schema of the documents exchanged with the plans
+service, and of plan definitions, for integrating with the plans service
+in other languages. The schema is versioned: its version increases
+whenever the wire format changes.
+
+When a definition is specified only the schema of that definition, and
+of the definitions it refers to, is displayed. The definitions are:
+
+    %s
+Examples
+schema
+	displays the schema of all the documents.
+schema PlanDefinition -o plan-definition.schema.json
+	writes the schema of plan definitions to plan-definition.schema.json.
+`
+
+const schemaPurpose = "display the JSON schema of the plans service documents"
+
+// NewSchemaCommand returns a new SchemaCommand.
+func NewSchemaCommand() cmd.Command {
+	return &SchemaCommand{}
+}
+
+// SchemaCommand displays the JSON schema of the wire format.
+type SchemaCommand struct {
+	cmd.CommandBase
+
+	out        cmd.Output
+	Definition string
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *SchemaCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "json", map[string]cmd.Formatter{
+		"json": formatSchemaJSON,
+	})
+}
+
+// Info implements Command.Info.
+func (c *SchemaCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "schema",
+		Args:    "[<definition>]",
+		Purpose: schemaPurpose,
+		Doc:     fmt.Sprintf(schemaDoc, strings.Join(schema.Names(), "\n    ")),
+	}
+}
+
+// Init implements Command.Init.
+func (c *SchemaCommand) Init(args []string) error {
+	if len(args) > 0 {
+		c.Definition, args = args[0], args[1:]
+	}
+	if err := cmd.CheckEmpty(args); err != nil {
+		return errors.Errorf("unknown command line arguments: " + strings.Join(args, ","))
+	}
+	if c.Definition != "" {
+		for _, name := range schema.Names() {
+			if name == c.Definition {
+				return nil
+			}
+		}
+		return errors.Errorf("unknown definition %q, expected one of %s", c.Definition, strings.Join(schema.Names(), ", "))
+	}
+	return nil
+}
+
+// Run implements Command.Run.
+func (c *SchemaCommand) Run(ctx *cmd.Context) error {
+	var s *schema.Schema
+	var err error
+	if c.Definition == "" {
+		s, err = schema.Generate()
+	} else {
+		s, err = schema.GenerateDefinition(c.Definition)
+	}
+	if err != nil {
+		return errors.Annotate(err, "failed to generate the schema")
+	}
+	return errors.Trace(c.out.Write(ctx, s))
+}
+
+// formatSchemaJSON writes the schema as indented JSON, as in the schema
+// file distributed with the client.
+func formatSchemaJSON(w io.Writer, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return errors.Trace(err)
+}
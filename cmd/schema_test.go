@@ -0,0 +1,51 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/juju/cmd/cmdtesting"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/plans-client/api/wireformat/schema"
+	"github.com/juju/plans-client/cmd"
+)
+
+type schemaSuite struct {
+	testing.CleanupSuite
+}
+
+var _ = gc.Suite(&schemaSuite{})
+
+func (s *schemaSuite) TestSchema(c *gc.C) {
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewSchemaCommand())
+	c.Assert(err, jc.ErrorIsNil)
+	// The schema displayed is the schema distributed with the client.
+	data, err := ioutil.ReadFile("../api/wireformat/schema/plans.schema.json")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, string(data))
+}
+
+func (s *schemaSuite) TestSchemaDefinition(c *gc.C) {
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewSchemaCommand(), "PlanDefinition")
+	c.Assert(err, jc.ErrorIsNil)
+	var sch schema.Schema
+	err = json.Unmarshal([]byte(cmdtesting.Stdout(ctx)), &sch)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(sch.Ref, gc.Equals, "#/definitions/PlanDefinition")
+	c.Assert(sch.Version, gc.Equals, schema.Version)
+	c.Assert(sch.Definitions["PlanDefinition"].Properties, gc.HasLen, 2)
+	c.Assert(sch.Definitions["MetricModel"], gc.NotNil)
+}
+
+func (s *schemaSuite) TestSchemaErrors(c *gc.C) {
+	_, err := cmdtesting.RunCommand(c, cmd.NewSchemaCommand(), "Budget")
+	c.Assert(err, gc.ErrorMatches, `unknown definition "Budget", expected one of Authorization, .*, ResellerAuthorizationRequest`)
+	_, err = cmdtesting.RunCommand(c, cmd.NewSchemaCommand(), "Plan", "foobar")
+	c.Assert(err, gc.ErrorMatches, `unknown command line arguments: foobar`)
+}
@@ -0,0 +1,335 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gosuri/uitable"
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+	cookiejar "github.com/juju/persistent-cookiejar"
+	"gopkg.in/macaroon-bakery.v2/bakery/checkers"
+	"gopkg.in/macaroon.v2"
+
+	"github.com/juju/plans-client/api"
+)
+
+const whoamiDoc = `
+whoami displays the identity used to authenticate with the plans service,
+as declared by the identity manager in the discharged macaroons stored in
+the cookie jar.
+Example
+whoami
+	displays the user name and groups for the plans service.
+whoami --url https://plans.staging.example
+	displays the identity used with the staging plans service.
+`
+
+const whoamiPurpose = "display the identity used with the plans service"
+
+const loginDoc = `
+login authenticates with the plans service, replacing any stored
+macaroons, and stores the discharged macaroons in the cookie jar.
+Example
+login
+	logs in to the plans service, using a web browser.
+login -B
+	logs in to the plans service using Ubuntu SSO credentials.
+`
+
+const loginPurpose = "log in to the plans service"
+
+const logoutDoc = `
+logout removes the macaroons stored for the plans service from the cookie
+jar.
+Example
+logout
+	logs out of the plans service.
+logout --usso-token
+	also removes the stored Ubuntu SSO token.
+`
+
+const logoutPurpose = "log out of the plans service"
+
+// identity holds the identity declared in a discharged macaroon.
+type identity struct {
+	User     string     `json:"user,omitempty" yaml:"user,omitempty"`
+	Groups   []string   `json:"groups,omitempty" yaml:"groups,omitempty"`
+	Expires  *time.Time `json:"expires,omitempty" yaml:"expires,omitempty"`
+	Provider string     `json:"identity-provider,omitempty" yaml:"identity-provider,omitempty"`
+}
+
+// identities returns the identities declared in the macaroons stored in
+// the jar for the host of the service URL.
+func identities(jar *cookiejar.Jar, serviceURL string) ([]identity, error) {
+	u, err := url.Parse(serviceURL)
+	if err != nil {
+		return nil, errors.Annotatef(err, "invalid service url %q", serviceURL)
+	}
+	ns := checkers.New(nil).Namespace()
+	ids := []identity{}
+	for _, cookie := range jar.AllCookies() {
+		if cookie.Domain != u.Hostname() || !strings.HasPrefix(cookie.Name, "macaroon-") {
+			continue
+		}
+		ms, err := decodeMacaroonCookie(cookie.Value)
+		if err != nil {
+			// The jar may hold cookies that are not macaroons.
+			continue
+		}
+		declared := checkers.InferDeclared(ns, ms)
+		id := identity{
+			User:   declared["username"],
+			Groups: strings.Fields(declared["groups"]),
+		}
+		sort.Strings(id.Groups)
+		if expires, ok := checkers.MacaroonsExpiryTime(ns, ms); ok {
+			expires = expires.UTC()
+			id.Expires = &expires
+		}
+		providers := []string{}
+		for _, m := range ms[1:] {
+			providers = append(providers, m.Location())
+		}
+		id.Provider = strings.Join(providers, ",")
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// decodeMacaroonCookie decodes the value of a macaroon cookie, as
+// encoded by httpbakery.NewCookie.
+func decodeMacaroonCookie(value string) (macaroon.Slice, error) {
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var ms macaroon.Slice
+	if err := json.Unmarshal(data, &ms); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(ms) == 0 {
+		return nil, errors.New("no macaroons in cookie")
+	}
+	return ms, nil
+}
+
+// removeMacaroons removes the cookies stored for the host of the service
+// URL from the jar.
+func removeMacaroons(jar *cookiejar.Jar, serviceURL string) error {
+	u, err := url.Parse(serviceURL)
+	if err != nil {
+		return errors.Annotatef(err, "invalid service url %q", serviceURL)
+	}
+	jar.RemoveAllHost(u.Hostname())
+	return nil
+}
+
+func formatIdentitiesTabular(w io.Writer, value interface{}) error {
+	ids, ok := value.([]identity)
+	if !ok {
+		return errors.Errorf("expected value of type %T, got %T", ids, value)
+	}
+	table := uitable.New()
+	table.MaxColWidth = 50
+	table.Wrap = true
+	table.AddRow("USER", "GROUPS", "EXPIRES", "IDENTITY PROVIDER")
+	for _, id := range ids {
+		expires := ""
+		if id.Expires != nil {
+			expires = id.Expires.Format(time.RFC3339)
+		}
+		table.AddRow(id.User, strings.Join(id.Groups, ","), expires, id.Provider)
+	}
+	_, err := w.Write(table.Bytes())
+	if err != nil {
+		return errors.Annotatef(err, "failed to print table")
+	}
+	return nil
+}
+
+var identityFormatters = map[string]cmd.Formatter{
+	"json":    cmd.FormatJson,
+	"yaml":    cmd.FormatYaml,
+	"tabular": formatIdentitiesTabular,
+}
+
+// NewWhoamiCommand returns a new WhoamiCommand.
+func NewWhoamiCommand() cmd.Command {
+	return &WhoamiCommand{}
+}
+
+// WhoamiCommand displays the identity used with the plans service.
+type WhoamiCommand struct {
+	baseCommand
+
+	out cmd.Output
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *WhoamiCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.baseCommand.SetFlags(f)
+	c.addOutputFlags(f, &c.out, "tabular", identityFormatters)
+}
+
+// Info implements Command.Info.
+func (c *WhoamiCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "whoami",
+		Purpose: whoamiPurpose,
+		Doc:     whoamiDoc,
+	}
+}
+
+// Init implements Command.Init.
+func (c *WhoamiCommand) Init(args []string) error {
+	return cmd.CheckEmpty(args)
+}
+
+// Run implements Command.Run.
+func (c *WhoamiCommand) Run(ctx *cmd.Context) error {
+	jar, err := c.cookieJar()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ids, err := identities(jar, c.ServiceURL)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(ids) == 0 {
+		return errors.Errorf("not logged in to %s", c.ServiceURL)
+	}
+	return errors.Trace(c.out.Write(ctx, ids))
+}
+
+// NewLoginCommand returns a new LoginCommand.
+func NewLoginCommand() cmd.Command {
+	return &LoginCommand{}
+}
+
+// LoginCommand authenticates with the plans service.
+type LoginCommand struct {
+	baseCommand
+
+	out cmd.Output
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *LoginCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.baseCommand.SetFlags(f)
+	c.addOutputFlags(f, &c.out, "tabular", identityFormatters)
+}
+
+// Info implements Command.Info.
+func (c *LoginCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "login",
+		Purpose: loginPurpose,
+		Doc:     loginDoc,
+	}
+}
+
+// Init implements Command.Init.
+func (c *LoginCommand) Init(args []string) error {
+	return cmd.CheckEmpty(args)
+}
+
+// Run implements Command.Run.
+func (c *LoginCommand) Run(ctx *cmd.Context) error {
+	client, cleanup, err := c.NewClient(ctx)
+	if err != nil {
+		return errors.Annotate(err, "failed to create an http client")
+	}
+	defer cleanup()
+	jar, ok := client.Jar.(*cookiejar.Jar)
+	if !ok {
+		return errors.Errorf("unexpected cookie jar of type %T", client.Jar)
+	}
+	if err := removeMacaroons(jar, c.ServiceURL); err != nil {
+		return errors.Trace(err)
+	}
+	apiClient, err := newClient(c.ServiceURL, client)
+	if err != nil {
+		return errors.Annotate(err, "failed to create a plan API client")
+	}
+	// The login request may fail for other reasons once the discharge
+	// is stored.
+	requestErr := api.Login(context.Background(), apiClient)
+	ids, err := identities(jar, c.ServiceURL)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(ids) == 0 {
+		if requestErr != nil {
+			return errors.Annotatef(requestErr, "failed to log in to %s", c.ServiceURL)
+		}
+		return errors.Errorf("failed to log in to %s: no macaroons were issued", c.ServiceURL)
+	}
+	if requestErr != nil {
+		ctx.Verbosef("login request failed after discharge: %v", requestErr)
+	}
+	return errors.Trace(c.out.Write(ctx, ids))
+}
+
+// NewLogoutCommand returns a new LogoutCommand.
+func NewLogoutCommand() cmd.Command {
+	return &LogoutCommand{}
+}
+
+// LogoutCommand removes the stored credentials for the plans service.
+type LogoutCommand struct {
+	baseCommand
+
+	RemoveUSSOToken bool
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *LogoutCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.baseCommand.SetFlags(f)
+	f.BoolVar(&c.RemoveUSSOToken, "usso-token", false, "also remove the stored Ubuntu SSO token")
+}
+
+// Info implements Command.Info.
+func (c *LogoutCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "logout",
+		Purpose: logoutPurpose,
+		Doc:     logoutDoc,
+	}
+}
+
+// Init implements Command.Init.
+func (c *LogoutCommand) Init(args []string) error {
+	return cmd.CheckEmpty(args)
+}
+
+// Run implements Command.Run.
+func (c *LogoutCommand) Run(ctx *cmd.Context) error {
+	jar, err := c.cookieJar()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := removeMacaroons(jar, c.ServiceURL); err != nil {
+		return errors.Trace(err)
+	}
+	if err := jar.Save(); err != nil {
+		return errors.Annotate(err, "failed to save cookie jar")
+	}
+	if c.RemoveUSSOToken {
+		if err := os.Remove(ussoTokenPath()); err != nil && !os.IsNotExist(err) {
+			return errors.Annotate(err, "failed to remove Ubuntu SSO token")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd_test
+
+import (
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+
+	"github.com/juju/cmd/cmdtesting"
+	"github.com/juju/errors"
+	cookiejar "github.com/juju/persistent-cookiejar"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/macaroon-bakery.v2/bakery/checkers"
+	"gopkg.in/macaroon-bakery.v2/httpbakery"
+	"gopkg.in/macaroon.v2"
+
+	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/cmd"
+	plantesting "github.com/juju/plans-client/testing"
+)
+
+type sessionSuite struct {
+	testing.IsolationSuite
+	mockAPI    *plantesting.MockPlanClient
+	cookieFile string
+}
+
+var _ = gc.Suite(&sessionSuite{})
+
+func (s *sessionSuite) SetUpTest(c *gc.C) {
+	s.IsolationSuite.SetUpTest(c)
+	dir := c.MkDir()
+	s.cookieFile = filepath.Join(dir, "cookies")
+	s.PatchEnvironment("GOCOOKIES", s.cookieFile)
+	s.PatchValue(cmd.ConfigFilePath, func() string {
+		return filepath.Join(dir, "config.yaml")
+	})
+	s.mockAPI = plantesting.NewMockPlanClient()
+	s.PatchValue(cmd.NewClient, func(string, *httpbakery.Client) (api.PlanClient, error) {
+		return s.mockAPI, nil
+	})
+}
+
+// newMacaroons returns a macaroon for the plans service discharged by
+// an identity manager declaring the user and groups.
+func newMacaroons(c *gc.C, user, groups string) macaroon.Slice {
+	m, err := macaroon.New([]byte("root-key"), []byte("plans-"+user), "plans", macaroon.LatestVersion)
+	c.Assert(err, jc.ErrorIsNil)
+	err = m.AddFirstPartyCaveat([]byte("time-before 2100-01-01T00:00:00Z"))
+	c.Assert(err, jc.ErrorIsNil)
+	d, err := macaroon.New([]byte("discharge-key"), []byte("discharge-"+user), "https://candid.example.com", macaroon.LatestVersion)
+	c.Assert(err, jc.ErrorIsNil)
+	err = d.AddFirstPartyCaveat([]byte("declared username " + user))
+	c.Assert(err, jc.ErrorIsNil)
+	if groups != "" {
+		err = d.AddFirstPartyCaveat([]byte("declared groups " + groups))
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	return macaroon.Slice{m, d}
+}
+
+func (s *sessionSuite) storeMacaroons(c *gc.C, serviceURL string, ms macaroon.Slice) {
+	jar, err := cookiejar.New(&cookiejar.Options{Filename: s.cookieFile})
+	c.Assert(err, jc.ErrorIsNil)
+	u, err := url.Parse(serviceURL)
+	c.Assert(err, jc.ErrorIsNil)
+	err = httpbakery.SetCookie(jar, u, checkers.New(nil).Namespace(), ms)
+	c.Assert(err, jc.ErrorIsNil)
+	err = jar.Save()
+	c.Assert(err, jc.ErrorIsNil)
+}
+
+func (s *sessionSuite) TestWhoami(c *gc.C) {
+	s.storeMacaroons(c, "https://plans.example.com/", newMacaroons(c, "bob", "testisv canonical"))
+	s.storeMacaroons(c, "https://other.example.com/", newMacaroons(c, "alice", ""))
+
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewWhoamiCommand(), "--url", "https://plans.example.com/v3")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, `USER	GROUPS           	EXPIRES             	IDENTITY PROVIDER         
+bob 	canonical,testisv	2100-01-01T00:00:00Z	https://candid.example.com
+`)
+
+	ctx, err = cmdtesting.RunCommand(c, cmd.NewWhoamiCommand(), "--url", "https://other.example.com", "--format", "json")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, `[{"user":"alice","expires":"2100-01-01T00:00:00Z","identity-provider":"https://candid.example.com"}]
+`)
+}
+
+func (s *sessionSuite) TestWhoamiNotLoggedIn(c *gc.C) {
+	_, err := cmdtesting.RunCommand(c, cmd.NewWhoamiCommand(), "--url", "https://plans.example.com")
+	c.Assert(err, gc.ErrorMatches, `not logged in to https://plans.example.com`)
+}
+
+func (s *sessionSuite) TestLogin(c *gc.C) {
+	s.storeMacaroons(c, "https://plans.example.com/", newMacaroons(c, "alice", ""))
+	s.PatchValue(cmd.NewClient, func(serviceURL string, client *httpbakery.Client) (api.PlanClient, error) {
+		// The mock does not issue requests, so store the macaroons
+		// the discharge would have obtained.
+		u, err := url.Parse(serviceURL)
+		c.Assert(err, jc.ErrorIsNil)
+		err = httpbakery.SetCookie(client.Jar, u, checkers.New(nil).Namespace(), newMacaroons(c, "bob", "testisv"))
+		c.Assert(err, jc.ErrorIsNil)
+		return s.mockAPI, nil
+	})
+
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewLoginCommand(), "--url", "https://plans.example.com", "--format", "yaml")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, `- user: bob
+  groups:
+  - testisv
+  expires: 2100-01-01T00:00:00Z
+  identity-provider: https://candid.example.com
+`)
+	s.mockAPI.CheckCallNames(c, "Login")
+
+	ctx, err = cmdtesting.RunCommand(c, cmd.NewWhoamiCommand(), "--url", "https://plans.example.com", "--format", "json")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), gc.Equals, `[{"user":"bob","groups":["testisv"],"expires":"2100-01-01T00:00:00Z","identity-provider":"https://candid.example.com"}]
+`)
+}
+
+func (s *sessionSuite) TestLoginFails(c *gc.C) {
+	s.storeMacaroons(c, "https://plans.example.com/", newMacaroons(c, "alice", ""))
+	s.mockAPI.SetErrors(errors.New("cannot get discharge"))
+
+	_, err := cmdtesting.RunCommand(c, cmd.NewLoginCommand(), "--url", "https://plans.example.com")
+	c.Assert(err, gc.ErrorMatches, `failed to log in to https://plans.example.com: cannot get discharge`)
+
+	_, err = cmdtesting.RunCommand(c, cmd.NewWhoamiCommand(), "--url", "https://plans.example.com")
+	c.Assert(err, gc.ErrorMatches, `not logged in to https://plans.example.com`)
+}
+
+func (s *sessionSuite) TestLogout(c *gc.C) {
+	s.storeMacaroons(c, "https://plans.example.com/", newMacaroons(c, "bob", ""))
+	s.storeMacaroons(c, "https://other.example.com/", newMacaroons(c, "alice", ""))
+	tokenPath := filepath.Join(c.MkDir(), "store-usso-token")
+	s.PatchValue(cmd.USSOTokenPath, func() string { return tokenPath })
+	err := ioutil.WriteFile(tokenPath, []byte("{}"), 0600)
+	c.Assert(err, jc.ErrorIsNil)
+
+	_, err = cmdtesting.RunCommand(c, cmd.NewLogoutCommand(), "--url", "https://plans.example.com")
+	c.Assert(err, jc.ErrorIsNil)
+	_, err = cmdtesting.RunCommand(c, cmd.NewWhoamiCommand(), "--url", "https://plans.example.com")
+	c.Assert(err, gc.ErrorMatches, `not logged in to https://plans.example.com`)
+	_, err = cmdtesting.RunCommand(c, cmd.NewWhoamiCommand(), "--url", "https://other.example.com")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(tokenPath, jc.IsNonEmptyFile)
+
+	_, err = cmdtesting.RunCommand(c, cmd.NewLogoutCommand(), "--url", "https://plans.example.com", "--usso-token")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(tokenPath, jc.DoesNotExist)
+}
@@ -20,9 +20,16 @@ import (
 
 const showPlanDoc = `
 show-plan displays detailed information about the plan
-Example
+Examples
 show-plan canonical/landscape-default
 	returns details of the canonical/landscape-default plan.
+show-plan canonical/landscape-default/released
+	returns details of the latest released revision of the plan.
+show-plan canonical/landscape-default@2017-03-01
+	returns details of the revision of the plan effective on March 1st
+	2017.
+
+See "help plan-urls" for all the revision selectors.
 `
 
 const showPlanPurpose = "show plan details"
@@ -37,6 +44,8 @@ type ShowCommand struct {
 	PlanURL        string
 	ShowContent    bool
 	OnlyDefinition bool
+
+	selector *wireformat.PlanSelector
 }
 
 // NewShowCommand creates a new ShowCommand.
@@ -48,7 +57,7 @@ func NewShowCommand() cmd.Command {
 func (c *ShowCommand) SetFlags(f *gnuflag.FlagSet) {
 	c.baseCommand.ServiceURL = defaultServiceURL()
 	c.baseCommand.SetFlags(f)
-	c.out.AddFlags(f, "tabular", map[string]cmd.Formatter{
+	c.addOutputFlags(f, &c.out, "tabular", map[string]cmd.Formatter{
 		"json":    cmd.FormatJson,
 		"yaml":    cmd.FormatYaml,
 		"tabular": formatTabular,
@@ -78,11 +87,20 @@ func (c *ShowCommand) Init(args []string) error {
 		return errors.New("missing plan url")
 	}
 	planURL, args := args[0], args[1:]
+	planURL, err := c.unqualifyPlan(planURL)
+	if err != nil {
+		return errors.Trace(err)
+	}
 	c.PlanURL = planURL
 
 	if err := cmd.CheckEmpty(args); err != nil {
 		return errors.Errorf("unknown command line arguments: " + strings.Join(args, ","))
 	}
+	sel, err := wireformat.ParsePlanSelector(c.PlanURL)
+	if err != nil {
+		return errors.Annotate(err, "invalid plan")
+	}
+	c.selector = sel
 
 	return nil
 }
@@ -100,9 +118,13 @@ func (c *ShowCommand) Run(ctx *cmd.Context) error {
 		return errors.Annotate(err, "failed to create a plan API client")
 	}
 
-	plan, err := apiClient.GetPlanDetails(context.Background(), c.PlanURL)
+	planID, err := resolvePlanRevision(apiClient, c.selector, c.PlanURL)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	plan, err := apiClient.GetPlanDetails(context.Background(), planID)
 	if err != nil {
-		return errors.Annotatef(err, "failed to retrieve plan %v details", c.PlanURL)
+		return errors.Annotatef(err, "failed to retrieve plan %v details", planID)
 	}
 
 	if c.OnlyDefinition {
@@ -110,19 +132,43 @@ func (c *ShowCommand) Run(ctx *cmd.Context) error {
 		return nil
 	}
 
-	err = c.out.Write(ctx, fromWire(c.ShowContent, plan))
+	states, err := charmStates(*plan, now())
+	if err != nil {
+		ctx.Warningf("cannot determine the state of the plan for all charms: %v", err)
+	}
+	err = c.out.Write(ctx, fromWire(c.ShowContent, plan, states))
 	if err != nil {
 		return errors.Trace(err)
 	}
 	return nil
 }
 
-func fromWire(showContent bool, plan *wireformat.PlanDetails) *planDetails {
+// charmStates returns the state of the plan for each charm it is attached
+// to. The state is empty for charms whose events do not form a valid
+// sequence, and the first such error is returned.
+func charmStates(plan wireformat.PlanDetails, now time.Time) ([]string, error) {
+	states := make([]string, len(plan.Charms))
+	var firstErr error
+	for i, ch := range plan.Charms {
+		l, err := wireformat.CharmLifecycleOf(plan, ch, now)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = errors.Trace(err)
+			}
+			continue
+		}
+		states[i] = string(l.State)
+	}
+	return states, firstErr
+}
+
+func fromWire(showContent bool, plan *wireformat.PlanDetails, states []string) *planDetails {
 	p := planDetails{
 		ID:            plan.Plan.Id,
 		Created:       eventFromWire(plan.Created),
 		Charms:        make([]charmDetails, len(plan.Charms)),
 		EffectiveTime: plan.Plan.EffectiveTime,
+		ReleaseNotes:  plan.Plan.ReleaseNotes,
 	}
 	if showContent {
 		p.Definition = plan.Plan.Definition
@@ -139,6 +185,7 @@ func fromWire(showContent bool, plan *wireformat.PlanDetails) *planDetails {
 			Attached:       eventFromWire(ch.Attached),
 			EffectiveSince: ch.EffectiveSince,
 			Default:        ch.Default,
+			State:          states[i],
 			Events:         make([]eventDetails, len(ch.Events)),
 		}
 		for j, e := range ch.Events {
@@ -157,6 +204,7 @@ type planDetails struct {
 	PlanPrice       string         `json:"price,omitempty" yaml:"price,omitempty"`
 	Charms          []charmDetails `json:"charms,omitempty" yaml:"charms,omitempty"`
 	EffectiveTime   *time.Time     `json:"effective-time,omitempty" yaml:"effective-time,omitempty"`
+	ReleaseNotes    string         `json:"release-notes,omitempty" yaml:"release-notes,omitempty"`
 }
 
 type charmDetails struct {
@@ -164,6 +212,7 @@ type charmDetails struct {
 	Attached       eventDetails   `json:"attached" yaml:"attached"`
 	EffectiveSince *time.Time     `json:"effective-since,omitempty" yaml:"effective-since,omitempty"`
 	Default        bool           `json:"default" yaml:"default"`
+	State          string         `json:"state,omitempty" yaml:"state,omitempty"`
 	Events         []eventDetails `json:"events,omitempty" yaml:"events,omitempty"`
 }
 
@@ -205,6 +254,9 @@ func formatTabular(w io.Writer, value interface{}) error {
 		table.AddRow("", "", "EFFECTIVE")
 		table.AddRow("", "", plan.EffectiveTime)
 	}
+	if plan.ReleaseNotes != "" {
+		table.AddRow("", "RELEASE NOTES", plan.ReleaseNotes)
+	}
 	if plan.PlanDescription != "" {
 		table.AddRow("", "DESCRIPTION", plan.PlanDescription)
 	}
@@ -218,11 +270,11 @@ func formatTabular(w io.Writer, value interface{}) error {
 		table.AddRow("CHARMS")
 		for _, charm := range plan.Charms {
 			if charm.EffectiveSince != nil {
-				table.AddRow("CHARM", "ATTACHED BY", "TIME", "DEFAULT", "EFFECTIVE SINCE")
-				table.AddRow(charm.CharmURL, charm.Attached.User, charm.Attached.Time, charm.Default, charm.EffectiveSince)
+				table.AddRow("CHARM", "ATTACHED BY", "TIME", "DEFAULT", "EFFECTIVE SINCE", "STATE")
+				table.AddRow(charm.CharmURL, charm.Attached.User, charm.Attached.Time, charm.Default, charm.EffectiveSince, charm.State)
 			} else {
-				table.AddRow("CHARM", "ATTACHED BY", "TIME", "DEFAULT", "")
-				table.AddRow(charm.CharmURL, charm.Attached.User, charm.Attached.Time, charm.Default, "")
+				table.AddRow("CHARM", "ATTACHED BY", "TIME", "DEFAULT", "", "STATE")
+				table.AddRow(charm.CharmURL, charm.Attached.User, charm.Attached.Time, charm.Default, "", charm.State)
 			}
 			if len(charm.Events) > 0 {
 				table.AddRow("", "EVENTS")
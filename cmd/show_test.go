@@ -4,6 +4,7 @@
 package cmd_test
 
 import (
+	"path/filepath"
 	"time"
 
 	"github.com/juju/cmd/cmdtesting"
@@ -27,6 +28,7 @@ type showSuite struct {
 var _ = gc.Suite(&showSuite{})
 
 func (s *showSuite) SetUpTest(c *gc.C) {
+	s.PatchEnvironment("GOCOOKIES", filepath.Join(c.MkDir(), "cookies"))
 	s.stub = &testing.Stub{}
 
 	s.mockAPI = plantesting.NewMockPlanClient()
@@ -102,6 +104,9 @@ func (s *showSuite) TestCommand(c *gc.C) {
 		}},
 	}
 
+	states, err := cmd.CharmStates(*p, time.Now())
+	c.Assert(err, jc.ErrorIsNil)
+
 	tests := []struct {
 		about            string
 		args             []string
@@ -125,7 +130,7 @@ func (s *showSuite) TestCommand(c *gc.C) {
 		about: "everything works - json",
 		args:  []string{"testisv/default", "--format", "json"},
 		assertStdout: func(c *gc.C, output string) {
-			c.Assert(output, jc.JSONEquals, cmd.FromWire(false, p))
+			c.Assert(output, jc.JSONEquals, cmd.FromWire(false, p, states))
 		},
 		assertCalls: func(stub *testing.Stub) {
 			stub.CheckCall(c, 0, "GetPlanDetails", "testisv/default")
@@ -134,7 +139,7 @@ func (s *showSuite) TestCommand(c *gc.C) {
 		about: "everything works - yaml",
 		args:  []string{"testisv/default", "--format", "yaml"},
 		assertStdout: func(c *gc.C, output string) {
-			c.Assert(output, jc.YAMLEquals, cmd.FromWire(false, p))
+			c.Assert(output, jc.YAMLEquals, cmd.FromWire(false, p, states))
 		},
 		assertCalls: func(stub *testing.Stub) {
 			stub.CheckCall(c, 0, "GetPlanDetails", "testisv/default")
@@ -143,7 +148,7 @@ func (s *showSuite) TestCommand(c *gc.C) {
 		about: "everything works - json - content",
 		args:  []string{"testisv/default", "--format", "json", "--content"},
 		assertStdout: func(c *gc.C, output string) {
-			c.Assert(output, jc.JSONEquals, cmd.FromWire(true, p))
+			c.Assert(output, jc.JSONEquals, cmd.FromWire(true, p, states))
 		},
 		assertCalls: func(stub *testing.Stub) {
 			stub.CheckCall(c, 0, "GetPlanDetails", "testisv/default")
@@ -152,7 +157,7 @@ func (s *showSuite) TestCommand(c *gc.C) {
 		about: "everything works - yaml - content",
 		args:  []string{"testisv/default", "--format", "yaml", "--content"},
 		assertStdout: func(c *gc.C, output string) {
-			c.Assert(output, jc.YAMLEquals, cmd.FromWire(true, p))
+			c.Assert(output, jc.YAMLEquals, cmd.FromWire(true, p, states))
 		},
 		assertCalls: func(stub *testing.Stub) {
 			stub.CheckCall(c, 0, "GetPlanDetails", "testisv/default")
@@ -170,10 +175,10 @@ testisv/default/1
                     	           	                    EFFECTIVE
                     	           	2015-01-01 01:00:00 +0000 UTC
 CHARMS              
-CHARM               	ATTACHED BY	                         TIME	DEFAULT	                             
-cs:~testisv/charm1-0	  jane.jaas	2015-01-01 01:00:00 +0000 UTC	  false	                             
-CHARM               	ATTACHED BY	                         TIME	DEFAULT	              EFFECTIVE SINCE
-cs:~testisv/charm2-1	   joe.jaas	2015-01-01 01:00:00 +0000 UTC	   true	2015-01-01 01:00:00 +0000 UTC
+CHARM               	ATTACHED BY	                         TIME	DEFAULT	                             	    STATE
+cs:~testisv/charm1-0	  jane.jaas	2015-01-01 01:00:00 +0000 UTC	  false	                             	   active
+CHARM               	ATTACHED BY	                         TIME	DEFAULT	              EFFECTIVE SINCE	    STATE
+cs:~testisv/charm2-1	   joe.jaas	2015-01-01 01:00:00 +0000 UTC	   true	2015-01-01 01:00:00 +0000 UTC	suspended
                     	     EVENTS
                     	           	                           BY	   TYPE	                         TIME
                     	           	                     eve.jaas	suspend	2015-01-01 01:02:03 +0000 UTC
@@ -212,10 +217,10 @@ testisv/default/1
                     	           	        price: 0.01                               
                     	           	                                                  
 CHARMS              
-CHARM               	ATTACHED BY	                                              TIME	DEFAULT	                             
-cs:~testisv/charm1-0	  jane.jaas	                     2015-01-01 01:00:00 +0000 UTC	  false	                             
-CHARM               	ATTACHED BY	                                              TIME	DEFAULT	              EFFECTIVE SINCE
-cs:~testisv/charm2-1	   joe.jaas	                     2015-01-01 01:00:00 +0000 UTC	   true	2015-01-01 01:00:00 +0000 UTC
+CHARM               	ATTACHED BY	                                              TIME	DEFAULT	                             	    STATE
+cs:~testisv/charm1-0	  jane.jaas	                     2015-01-01 01:00:00 +0000 UTC	  false	                             	   active
+CHARM               	ATTACHED BY	                                              TIME	DEFAULT	              EFFECTIVE SINCE	    STATE
+cs:~testisv/charm2-1	   joe.jaas	                     2015-01-01 01:00:00 +0000 UTC	   true	2015-01-01 01:00:00 +0000 UTC	suspended
                     	     EVENTS
                     	           	                                                BY	   TYPE	                         TIME
                     	           	                                          eve.jaas	suspend	2015-01-01 01:02:03 +0000 UTC
@@ -301,6 +306,9 @@ func (s *showSuite) TestCommandWithUnreleasedPlan(c *gc.C) {
 		},
 	}
 
+	states, err := cmd.CharmStates(*p, time.Now())
+	c.Assert(err, jc.ErrorIsNil)
+
 	tests := []struct {
 		about            string
 		args             []string
@@ -313,7 +321,7 @@ func (s *showSuite) TestCommandWithUnreleasedPlan(c *gc.C) {
 		about: "everything works - json",
 		args:  []string{"testisv/default", "--format", "json"},
 		assertStdout: func(c *gc.C, output string) {
-			c.Assert(output, jc.JSONEquals, cmd.FromWire(false, p))
+			c.Assert(output, jc.JSONEquals, cmd.FromWire(false, p, states))
 		},
 		assertCalls: func(stub *testing.Stub) {
 			stub.CheckCall(c, 0, "GetPlanDetails", "testisv/default")
@@ -322,7 +330,7 @@ func (s *showSuite) TestCommandWithUnreleasedPlan(c *gc.C) {
 		about: "everything works - yaml",
 		args:  []string{"testisv/default", "--format", "yaml"},
 		assertStdout: func(c *gc.C, output string) {
-			c.Assert(output, jc.YAMLEquals, cmd.FromWire(false, p))
+			c.Assert(output, jc.YAMLEquals, cmd.FromWire(false, p, states))
 		},
 		assertCalls: func(stub *testing.Stub) {
 			stub.CheckCall(c, 0, "GetPlanDetails", "testisv/default")
@@ -331,7 +339,7 @@ func (s *showSuite) TestCommandWithUnreleasedPlan(c *gc.C) {
 		about: "everything works - json - content",
 		args:  []string{"testisv/default", "--format", "json", "--content"},
 		assertStdout: func(c *gc.C, output string) {
-			c.Assert(output, jc.JSONEquals, cmd.FromWire(true, p))
+			c.Assert(output, jc.JSONEquals, cmd.FromWire(true, p, states))
 		},
 		assertCalls: func(stub *testing.Stub) {
 			stub.CheckCall(c, 0, "GetPlanDetails", "testisv/default")
@@ -340,7 +348,7 @@ func (s *showSuite) TestCommandWithUnreleasedPlan(c *gc.C) {
 		about: "everything works - yaml - content",
 		args:  []string{"testisv/default", "--format", "yaml", "--content"},
 		assertStdout: func(c *gc.C, output string) {
-			c.Assert(output, jc.YAMLEquals, cmd.FromWire(true, p))
+			c.Assert(output, jc.YAMLEquals, cmd.FromWire(true, p, states))
 		},
 		assertCalls: func(stub *testing.Stub) {
 			stub.CheckCall(c, 0, "GetPlanDetails", "testisv/default")
@@ -417,3 +425,40 @@ testisv/default/1
 		}
 	}
 }
+
+func (s *showSuite) TestSelector(c *gc.C) {
+	s.mockAPI.PlanRevisions = []wireformat.Plan{
+		{Id: "testisv/default/1", URL: "testisv/default", Released: true},
+		{Id: "testisv/default/2", URL: "testisv/default"},
+	}
+	_, err := cmdtesting.RunCommand(c, &cmd.ShowCommand{}, "testisv/default/-1", "--format", "json")
+	c.Assert(err, jc.ErrorIsNil)
+	s.mockAPI.CheckCallNames(c, "GetPlanRevisions", "GetPlanDetails")
+	s.mockAPI.CheckCall(c, 0, "GetPlanRevisions", "testisv/default")
+	s.mockAPI.CheckCall(c, 1, "GetPlanDetails", "testisv/default/1")
+}
+
+func (s *showSuite) TestInvalidSelector(c *gc.C) {
+	_, err := cmdtesting.RunCommand(c, &cmd.ShowCommand{}, "testisv/default@last-week")
+	c.Assert(err, gc.ErrorMatches, `invalid plan: invalid plan selector "testisv/default@last-week": timestamp "last-week" not valid`)
+	s.mockAPI.CheckNoCalls(c)
+}
+
+func (s *showSuite) TestReleaseNotes(c *gc.C) {
+	s.mockAPI.PlanDetails = &wireformat.PlanDetails{
+		Plan: wireformat.Plan{
+			Id:           "testisv/default/1",
+			URL:          "testisv/default",
+			Definition:   plantesting.TestPlan,
+			ReleaseNotes: "lower the price",
+		},
+		Created: wireformat.Event{User: "jane", Type: "create", Time: time.Date(2015, 1, 1, 1, 0, 0, 0, time.UTC)},
+	}
+	ctx, err := cmdtesting.RunCommand(c, &cmd.ShowCommand{}, "testisv/default/1", "--format", "yaml")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), jc.Contains, "release-notes: lower the price\n")
+
+	ctx, err = cmdtesting.RunCommand(c, &cmd.ShowCommand{}, "testisv/default/1")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(cmdtesting.Stdout(ctx), gc.Matches, `(?s).*RELEASE NOTES\s+lower the price\n.*`)
+}
@@ -0,0 +1,253 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/juju/cmd"
+	"github.com/juju/errors"
+	"github.com/juju/gnuflag"
+)
+
+const superCommandDoc = `
+charm-plans manages rating plans and their association with charms.
+
+Flags specified ahead of the command name (--url, -B, --format, --profile
+and the logging flags) apply to the command that follows.
+
+See "charm-plans help commands" for the list of available commands.
+`
+
+const superCommandPurpose = "manage charm plans"
+
+const planURLsTopic = `
+Plans are identified by their owner and name, optionally followed by a
+revision number:
+
+    canonical/landscape-default      the plan url
+    canonical/landscape-default/7    revision 7 of the plan
+
+Commands that operate on a plan as a whole (attach-plan, suspend-plan,
+resume-plan) expect a plan url, release-plan expects a plan revision and
+show-plan accepts either.
+
+show-plan, release-plan and show-plan-revisions also accept revision
+selectors, resolved against the revisions of the plan:
+
+    canonical/landscape-default/latest       the latest revision
+    canonical/landscape-default/released     the latest released revision
+    canonical/landscape-default/3..7         revisions 3 to 7
+    canonical/landscape-default/-1           the revision before the latest
+    canonical/landscape-default@2017-03-01   the revision effective at a
+                                             YYYY-MM-DD date, interpreted
+                                             as midnight UTC, or at an
+                                             RFC3339 time
+
+show-plan and release-plan require a selector matching a single revision.
+
+When working against several plans services, a plan may be qualified with
+the plans service it belongs to, wherever a plan url or revision is
+expected:
+
+    plan:canonical/landscape-default/7
+    https://plans.staging.example/canonical/landscape-default/7
+
+The plans service named by a reference overrides the one specified with
+--url or by the profile. The plan: scheme names no plans service.
+`
+
+const authenticationTopic = `
+By default the plan commands authenticate with the identity manager by
+opening a web browser, or by prompting for Ubuntu SSO credentials when -B
+is specified.
+
+Unattended clients (for example CI pipelines) authenticate with one of:
+
+    --agent-file <path>     an agent file holding the key pair and the
+                            agent users registered with the identity
+                            manager, defaulting to $BAKERY_AGENT_FILE
+    --macaroon-file <path>  pre-discharged macaroons for the plans service,
+                            as JSON or base64-encoded JSON, defaulting to
+                            $PLANS_MACAROON_FILE
+
+An agent file has the format:
+
+    {
+        "key": {"public": "<public key>", "private": "<private key>"},
+        "agents": [{"url": "<identity manager url>", "username": "<user>"}]
+    }
+
+With either flag, interactive login is disabled and the command fails if a
+discharge requires interaction.
+`
+
+// commandAliases maps the names of the standalone charm-* binaries
+// to the plan commands they run.
+var commandAliases = map[string]string{
+	"charm-attach-plan":         "attach-plan",
+	"charm-list-plans":          "list-plans",
+	"charm-push-plan":           "push-plan",
+	"charm-release-plan":        "release-plans",
+	"release-plan":              "release-plans",
+	"charm-resume-plan":         "resume-plan",
+	"charm-show-plan":           "show-plan",
+	"charm-show-plan-revisions": "show-plan-revisions",
+	"charm-suspend-plan":        "suspend-plan",
+}
+
+// NewSuperCommand returns the charm-plans command, which runs all plan
+// commands as sub-commands.
+func NewSuperCommand() *cmd.SuperCommand {
+	flags := newGlobalFlags()
+	super := cmd.NewSuperCommand(cmd.SuperCommandParams{
+		Name:        "charm-plans",
+		Purpose:     superCommandPurpose,
+		Doc:         superCommandDoc,
+		Log:         &cmd.Log{},
+		GlobalFlags: flags,
+	})
+	for _, c := range planCommands() {
+		super.Register(&globalCommand{Command: c, flags: flags})
+	}
+	super.Register(&globalCommand{Command: NewCompletionCommand(super.Name), flags: flags})
+	for alias, name := range commandAliases {
+		super.RegisterAlias(alias, name, nil)
+	}
+	super.AddHelpTopic("plan-urls", "How plans and plan revisions are specified", planURLsTopic)
+	super.AddHelpTopic("authentication", "How to authenticate without user interaction", authenticationTopic)
+	return super
+}
+
+// planCommands returns new instances of all commands run by
+// charm-plans, other than the completion command.
+func planCommands() []cmd.Command {
+	return []cmd.Command{
+		NewAttachCommand(),
+		NewAuthorizePlanCommand(),
+		NewAuthorizeResellerCommand(),
+		NewCheckBundlePlansCommand(),
+		NewConfigCommand(),
+		NewInspectMacaroonCommand(),
+		NewListAuthorizationsCommand(),
+		NewListPlansCommand(),
+		NewListResellerAuthorizationsCommand(),
+		NewLoginCommand(),
+		NewLogoutCommand(),
+		NewPlanAtCommand(),
+		NewPlanHistoryCommand(),
+		NewPushCommand(),
+		NewReleaseCommand(),
+		NewReportAuthorizationsCommand(),
+		NewReportModelPlansCommand(),
+		NewResumeCommand(),
+		NewSchemaCommand(),
+		NewShowCommand(),
+		NewShowRevisionsCommand(),
+		NewSuspendCommand(),
+		NewWhoamiCommand(),
+	}
+}
+
+// globalFlags holds the flags the charm-plans command accepts ahead of
+// the sub-command name.
+type globalFlags struct {
+	serviceURL *sharedValue
+	noBrowser  *sharedValue
+	format     *sharedValue
+	profile    *sharedValue
+}
+
+func newGlobalFlags() *globalFlags {
+	return &globalFlags{
+		serviceURL: &sharedValue{value: defaultServiceURL()},
+		noBrowser:  &sharedValue{value: "false", isBool: true},
+		format:     &sharedValue{},
+		profile:    &sharedValue{value: os.Getenv("PLANS_PROFILE")},
+	}
+}
+
+// AddFlags implements cmd.FlagAdder.
+func (g *globalFlags) AddFlags(f *gnuflag.FlagSet) {
+	f.Var(g.serviceURL, "url", "host and port of the plans services")
+	f.Var(g.noBrowser, "B", "Do not use web browser for authentication")
+	f.Var(g.noBrowser, "no-browser-login", "")
+	f.Var(g.format, "format", "Specify output format")
+	f.Var(g.profile, "profile", "name of the configuration profile to use")
+}
+
+// sharedValue is a gnuflag.Value registered by the super command that
+// forwards its value to the same-named flags of the selected sub-command.
+type sharedValue struct {
+	value  string
+	set    bool
+	isBool bool
+	bound  []gnuflag.Value
+}
+
+// String implements gnuflag.Value.
+func (v *sharedValue) String() string {
+	return v.value
+}
+
+// Set implements gnuflag.Value.
+func (v *sharedValue) Set(s string) error {
+	for _, target := range v.bound {
+		if err := target.Set(s); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	v.value, v.set = s, true
+	return nil
+}
+
+// IsBoolFlag reports whether the flag may be given without a value.
+func (v *sharedValue) IsBoolFlag() bool {
+	return v.isBool
+}
+
+// bind makes the target receive all values subsequently set on the shared
+// value, as well as any value set before the target was bound.
+func (v *sharedValue) bind(target gnuflag.Value) error {
+	v.bound = append(v.bound, target)
+	if v.set {
+		return errors.Trace(target.Set(v.value))
+	}
+	return nil
+}
+
+// globalCommand wraps a command registered with the charm-plans super
+// command, binding the command's flags to the global flags of the
+// same name.
+type globalCommand struct {
+	cmd.Command
+	flags *globalFlags
+
+	bindErr error
+}
+
+// SetFlags implements Command.SetFlags.
+func (c *globalCommand) SetFlags(f *gnuflag.FlagSet) {
+	own := gnuflag.NewFlagSet(c.Info().Name, gnuflag.ContinueOnError)
+	c.Command.SetFlags(own)
+	own.VisitAll(func(flag *gnuflag.Flag) {
+		if existing := f.Lookup(flag.Name); existing != nil {
+			if shared, ok := existing.Value.(*sharedValue); ok {
+				if err := shared.bind(flag.Value); err != nil && c.bindErr == nil {
+					c.bindErr = errors.Annotatef(err, "invalid value %q for flag --%s", shared.value, flag.Name)
+				}
+				return
+			}
+		}
+		f.Var(flag.Value, flag.Name, flag.Usage)
+	})
+}
+
+// Init implements Command.Init.
+func (c *globalCommand) Init(args []string) error {
+	if c.bindErr != nil {
+		return c.bindErr
+	}
+	return c.Command.Init(args)
+}
@@ -0,0 +1,144 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package cmd_test
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/juju/cmd/cmdtesting"
+	"github.com/juju/loggo"
+	"github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	"gopkg.in/macaroon-bakery.v2/httpbakery"
+
+	"github.com/juju/plans-client/api"
+	"github.com/juju/plans-client/api/wireformat"
+	"github.com/juju/plans-client/cmd"
+	plantesting "github.com/juju/plans-client/testing"
+)
+
+type superCommandSuite struct {
+	testing.CleanupSuite
+	mockAPI    *plantesting.MockPlanClient
+	serviceURL string
+}
+
+var _ = gc.Suite(&superCommandSuite{})
+
+func (s *superCommandSuite) SetUpTest(c *gc.C) {
+	s.CleanupSuite.SetUpTest(c)
+	s.PatchEnvironment("GOCOOKIES", filepath.Join(c.MkDir(), "cookies"))
+	s.mockAPI = plantesting.NewMockPlanClient()
+	s.mockAPI.Plans = []wireformat.Plan{{
+		Id:         "canonical/test-plan/1",
+		URL:        "canonical/test-plan",
+		Definition: "test definition",
+		CreatedOn:  time.Date(2017, 12, 1, 0, 0, 0, 0, time.UTC).Format(time.RFC3339),
+	}}
+	s.serviceURL = ""
+	s.PatchValue(cmd.NewClient, func(url string, _ *httpbakery.Client) (api.PlanClient, error) {
+		s.serviceURL = url
+		return s.mockAPI, nil
+	})
+	s.AddCleanup(func(*gc.C) {
+		loggo.RemoveWriter("warning")
+	})
+}
+
+func (s *superCommandSuite) TestGlobalFlags(c *gc.C) {
+	tests := []struct {
+		about      string
+		args       []string
+		err        string
+		serviceURL string
+		stdout     string
+	}{{
+		about:      "global url flag",
+		args:       []string{"--url", "https://global.example", "list-plans", "canonical"},
+		serviceURL: "https://global.example",
+		stdout: `PLAN                 	          CREATED ON	EFFECTIVE TIME	     DEFINITION
+canonical/test-plan/1	2017-12-01T00:00:00Z	              	test definition
+`,
+	}, {
+		about:      "command url flag",
+		args:       []string{"list-plans", "canonical", "--url", "https://local.example"},
+		serviceURL: "https://local.example",
+		stdout: `PLAN                 	          CREATED ON	EFFECTIVE TIME	     DEFINITION
+canonical/test-plan/1	2017-12-01T00:00:00Z	              	test definition
+`,
+	}, {
+		about:      "global format flag",
+		args:       []string{"--url", "https://global.example", "--format", "json", "list-plans", "canonical"},
+		serviceURL: "https://global.example",
+		stdout: `[{"id":"canonical/test-plan/1","url":"canonical/test-plan","plan":"test definition","created-on":"2017-12-01T00:00:00Z","description":"","price":"","released":false}]
+`,
+	}, {
+		about: "unknown global format",
+		args:  []string{"--format", "csv", "list-plans", "canonical"},
+		err:   `invalid value "csv" for flag --format: unknown format "csv"`,
+	}, {
+		about:      "binary name alias",
+		args:       []string{"charm-list-plans", "canonical", "--url", "https://alias.example"},
+		serviceURL: "https://alias.example",
+		stdout: `PLAN                 	          CREATED ON	EFFECTIVE TIME	     DEFINITION
+canonical/test-plan/1	2017-12-01T00:00:00Z	              	test definition
+`,
+	}}
+	for i, t := range tests {
+		c.Logf("Running test %d %s", i, t.about)
+		s.mockAPI.ResetCalls()
+		s.serviceURL = ""
+		ctx, err := cmdtesting.RunCommand(c, cmd.NewSuperCommand(), t.args...)
+		loggo.RemoveWriter("warning")
+		if t.err != "" {
+			c.Assert(err, gc.ErrorMatches, t.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(s.serviceURL, gc.Equals, t.serviceURL)
+		s.mockAPI.CheckCall(c, 0, "GetPlans", "canonical")
+		c.Assert(cmdtesting.Stdout(ctx), gc.Equals, t.stdout)
+	}
+}
+
+func (s *superCommandSuite) TestHelpTopics(c *gc.C) {
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewSuperCommand(), "help", "topics")
+	c.Assert(err, jc.ErrorIsNil)
+	c.Check(cmdtesting.Stdout(ctx), jc.Contains, "plan-urls")
+	c.Check(cmdtesting.Stdout(ctx), jc.Contains, "authentication")
+}
+
+func (s *superCommandSuite) TestHelpCommands(c *gc.C) {
+	ctx, err := cmdtesting.RunCommand(c, cmd.NewSuperCommand(), "help", "commands")
+	c.Assert(err, jc.ErrorIsNil)
+	for _, name := range []string{
+		"attach-plan",
+		"authorize-plan",
+		"authorize-reseller",
+		"check-bundle-plans",
+		"charm-list-plans",
+		"inspect-macaroon",
+		"list-authorizations",
+		"list-plans",
+		"list-reseller-authorizations",
+		"login",
+		"logout",
+		"plan-at",
+		"plan-history",
+		"push-plan",
+		"release-plan",
+		"report-authorizations",
+		"report-model-plans",
+		"resume-plan",
+		"schema",
+		"show-plan",
+		"show-plan-revisions",
+		"suspend-plan",
+		"whoami",
+	} {
+		c.Check(cmdtesting.Stdout(ctx), jc.Contains, name)
+	}
+}
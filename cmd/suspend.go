@@ -81,7 +81,11 @@ func (c *suspendResumeCommand) Init(args []string) error {
 		return errors.New("cannot use --all and specify charm urls")
 	}
 
-	c.PlanURL, c.CharmURLs = args[0], args[1:]
+	planURL, err := c.unqualifyPlan(args[0])
+	if err != nil {
+		return errors.Trace(err)
+	}
+	c.PlanURL, c.CharmURLs = planURL, args[1:]
 	return nil
 }
 
@@ -12,6 +12,7 @@ require (
 	github.com/juju/errors v0.0.0-20200330140219-3fe23663418f
 	github.com/juju/gnuflag v0.0.0-20171113085948-2ce1bb71843d
 	github.com/juju/juju v0.0.0-20201007080928-1f35f6a20b57
+	github.com/juju/loggo v0.0.0-20200526014432-9ce3a2e09b5e
 	github.com/juju/names v0.0.0-20180129205841-f9b5b8b7614d
 	github.com/juju/names/v4 v4.0.0-20200923012352-008effd8611b
 	github.com/juju/persistent-cookiejar v0.0.0-20171026135701-d5e5a8405ef9
@@ -23,6 +24,7 @@ require (
 	gopkg.in/juju/environschema.v1 v1.0.0
 	gopkg.in/macaroon-bakery.v2 v2.2.0
 	gopkg.in/macaroon.v1 v1.0.0
+	gopkg.in/macaroon.v2 v2.1.0
 	gopkg.in/yaml.v2 v2.3.0
 )
 
@@ -0,0 +1,344 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+// Package macaroons decodes and inspects the authorization macaroons
+// issued by the plans service, and verifies offline that their declared
+// caveats match a deployment.
+package macaroons
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/juju/errors"
+	"gopkg.in/macaroon-bakery.v2/bakery/checkers"
+	macaroonv1 "gopkg.in/macaroon.v1"
+	"gopkg.in/macaroon.v2"
+)
+
+// Declared attribute names used by the plans service in authorization
+// macaroons. Older macaroons use the Juju 1 names "environment" and
+// "service".
+var (
+	modelKeys       = []string{"model", "environment"}
+	charmKeys       = []string{"charm"}
+	applicationKeys = []string{"application", "service"}
+	planKeys        = []string{"plan"}
+)
+
+// Decode decodes a macaroon, or a macaroon followed by its discharges,
+// from JSON, base64-encoded JSON or base64-encoded binary data.
+func Decode(data []byte) (macaroon.Slice, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, errors.New("empty macaroon")
+	}
+	if data[0] == '{' || data[0] == '[' {
+		return decodeJSON(data)
+	}
+	decoded, err := decodeBase64(string(data))
+	if err != nil {
+		return nil, errors.New("expected JSON, base64-encoded JSON or base64-encoded binary macaroon")
+	}
+	if len(decoded) > 0 && (decoded[0] == '{' || decoded[0] == '[') {
+		return decodeJSON(decoded)
+	}
+	var ms macaroon.Slice
+	if err := ms.UnmarshalBinary(decoded); err != nil {
+		return nil, errors.Annotate(err, "failed to decode binary macaroon")
+	}
+	if len(ms) == 0 {
+		return nil, errors.New("empty macaroon")
+	}
+	return ms, nil
+}
+
+// FromV1 converts macaroons of the version 1 macaroon package, as returned
+// by the Authorize and AuthorizeReseller methods of api.PlanClient, to a
+// slice that may be inspected.
+func FromV1(ms ...*macaroonv1.Macaroon) (macaroon.Slice, error) {
+	slice := make(macaroon.Slice, len(ms))
+	for i, m := range ms {
+		if m == nil {
+			return nil, errors.New("nil macaroon")
+		}
+		data, err := m.MarshalBinary()
+		if err != nil {
+			return nil, errors.Annotate(err, "failed to encode macaroon")
+		}
+		var m2 macaroon.Macaroon
+		if err := m2.UnmarshalBinary(data); err != nil {
+			return nil, errors.Annotate(err, "failed to decode macaroon")
+		}
+		slice[i] = &m2
+	}
+	return slice, nil
+}
+
+func decodeJSON(data []byte) (macaroon.Slice, error) {
+	if data[0] == '{' {
+		var m macaroon.Macaroon
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, errors.Annotate(err, "failed to decode macaroon")
+		}
+		return macaroon.Slice{&m}, nil
+	}
+	var ms macaroon.Slice
+	if err := json.Unmarshal(data, &ms); err != nil {
+		return nil, errors.Annotate(err, "failed to decode macaroons")
+	}
+	if len(ms) == 0 {
+		return nil, errors.New("empty macaroon")
+	}
+	return ms, nil
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	if data, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return data, nil
+	}
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// Info holds the human-readable contents of a macaroon.
+type Info struct {
+	Location          string             `json:"location" yaml:"location"`
+	ID                string             `json:"id" yaml:"id"`
+	Signature         string             `json:"signature" yaml:"signature"`
+	FirstPartyCaveats []string           `json:"first-party-caveats,omitempty" yaml:"first-party-caveats,omitempty"`
+	ThirdPartyCaveats []ThirdPartyCaveat `json:"third-party-caveats,omitempty" yaml:"third-party-caveats,omitempty"`
+	Declared          map[string]string  `json:"declared,omitempty" yaml:"declared,omitempty"`
+	Expires           *time.Time         `json:"expires,omitempty" yaml:"expires,omitempty"`
+	Discharges        []DischargeInfo    `json:"discharges,omitempty" yaml:"discharges,omitempty"`
+
+	declaredConflicts map[string][]string
+}
+
+// ThirdPartyCaveat describes a third party caveat of a macaroon.
+type ThirdPartyCaveat struct {
+	Location   string `json:"location" yaml:"location"`
+	ID         string `json:"id" yaml:"id"`
+	Discharged bool   `json:"discharged" yaml:"discharged"`
+}
+
+// DischargeInfo holds the human-readable contents of a discharge
+// macaroon.
+type DischargeInfo struct {
+	Location          string     `json:"location" yaml:"location"`
+	ID                string     `json:"id" yaml:"id"`
+	FirstPartyCaveats []string   `json:"first-party-caveats,omitempty" yaml:"first-party-caveats,omitempty"`
+	Expires           *time.Time `json:"expires,omitempty" yaml:"expires,omitempty"`
+}
+
+// Inspect returns the contents of the primary macaroon of the slice,
+// followed by its discharges. The declared attributes and the expiry
+// time take the caveats of the discharges into account.
+func Inspect(ms macaroon.Slice) (*Info, error) {
+	if len(ms) == 0 || ms[0] == nil {
+		return nil, errors.New("no macaroon to inspect")
+	}
+	ns := checkers.New(nil).Namespace()
+	primary := ms[0]
+	info := &Info{
+		Location:          primary.Location(),
+		ID:                printableID(primary.Id()),
+		Signature:         hex.EncodeToString(primary.Signature()),
+		declaredConflicts: declaredConflicts(ns, ms),
+	}
+	discharged := make(map[string]bool)
+	for _, d := range ms[1:] {
+		discharged[string(d.Id())] = true
+		di := DischargeInfo{
+			Location:          d.Location(),
+			ID:                printableID(d.Id()),
+			FirstPartyCaveats: firstPartyCaveats(d),
+		}
+		if t, ok := checkers.ExpiryTime(ns, d.Caveats()); ok {
+			t = t.UTC()
+			di.Expires = &t
+		}
+		info.Discharges = append(info.Discharges, di)
+	}
+	for _, cav := range primary.Caveats() {
+		if len(cav.VerificationId) == 0 {
+			info.FirstPartyCaveats = append(info.FirstPartyCaveats, string(cav.Id))
+			continue
+		}
+		info.ThirdPartyCaveats = append(info.ThirdPartyCaveats, ThirdPartyCaveat{
+			Location:   cav.Location,
+			ID:         printableID(cav.Id),
+			Discharged: discharged[string(cav.Id)],
+		})
+	}
+	if declared := checkers.InferDeclared(ns, ms); len(declared) > 0 {
+		info.Declared = declared
+	}
+	if t, ok := checkers.MacaroonsExpiryTime(ns, ms); ok {
+		t = t.UTC()
+		info.Expires = &t
+	}
+	return info, nil
+}
+
+func firstPartyCaveats(m *macaroon.Macaroon) []string {
+	var caveats []string
+	for _, cav := range m.Caveats() {
+		if len(cav.VerificationId) == 0 {
+			caveats = append(caveats, string(cav.Id))
+		}
+	}
+	return caveats
+}
+
+// declaredConflicts returns the attributes declared with different
+// values by the macaroons, which checkers.InferDeclared silently drops.
+func declaredConflicts(ns *checkers.Namespace, ms macaroon.Slice) map[string][]string {
+	prefix, _ := ns.Resolve(checkers.StdNamespace)
+	values := make(map[string][]string)
+	for _, m := range ms {
+		for _, cav := range m.Caveats() {
+			if len(cav.VerificationId) != 0 {
+				continue
+			}
+			name, arg, err := checkers.ParseCaveat(string(cav.Id))
+			if err != nil || name != checkers.ConditionWithPrefix(prefix, checkers.CondDeclared) {
+				continue
+			}
+			parts := strings.SplitN(arg, " ", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			if !contains(values[parts[0]], parts[1]) {
+				values[parts[0]] = append(values[parts[0]], parts[1])
+			}
+		}
+	}
+	var conflicts map[string][]string
+	for key, vals := range values {
+		if len(vals) > 1 {
+			if conflicts == nil {
+				conflicts = make(map[string][]string)
+			}
+			sort.Strings(vals)
+			conflicts[key] = vals
+		}
+	}
+	return conflicts
+}
+
+// printableID returns the macaroon or caveat id as text when it is
+// printable, and base64-encoded otherwise.
+func printableID(id []byte) string {
+	if utf8.Valid(id) && strings.IndexFunc(string(id), func(r rune) bool { return !unicode.IsPrint(r) }) < 0 {
+		return string(id)
+	}
+	return base64.RawURLEncoding.EncodeToString(id)
+}
+
+// Expected holds the deployment an authorization macaroon is expected
+// to be used for. Empty fields are not verified.
+type Expected struct {
+	ModelUUID   string
+	CharmURL    string
+	Application string
+	Plan        string
+}
+
+// VerificationError is returned by Verify when the macaroon does not
+// match the expected deployment.
+type VerificationError struct {
+	Problems []string
+}
+
+// Error implements error.
+func (e *VerificationError) Error() string {
+	return "macaroon verification failed: " + strings.Join(e.Problems, "; ")
+}
+
+// IsVerificationError returns true if the error cause is a
+// *VerificationError.
+func IsVerificationError(err error) bool {
+	_, ok := errors.Cause(err).(*VerificationError)
+	return ok
+}
+
+// Verify checks offline that the macaroon has not expired at the given
+// time, that its third party caveats are discharged and that its
+// declared attributes match the expected deployment. It returns a
+// *VerificationError listing all the problems found. The signatures
+// cannot be verified without the root key held by the plans service.
+func (info *Info) Verify(expected Expected, now time.Time) error {
+	var problems []string
+	if info.Expires != nil && !info.Expires.After(now) {
+		problems = append(problems, fmt.Sprintf("macaroon expired at %s", info.Expires.Format(time.RFC3339)))
+	}
+	for _, cav := range info.ThirdPartyCaveats {
+		if !cav.Discharged {
+			problems = append(problems, fmt.Sprintf("third party caveat %q at %s not discharged", cav.ID, cav.Location))
+		}
+	}
+	checks := []struct {
+		attr     string
+		keys     []string
+		expected string
+	}{
+		{"model", modelKeys, expected.ModelUUID},
+		{"charm", charmKeys, expected.CharmURL},
+		{"application", applicationKeys, expected.Application},
+		{"plan", planKeys, expected.Plan},
+	}
+	for _, check := range checks {
+		if check.expected == "" {
+			continue
+		}
+		if problem := info.checkDeclared(check.attr, check.keys, check.expected); problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+	if len(problems) > 0 {
+		return &VerificationError{Problems: problems}
+	}
+	return nil
+}
+
+func (info *Info) checkDeclared(attr string, keys []string, expected string) string {
+	for _, key := range keys {
+		if conflicting, ok := info.declaredConflicts[key]; ok {
+			return fmt.Sprintf("%s declared with conflicting values %s", attr, strings.Join(quote(conflicting), ", "))
+		}
+		value, ok := info.Declared[key]
+		if !ok {
+			continue
+		}
+		if value != expected {
+			return fmt.Sprintf("%s mismatch: macaroon declares %q, expected %q", attr, value, expected)
+		}
+		return ""
+	}
+	return fmt.Sprintf("%s not declared by the macaroon", attr)
+}
+
+func quote(values []string) []string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return quoted
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
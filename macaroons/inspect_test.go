@@ -0,0 +1,267 @@
+// Copyright 2017 Canonical Ltd.
+// Licensed under the GPLv3, see LICENCE file for details.
+
+package macaroons_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "gopkg.in/check.v1"
+	macaroonv1 "gopkg.in/macaroon.v1"
+	"gopkg.in/macaroon.v2"
+
+	"github.com/juju/plans-client/macaroons"
+)
+
+const testModelUUID = "9d4ba8a9-1b2c-4f5e-8e3a-2f0c63a1c0de"
+
+var testExpiry = time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)
+
+type inspectSuite struct{}
+
+var _ = gc.Suite(&inspectSuite{})
+
+// newAuthorization returns an authorization macaroon with the given
+// first party caveats, followed by the discharge of its third party
+// caveat unless discharged is false.
+func newAuthorization(c *gc.C, discharged bool, caveats ...string) macaroon.Slice {
+	m, err := macaroon.New([]byte("root-key"), []byte("plan-auth"), "plans", macaroon.LatestVersion)
+	c.Assert(err, jc.ErrorIsNil)
+	for _, cav := range caveats {
+		err := m.AddFirstPartyCaveat([]byte(cav))
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	err = m.AddThirdPartyCaveat([]byte("third-party-key"), []byte("is-authenticated-user"), "https://identity.example")
+	c.Assert(err, jc.ErrorIsNil)
+	if !discharged {
+		return macaroon.Slice{m}
+	}
+	d, err := macaroon.New([]byte("third-party-key"), []byte("is-authenticated-user"), "https://identity.example", macaroon.LatestVersion)
+	c.Assert(err, jc.ErrorIsNil)
+	err = d.AddFirstPartyCaveat([]byte("declared username bob"))
+	c.Assert(err, jc.ErrorIsNil)
+	d.Bind(m.Signature())
+	return macaroon.Slice{m, d}
+}
+
+func defaultCaveats() []string {
+	return []string{
+		"declared environment " + testModelUUID,
+		"declared charm cs:~testisv/charm1-0",
+		"declared service app1",
+		"declared plan testisv/default",
+		"time-before " + testExpiry.Format(time.RFC3339Nano),
+	}
+}
+
+func (s *inspectSuite) TestDecode(c *gc.C) {
+	ms := newAuthorization(c, true, defaultCaveats()...)
+	sliceJSON, err := json.Marshal(ms)
+	c.Assert(err, jc.ErrorIsNil)
+	primaryJSON, err := json.Marshal(ms[0])
+	c.Assert(err, jc.ErrorIsNil)
+	binary, err := ms.MarshalBinary()
+	c.Assert(err, jc.ErrorIsNil)
+	v1, err := macaroonv1.New([]byte("root-key"), "plan-auth", "plans")
+	c.Assert(err, jc.ErrorIsNil)
+	v1Binary, err := v1.MarshalBinary()
+	c.Assert(err, jc.ErrorIsNil)
+
+	tests := []struct {
+		about string
+		data  string
+		count int
+		err   string
+	}{{
+		about: "JSON slice",
+		data:  string(sliceJSON),
+		count: 2,
+	}, {
+		about: "JSON macaroon",
+		data:  " " + string(primaryJSON) + "\n",
+		count: 1,
+	}, {
+		about: "base64 JSON",
+		data:  base64.StdEncoding.EncodeToString(sliceJSON),
+		count: 2,
+	}, {
+		about: "base64 binary",
+		data:  base64.RawURLEncoding.EncodeToString(binary),
+		count: 2,
+	}, {
+		about: "base64 binary version 1",
+		data:  base64.StdEncoding.EncodeToString(v1Binary),
+		count: 1,
+	}, {
+		about: "empty",
+		data:  " ",
+		err:   "empty macaroon",
+	}, {
+		about: "empty slice",
+		data:  "[]",
+		err:   "empty macaroon",
+	}, {
+		about: "invalid",
+		data:  "not a macaroon!",
+		err:   "expected JSON, base64-encoded JSON or base64-encoded binary macaroon",
+	}}
+	for i, t := range tests {
+		c.Logf("running test %d: %s", i, t.about)
+		decoded, err := macaroons.Decode([]byte(t.data))
+		if t.err != "" {
+			c.Assert(err, gc.ErrorMatches, t.err)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+		c.Assert(decoded, gc.HasLen, t.count)
+		c.Assert(string(decoded[0].Id()), gc.Equals, "plan-auth")
+	}
+}
+
+func (s *inspectSuite) TestInspect(c *gc.C) {
+	ms := newAuthorization(c, true, defaultCaveats()...)
+	info, err := macaroons.Inspect(ms)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(info, jc.DeepEquals, &macaroons.Info{
+		Location:          "plans",
+		ID:                "plan-auth",
+		Signature:         info.Signature,
+		FirstPartyCaveats: defaultCaveats(),
+		ThirdPartyCaveats: []macaroons.ThirdPartyCaveat{{
+			Location:   "https://identity.example",
+			ID:         "is-authenticated-user",
+			Discharged: true,
+		}},
+		Declared: map[string]string{
+			"environment": testModelUUID,
+			"charm":       "cs:~testisv/charm1-0",
+			"service":     "app1",
+			"plan":        "testisv/default",
+			"username":    "bob",
+		},
+		Expires: &testExpiry,
+		Discharges: []macaroons.DischargeInfo{{
+			Location:          "https://identity.example",
+			ID:                "is-authenticated-user",
+			FirstPartyCaveats: []string{"declared username bob"},
+		}},
+	})
+	c.Assert(info.Signature, gc.HasLen, 64)
+}
+
+func (s *inspectSuite) TestFromV1(c *gc.C) {
+	m, err := macaroonv1.New([]byte("root-key"), "plan-auth", "plans")
+	c.Assert(err, jc.ErrorIsNil)
+	for _, cav := range defaultCaveats() {
+		err := m.AddFirstPartyCaveat(cav)
+		c.Assert(err, jc.ErrorIsNil)
+	}
+	ms, err := macaroons.FromV1(m)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(ms, gc.HasLen, 1)
+	c.Assert(ms[0].Signature(), jc.DeepEquals, m.Signature())
+	info, err := macaroons.Inspect(ms)
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(info.FirstPartyCaveats, jc.DeepEquals, defaultCaveats())
+	c.Assert(info.Declared["plan"], gc.Equals, "testisv/default")
+
+	_, err = macaroons.FromV1(nil)
+	c.Assert(err, gc.ErrorMatches, "nil macaroon")
+}
+
+func (s *inspectSuite) TestInspectBinaryID(c *gc.C) {
+	m, err := macaroon.New([]byte("root-key"), []byte{0, 1, 2}, "plans", macaroon.LatestVersion)
+	c.Assert(err, jc.ErrorIsNil)
+	info, err := macaroons.Inspect(macaroon.Slice{m})
+	c.Assert(err, jc.ErrorIsNil)
+	c.Assert(info.ID, gc.Equals, "AAEC")
+}
+
+func (s *inspectSuite) TestVerify(c *gc.C) {
+	expected := macaroons.Expected{
+		ModelUUID:   testModelUUID,
+		CharmURL:    "cs:~testisv/charm1-0",
+		Application: "app1",
+		Plan:        "testisv/default",
+	}
+	before := testExpiry.Add(-time.Hour)
+	tests := []struct {
+		about      string
+		caveats    []string
+		discharged bool
+		expected   macaroons.Expected
+		now        time.Time
+		err        string
+	}{{
+		about:      "matching deployment",
+		caveats:    defaultCaveats(),
+		discharged: true,
+		expected:   expected,
+		now:        before,
+	}, {
+		about:      "juju 2 attribute names",
+		caveats:    []string{"declared model " + testModelUUID, "declared application app1"},
+		discharged: true,
+		expected:   macaroons.Expected{ModelUUID: testModelUUID, Application: "app1"},
+		now:        before,
+	}, {
+		about:      "nothing expected",
+		caveats:    defaultCaveats(),
+		discharged: true,
+		now:        before,
+	}, {
+		about:      "expired",
+		caveats:    defaultCaveats(),
+		discharged: true,
+		expected:   expected,
+		now:        testExpiry,
+		err:        `macaroon verification failed: macaroon expired at 2017-01-02T03:04:05Z`,
+	}, {
+		about:    "not discharged",
+		caveats:  defaultCaveats(),
+		expected: expected,
+		now:      before,
+		err:      `macaroon verification failed: third party caveat "is-authenticated-user" at https://identity.example not discharged`,
+	}, {
+		about:      "mismatches",
+		caveats:    defaultCaveats(),
+		discharged: true,
+		expected: macaroons.Expected{
+			ModelUUID:   testModelUUID,
+			CharmURL:    "cs:~testisv/charm1-1",
+			Application: "app2",
+			Plan:        "testisv/default",
+		},
+		now: before,
+		err: `macaroon verification failed: charm mismatch: macaroon declares "cs:~testisv/charm1-0", expected "cs:~testisv/charm1-1"; application mismatch: macaroon declares "app1", expected "app2"`,
+	}, {
+		about:      "not declared",
+		caveats:    []string{"declared charm cs:~testisv/charm1-0"},
+		discharged: true,
+		expected:   expected,
+		now:        before,
+		err:        `macaroon verification failed: model not declared by the macaroon; application not declared by the macaroon; plan not declared by the macaroon`,
+	}, {
+		about:      "conflicting declarations",
+		caveats:    []string{"declared plan testisv/default", "declared plan testisv/other"},
+		discharged: true,
+		expected:   macaroons.Expected{Plan: "testisv/default"},
+		now:        before,
+		err:        `macaroon verification failed: plan declared with conflicting values "testisv/default", "testisv/other"`,
+	}}
+	for i, t := range tests {
+		c.Logf("running test %d: %s", i, t.about)
+		info, err := macaroons.Inspect(newAuthorization(c, t.discharged, t.caveats...))
+		c.Assert(err, jc.ErrorIsNil)
+		err = info.Verify(t.expected, t.now)
+		if t.err != "" {
+			c.Assert(err, gc.ErrorMatches, t.err)
+			c.Assert(macaroons.IsVerificationError(err), jc.IsTrue)
+			continue
+		}
+		c.Assert(err, jc.ErrorIsNil)
+	}
+}
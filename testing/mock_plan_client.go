@@ -37,6 +37,18 @@ type MockPlanClient struct {
 	PlanRevisions []wireformat.Plan
 	Plans         []wireformat.Plan
 	Released      bool
+
+	// DefaultPlan and CharmPlans, when set, are returned by
+	// GetDefaultPlan and GetPlansForCharm.
+	DefaultPlan *wireformat.Plan
+	CharmPlans  []wireformat.Plan
+	// RevisionDetails, when set, holds the details returned by
+	// GetPlanDetails for each plan id.
+	RevisionDetails map[string]*wireformat.PlanDetails
+
+	Authorizations         []wireformat.Authorization
+	ResellerAuthorizations []wireformat.ResellerAuthorization
+	AuthorizationMacaroon  *macaroon.Macaroon
 }
 
 // NewMockPlanClient returns a new MockPlanClient
@@ -82,12 +94,25 @@ func (m *MockPlanClient) Suspend(_ context.Context, planURL string, all bool, ch
 // Save stores the plan in the mock.
 func (m *MockPlanClient) Save(_ context.Context, planURL, definition string) (*wireformat.Plan, error) {
 	m.MethodCall(m, "Save", planURL, definition)
+	return m.savedPlan(wireformat.PlanMetadata{}), m.NextErr()
+}
+
+// SaveWithMetadata stores the plan and its metadata in the mock.
+func (m *MockPlanClient) SaveWithMetadata(_ context.Context, planURL, definition string, metadata wireformat.PlanMetadata) (*wireformat.Plan, error) {
+	m.MethodCall(m, "SaveWithMetadata", planURL, definition, metadata)
+	return m.savedPlan(metadata), m.NextErr()
+}
+
+func (m *MockPlanClient) savedPlan(metadata wireformat.PlanMetadata) *wireformat.Plan {
 	return &wireformat.Plan{
-		Id:         "testisv/default/17",
-		URL:        "testisv/default",
-		Definition: TestPlan,
-		CreatedOn:  time.Date(2015, 1, 1, 1, 0, 0, 0, time.UTC).Format(time.RFC3339),
-	}, m.NextErr()
+		Id:              "testisv/default/17",
+		URL:             "testisv/default",
+		Definition:      TestPlan,
+		CreatedOn:       time.Date(2015, 1, 1, 1, 0, 0, 0, time.UTC).Format(time.RFC3339),
+		PlanDescription: metadata.Description,
+		PlanPrice:       metadata.Price,
+		ReleaseNotes:    metadata.ReleaseNotes,
+	}
 }
 
 // AddCharm adds a charm to an existing plan
@@ -98,6 +123,9 @@ func (m *MockPlanClient) AddCharm(_ context.Context, plan, charmURL string, isDe
 
 func (m *MockPlanClient) GetDefaultPlan(_ context.Context, charmURL string) (*wireformat.Plan, error) {
 	m.MethodCall(m, "GetDefaultPlan", charmURL)
+	if m.DefaultPlan != nil {
+		return m.DefaultPlan, m.NextErr()
+	}
 	p := &wireformat.Plan{
 		URL:        "testisv/default",
 		Definition: TestPlan,
@@ -108,6 +136,9 @@ func (m *MockPlanClient) GetDefaultPlan(_ context.Context, charmURL string) (*wi
 
 func (m *MockPlanClient) GetPlansForCharm(_ context.Context, charmURL string) ([]wireformat.Plan, error) {
 	m.MethodCall(m, "GetPlansForCharm", charmURL)
+	if m.CharmPlans != nil {
+		return m.CharmPlans, m.NextErr()
+	}
 	p := []wireformat.Plan{{
 		URL:        "testisv/default",
 		Definition: TestPlan,
@@ -144,6 +175,9 @@ func (m *MockPlanClient) GetPlanRevisions(_ context.Context, plan string) ([]wir
 // GetPlanDetails returns detailed information about a plan.
 func (m *MockPlanClient) GetPlanDetails(_ context.Context, planURL string) (*wireformat.PlanDetails, error) {
 	m.MethodCall(m, "GetPlanDetails", planURL)
+	if d, ok := m.RevisionDetails[planURL]; ok {
+		return d, m.NextErr()
+	}
 	if m.PlanDetails != nil {
 		return m.PlanDetails, m.NextErr()
 	} else {
@@ -197,23 +231,37 @@ func (m *MockPlanClient) GetPlanDetails(_ context.Context, planURL string) (*wir
 
 // Authorize returns the authorization macaroon for the specified environment, charm url and service name.
 func (m *MockPlanClient) Authorize(_ context.Context, environmentUUID, charmURL, serviceName, plan string) (*macaroon.Macaroon, error) {
-	panic("not implemented")
+	m.MethodCall(m, "Authorize", environmentUUID, charmURL, serviceName, plan)
+	return m.AuthorizationMacaroon, m.NextErr()
 }
 
 // AuthorizeReseller returns the reseller authorization macaroon for the specified application.
 func (m *MockPlanClient) AuthorizeReseller(_ context.Context, plan, charm, application, applicationOwner, applicationUser string) (*macaroon.Macaroon, error) {
-	panic("not implemented")
+	m.MethodCall(m, "AuthorizeReseller", plan, charm, application, applicationOwner, applicationUser)
+	return m.AuthorizationMacaroon, m.NextErr()
 }
 
 // GetAuthorizations returns a slice of Authorizations that match the
 // criteria specified in the query.
 func (m *MockPlanClient) GetAuthorizations(_ context.Context, query wireformat.AuthorizationQuery) ([]wireformat.Authorization, error) {
-	panic("not implemented")
+	m.MethodCall(m, "GetAuthorizations", query)
+	return m.Authorizations, m.NextErr()
 }
 
 // GetResellerAuthorizations retuns a slice of reseller Authorizations.
 func (m *MockPlanClient) GetResellerAuthorizations(_ context.Context, query wireformat.ResellerAuthorizationQuery) ([]wireformat.ResellerAuthorization, error) {
-	panic("not implemented")
+	m.MethodCall(m, "GetResellerAuthorizations", query)
+	return m.ResellerAuthorizations, m.NextErr()
 }
 
-var _ api.PlanClient = (*MockPlanClient)(nil)
+// Login records the call in the mock.
+func (m *MockPlanClient) Login(_ context.Context) error {
+	m.MethodCall(m, "Login")
+	return m.NextErr()
+}
+
+var (
+	_ api.PlanClient        = (*MockPlanClient)(nil)
+	_ api.PlanMetadataSaver = (*MockPlanClient)(nil)
+	_ api.Authenticator     = (*MockPlanClient)(nil)
+)